@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"term-workspaces/internal/agent"
+	"term-workspaces/internal/tasks"
+)
+
+// discoverAgentSession records the latest Codex or Claude Code conversation
+// started in the task's cwd since its pane was spawned. Resume is a
+// convenience, so a failed scan is reported and, unless the other agent's
+// logs turned up a conversation, the session keeps whatever it already had.
+func discoverAgentSession(session *tasks.TaskSession) {
+	if session.Cwd == "" {
+		return
+	}
+	since := session.SpawnedAt
+	if since.IsZero() {
+		since = session.CreatedAt
+	}
+	found, ok, err := agent.FindSession(agent.DefaultDirs(), session.Cwd, since)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ttt: agent session discovery for %s: %v\n", session.TaskID, err)
+	}
+	if ok {
+		session.AgentKind = string(found.Kind)
+		session.AgentSessionID = found.ID
+	}
+}
+
+// resumeArgv is the pane program that continues the session's previous
+// agent conversation, or nil when there is nothing to resume.
+func resumeArgv(session tasks.TaskSession) ([]string, error) {
+	if session.AgentSessionID == "" {
+		return nil, nil
+	}
+	return agent.ResumeArgv(agent.Kind(session.AgentKind), session.AgentSessionID)
+}
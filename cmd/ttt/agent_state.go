@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"term-workspaces/internal/tasks"
+	"term-workspaces/internal/terminal"
+)
+
+// shellCommands are foreground programs that mean the agent has exited and
+// left the pane at an interactive shell.
+var shellCommands = map[string]struct{}{
+	"bash": {}, "csh": {}, "dash": {}, "fish": {}, "ksh": {},
+	"login": {}, "nu": {}, "sh": {}, "tcsh": {}, "zsh": {},
+}
+
+// promptSuffixes end the last screen line when a shell is waiting at its
+// prompt. Plain "$", "%" and "#" are left out: agent output and progress
+// lines end with them too often to read as a prompt.
+var promptSuffixes = []string{"❯", "➜"}
+
+// detectAgentState classifies what is running in a live task pane. A shell
+// in the backend's foreground process means the agent has exited; otherwise
+// the pane's screen is read, and a trailing shell prompt (when the backend
+// doesn't report the foreground process) or an attention pattern decides.
+// Without an agent (runsAgent false) a pane at a shell is just idle, and its
+// state is unknown.
+func detectAgentState(ctx context.Context, client terminal.Client, pane terminal.Pane, attention attentionMatcher, runsAgent bool) tasks.AgentState {
+	state := screenAgentState(ctx, client, pane, attention)
+	if state == tasks.AgentStateExitedToShell && !runsAgent {
+		return tasks.AgentStateUnknown
+	}
+	return state
+}
+
+func screenAgentState(ctx context.Context, client terminal.Client, pane terminal.Pane, attention attentionMatcher) tasks.AgentState {
+	if pane.ForegroundProcess != "" && isShellCommand(pane.ForegroundProcess) {
+		return tasks.AgentStateExitedToShell
+	}
+
+	text, err := client.GetText(ctx, pane.PaneID)
+	if err != nil {
+		if pane.ForegroundProcess != "" {
+			return tasks.AgentStateRunning
+		}
+		return tasks.AgentStateUnknown
+	}
+	if pane.ForegroundProcess == "" {
+		if state := agentStateFromScreen(text); state != tasks.AgentStateRunning {
+			return state
+		}
+	}
+	if attention.matches(text) {
+		return tasks.AgentStateWaitingForInput
+	}
+	return tasks.AgentStateRunning
+}
+
+func isShellCommand(command string) bool {
+	// Login shells are reported as "-zsh".
+	name := strings.TrimPrefix(filepath.Base(command), "-")
+	_, ok := shellCommands[name]
+	return ok
+}
+
+func agentStateFromScreen(text string) tasks.AgentState {
+	lines := strings.Split(text, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+		for _, suffix := range promptSuffixes {
+			if strings.HasSuffix(line, suffix) {
+				return tasks.AgentStateExitedToShell
+			}
+		}
+		return tasks.AgentStateRunning
+	}
+	return tasks.AgentStateUnknown
+}
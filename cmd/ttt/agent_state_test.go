@@ -0,0 +1,42 @@
+package main
+
+import (
+	"term-workspaces/internal/tasks"
+	"testing"
+)
+
+func TestAgentStateFromScreen(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]tasks.AgentState{
+		"":                                 tasks.AgentStateUnknown,
+		"\n  \n":                           tasks.AgentStateUnknown,
+		"~/repo ❯":                         tasks.AgentStateExitedToShell,
+		"output\n~/repo main ➜\n\n":        tasks.AgentStateExitedToShell,
+		"me@host ~/repo $ ":                tasks.AgentStateRunning,
+		"Progress: 42%\n":                  tasks.AgentStateRunning,
+		"root@box:/srv# ":                  tasks.AgentStateRunning,
+		"▌ Ask Codex to do anything\n":     tasks.AgentStateRunning,
+		"Thinking… (esc to interrupt)\n\n": tasks.AgentStateRunning,
+	}
+	for screen, expected := range cases {
+		if got := agentStateFromScreen(screen); got != expected {
+			t.Fatalf("agentStateFromScreen(%q) = %q, want %q", screen, got, expected)
+		}
+	}
+}
+
+func TestIsShellCommand(t *testing.T) {
+	t.Parallel()
+
+	for _, command := range []string{"zsh", "-zsh", "/bin/bash", "/opt/homebrew/bin/fish"} {
+		if !isShellCommand(command) {
+			t.Fatalf("expected %q to be a shell", command)
+		}
+	}
+	for _, command := range []string{"codex", "node", "claude", "vim"} {
+		if isShellCommand(command) {
+			t.Fatalf("expected %q not to be a shell", command)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"term-workspaces/internal/config"
+	"term-workspaces/internal/tasks"
+	"time"
+)
+
+// attentionMatcher decides from a pane's screen whether its agent is waiting
+// for the user.
+type attentionMatcher struct {
+	patterns []*regexp.Regexp
+	lines    int
+	profile  func(name string) (config.Profile, bool)
+}
+
+func newAttentionMatcher(cfg config.Config) (attentionMatcher, error) {
+	sources := cfg.AttentionPatterns()
+	patterns := make([]*regexp.Regexp, 0, len(sources))
+	for _, source := range sources {
+		pattern, err := regexp.Compile(source)
+		if err != nil {
+			return attentionMatcher{}, fmt.Errorf("invalid attention pattern %q: %w", source, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return attentionMatcher{patterns: patterns, lines: cfg.AttentionLines(), profile: cfg.Profile}, nil
+}
+
+// runsAgent reports whether the session's profile runs an agent. The pane
+// of an agent-less profile, such as shell, sits at a prompt whenever it is
+// idle, which says nothing about an agent. Profiles no longer configured are
+// assumed to have run one.
+func (m attentionMatcher) runsAgent(session tasks.TaskSession) bool {
+	if m.profile == nil {
+		return true
+	}
+	profile, ok := m.profile(session.Profile)
+	return !ok || profile.Agent != ""
+}
+
+// matches reports whether any of the last non-empty lines of text matches an
+// attention pattern.
+func (m attentionMatcher) matches(text string) bool {
+	lines := strings.Split(text, "\n")
+	checked := 0
+	for i := len(lines) - 1; i >= 0 && checked < m.lines; i-- {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+		checked++
+		for _, pattern := range m.patterns {
+			if pattern.MatchString(line) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// updateAttention stamps when a session started needing attention and clears
+// the stamp once its agent is working again.
+func updateAttention(session *tasks.TaskSession, now time.Time) {
+	if !session.NeedsAttention() {
+		session.AttentionAt = time.Time{}
+		return
+	}
+	if session.AttentionAt.IsZero() {
+		session.AttentionAt = now
+	}
+}
+
+// sortByAttention moves sessions needing attention to the front, longest
+// waiting first, and keeps the existing order otherwise.
+func sortByAttention(sessions []tasks.TaskSession) {
+	sort.SliceStable(sessions, func(i, j int) bool {
+		left, right := sessions[i], sessions[j]
+		if left.NeedsAttention() != right.NeedsAttention() {
+			return left.NeedsAttention()
+		}
+		if left.NeedsAttention() {
+			return left.AttentionAt.Before(right.AttentionAt)
+		}
+		return false
+	})
+}
+
+func runTaskAttention(args []string) error {
+	fs := flag.NewFlagSet("task attention", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	dbPath := fs.String("db", defaultDBPath(), "Path to sqlite database")
+	configPath := fs.String("config", defaultConfigPath(), "Path to JSON config file")
+	jsonOutput := fs.Bool("json", false, "Emit machine-readable JSON")
+	reconcile := fs.Bool("reconcile", false, "Reconcile session health against live terminal panes before output")
+	notesDir := fs.String("notes-dir", defaultNotesDir(), "Directory for task note markdown files")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := tasks.NewSQLiteStore(*dbPath)
+	if err != nil {
+		return fmt.Errorf("open sqlite task store: %w", err)
+	}
+	defer func() {
+		_ = store.Close()
+	}()
+	ctx := context.Background()
+	if *reconcile {
+		cfg, err := config.Load(*configPath)
+		if err != nil {
+			return err
+		}
+		attention, err := newAttentionMatcher(cfg)
+		if err != nil {
+			return err
+		}
+		client, err := newTerminalClient(cfg)
+		if err != nil {
+			return err
+		}
+		if err := reconcileSessionHealth(ctx, store, client, attention, *notesDir, make(domainListings)); err != nil {
+			return fmt.Errorf("reconcile sessions: %w", err)
+		}
+	}
+
+	sessions, err := store.ListSessions(ctx)
+	if err != nil {
+		return fmt.Errorf("list sessions: %w", err)
+	}
+	waiting := make([]tasks.TaskSession, 0, len(sessions))
+	for _, session := range sessions {
+		if session.NeedsAttention() {
+			waiting = append(waiting, session)
+		}
+	}
+	sortByAttention(waiting)
+	if *jsonOutput {
+		return writeJSON(waiting)
+	}
+	if len(waiting) == 0 {
+		fmt.Println("no sessions need attention")
+		return nil
+	}
+
+	fmt.Println("task_id\tagent_state\tattention_at\tpane_id\tworkspace\tcwd")
+	for _, session := range waiting {
+		fmt.Printf("%s\t%s\t%s\t%d\t%s\t%s\n",
+			session.TaskID,
+			session.AgentState,
+			session.AttentionAt.Format(time.RFC3339),
+			session.PaneID,
+			session.Workspace,
+			session.Cwd,
+		)
+	}
+	return nil
+}
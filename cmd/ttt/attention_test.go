@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"term-workspaces/internal/config"
+	"term-workspaces/internal/tasks"
+	"testing"
+	"time"
+)
+
+func TestAttentionMatcherChecksTrailingLines(t *testing.T) {
+	t.Parallel()
+
+	matcher, err := newAttentionMatcher(config.Config{})
+	if err != nil {
+		t.Fatalf("newAttentionMatcher: %v", err)
+	}
+	cases := map[string]bool{
+		"":                               false,
+		"Thinking… (esc to interrupt)\n": false,
+		"Would you like to run the following command?\n  git push\n": true,
+		"Do you want to proceed?\n❯ 1. Yes\n  2. No\n\n":             true,
+		"Overwrite file? [y/N]":                                      true,
+		"Overwrite file (y/n) ":                                      true,
+		"Which branch should I target?\n\n▌ \n":                      true,
+		"Which branch?\n1\n2\n3\n4\n5\n6\n":                          false,
+	}
+	for screen, expected := range cases {
+		if got := matcher.matches(screen); got != expected {
+			t.Fatalf("matches(%q) = %v, want %v", screen, got, expected)
+		}
+	}
+
+	if _, err := newAttentionMatcher(config.Config{Attention: config.AttentionConfig{Patterns: []string{"("}}}); err == nil {
+		t.Fatalf("expected invalid pattern error")
+	}
+}
+
+func TestSortByAttentionPutsLongestWaitingFirst(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().UTC()
+	sessions := []tasks.TaskSession{
+		{TaskID: "busy", Status: tasks.SessionStatusOpen, AgentState: tasks.AgentStateRunning},
+		{TaskID: "recent", Status: tasks.SessionStatusOpen, AgentState: tasks.AgentStateWaitingForInput, AttentionAt: now},
+		{TaskID: "closed", Status: tasks.SessionStatusClosed},
+		{TaskID: "oldest", Status: tasks.SessionStatusOpen, AgentState: tasks.AgentStateExitedToShell, AttentionAt: now.Add(-time.Hour)},
+	}
+	sortByAttention(sessions)
+	order := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		order = append(order, session.TaskID)
+	}
+	if got := strings.Join(order, ","); got != "oldest,recent,busy,closed" {
+		t.Fatalf("unexpected order: %s", got)
+	}
+}
+
+func TestRunTaskAttentionListsWaitingSessions(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	fake := &fakeTerminalClient{nextPaneID: 2200}
+	useFakeTerminal(t, fake)
+
+	for _, branch := range []string{"feature/busy", "feature/approval"} {
+		if _, err := captureStdout(func() error {
+			return run([]string{
+				"task", "open-session",
+				"--repo", "zew1me/term-workspaces",
+				"--branch", branch,
+				"--db", dbPath,
+			})
+		}); err != nil {
+			t.Fatalf("open-session %s failed: %v", branch, err)
+		}
+	}
+
+	out, err := captureStdout(func() error {
+		return run([]string{"task", "attention", "--db", dbPath, "--reconcile"})
+	})
+	if err != nil {
+		t.Fatalf("task attention failed: %v", err)
+	}
+	if strings.TrimSpace(out) != "no sessions need attention" {
+		t.Fatalf("expected nothing waiting, got %q", out)
+	}
+
+	fake.panes[0].ForegroundProcess = "codex"
+	fake.panes[1].ForegroundProcess = "codex"
+	fake.screens = map[int64]string{
+		2200: "Thinking… (esc to interrupt)\n",
+		2201: "Would you like to run the following command?\n  go test ./...\n▌ 1. Yes  2. No\n",
+	}
+	out, err = captureStdout(func() error {
+		return run([]string{"task", "attention", "--db", dbPath, "--reconcile", "--json"})
+	})
+	if err != nil {
+		t.Fatalf("task attention --json failed: %v", err)
+	}
+	var waiting []tasks.TaskSession
+	if err := json.Unmarshal([]byte(out), &waiting); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v (%q)", err, out)
+	}
+	if len(waiting) != 1 || waiting[0].PaneID != 2201 || waiting[0].AgentState != tasks.AgentStateWaitingForInput || waiting[0].AttentionAt.IsZero() {
+		t.Fatalf("expected approval pane waiting for input, got %#v", waiting)
+	}
+	firstSeen := waiting[0].AttentionAt
+
+	store, err := tasks.NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	model, err := buildUIModelFromStore(context.Background(), store)
+	_ = store.Close()
+	if err != nil {
+		t.Fatalf("buildUIModelFromStore: %v", err)
+	}
+	view := model.SelectTab(1).View()
+	waitingRow := strings.Index(view, "[!] task="+waiting[0].TaskID)
+	busyRow := strings.Index(view, "pane=2200 agent=running")
+	if waitingRow < 0 || busyRow < 0 || waitingRow > busyRow {
+		t.Fatalf("expected waiting session listed first in Open Sessions tab: %q", view)
+	}
+
+	// The timestamp marks when waiting started, not the latest poll.
+	if _, err := captureStdout(func() error {
+		return run([]string{"task", "sessions", "--db", dbPath, "--reconcile"})
+	}); err != nil {
+		t.Fatalf("task sessions --reconcile failed: %v", err)
+	}
+	out, err = captureStdout(func() error {
+		return run([]string{"task", "attention", "--db", dbPath, "--json"})
+	})
+	if err != nil {
+		t.Fatalf("task attention --json failed: %v", err)
+	}
+	if err := json.Unmarshal([]byte(out), &waiting); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v (%q)", err, out)
+	}
+	if len(waiting) != 1 || !waiting[0].AttentionAt.Equal(firstSeen) {
+		t.Fatalf("expected attention_at to stay at %s, got %#v", firstSeen, waiting)
+	}
+
+	// Answering the prompt clears the attention state.
+	fake.screens[2201] = "Running go test ./...\n"
+	out, err = captureStdout(func() error {
+		return run([]string{"task", "attention", "--db", dbPath, "--reconcile"})
+	})
+	if err != nil {
+		t.Fatalf("task attention failed: %v", err)
+	}
+	if strings.TrimSpace(out) != "no sessions need attention" {
+		t.Fatalf("expected attention cleared, got %q", out)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"term-workspaces/internal/github"
+	"term-workspaces/internal/tasks"
+	"term-workspaces/internal/terminal"
+	"time"
+)
+
+// prTitleTimeout bounds the `gh` call so a slow network doesn't hold up
+// opening a session.
+const prTitleTimeout = 10 * time.Second
+
+// A send-text prompt is typed once the freshly spawned agent is reading its
+// input; the pane's screen is polled for that and given up on after
+// agentReadyTimeout.
+var (
+	agentReadyPoll    = 250 * time.Millisecond
+	agentReadyTimeout = 30 * time.Second
+)
+
+type prTitleFetcher interface {
+	PRTitle(ctx context.Context, repo string, number int) (string, error)
+}
+
+var newPRTitleFetcher = func() prTitleFetcher {
+	return github.NewCLIClient()
+}
+
+// fillTaskAliases completes a branch and PR number, as given on the command
+// line, from the task's aliases when only one of them was given.
+func fillTaskAliases(ctx context.Context, store *tasks.SQLiteStore, taskID string, branch *string, prNumber *int) error {
+	if *branch != "" && *prNumber > 0 {
+		return nil
+	}
+	rows, err := store.ListTaskAliasRows(ctx)
+	if err != nil {
+		return fmt.Errorf("list task aliases: %w", err)
+	}
+	for _, row := range rows {
+		if row.TaskID != taskID {
+			continue
+		}
+		if *branch == "" && row.Branch != "" {
+			*branch = row.Branch
+		}
+		if *prNumber <= 0 && row.AliasType == tasks.AliasTypePR {
+			*prNumber = row.PRNumber
+		}
+	}
+	return nil
+}
+
+// lookupPRTitle asks `gh` for a PR title. Failures are reported and yield an
+// empty title, since the title is only ever a nicety.
+func lookupPRTitle(ctx context.Context, repo string, prNumber int) string {
+	titleCtx, cancel := context.WithTimeout(ctx, prTitleTimeout)
+	defer cancel()
+	title, err := newPRTitleFetcher().PRTitle(titleCtx, repo, prNumber)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ttt: PR title unavailable: %v\n", err)
+	}
+	return title
+}
+
+// loadTaskContext adds the task note's objective, next actions and blockers
+// and the PR title to data, and renders data.Context from them. A missing
+// note or an unavailable `gh` leaves those parts out.
+func loadTaskContext(ctx context.Context, notesDir string, data *promptData) error {
+	// #nosec G304 -- the note path is derived from the notes dir and task ID.
+	notePath := tasks.NotePath(notesDir, data.TaskID)
+	content, err := os.ReadFile(notePath)
+	switch {
+	case err == nil:
+		data.NotePath = notePath
+		sections := tasks.NoteSections(string(content))
+		data.Objective = sections[tasks.NoteSectionObjective]
+		data.NextActions = sections[tasks.NoteSectionNextActions]
+		data.Blockers = sections[tasks.NoteSectionBlockers]
+	case !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("read task note: %w", err)
+	}
+
+	if data.PRNumber > 0 {
+		data.PRTitle = lookupPRTitle(ctx, data.Repo, data.PRNumber)
+	}
+
+	data.Context = renderTaskContext(*data)
+	return nil
+}
+
+// renderTaskContext is the bootstrap prompt for a fresh agent. It is empty
+// when the note has nothing filled in and the PR title is unknown, so the
+// agent then starts without a prompt.
+func renderTaskContext(data promptData) string {
+	if data.Objective == "" && data.NextActions == "" && data.Blockers == "" && data.PRTitle == "" {
+		return ""
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "You are resuming work on task %s in %s", data.TaskID, data.Repo)
+	if data.Branch != "" {
+		fmt.Fprintf(&prompt, " on branch %s", data.Branch)
+	}
+	switch {
+	case data.PRNumber > 0 && data.PRTitle != "":
+		fmt.Fprintf(&prompt, " (PR #%d: %s)", data.PRNumber, data.PRTitle)
+	case data.PRNumber > 0:
+		fmt.Fprintf(&prompt, " (PR #%d)", data.PRNumber)
+	}
+	prompt.WriteString(".\n")
+
+	for _, section := range []struct {
+		title string
+		body  string
+	}{
+		{tasks.NoteSectionObjective, data.Objective},
+		{tasks.NoteSectionNextActions, data.NextActions},
+		{tasks.NoteSectionBlockers, data.Blockers},
+	} {
+		if section.body == "" {
+			continue
+		}
+		fmt.Fprintf(&prompt, "\n%s:\n%s\n", section.title, section.body)
+	}
+	if data.NotePath != "" {
+		fmt.Fprintf(&prompt, "\nThe task note is %s; keep it up to date as you work.", data.NotePath)
+	}
+	return prompt.String()
+}
+
+// waitForAgentReady polls paneID's screen until the agent in it looks ready
+// for input: ready matches the screen or, without a pattern, the screen
+// shows something and is unchanged between two polls.
+func waitForAgentReady(ctx context.Context, client terminal.Client, paneID int64, ready *regexp.Regexp) error {
+	ctx, cancel := context.WithTimeout(ctx, agentReadyTimeout)
+	defer cancel()
+	previous := ""
+	for {
+		text, err := client.GetText(ctx, paneID)
+		if err != nil {
+			return err
+		}
+		switch {
+		case ready != nil:
+			if ready.MatchString(text) {
+				return nil
+			}
+		case strings.TrimSpace(text) != "" && text == previous:
+			return nil
+		}
+		previous = text
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: pane %d not ready after %s", terminal.ErrTimeout, paneID, agentReadyTimeout)
+		case <-time.After(agentReadyPoll):
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"term-workspaces/internal/terminal"
+	"testing"
+)
+
+func TestRenderTaskContextSkipsEmptyNote(t *testing.T) {
+	t.Parallel()
+
+	if got := renderTaskContext(promptData{TaskID: "task-1", Repo: "owner/repo", Branch: "main"}); got != "" {
+		t.Fatalf("expected no context for an empty note, got %q", got)
+	}
+
+	got := renderTaskContext(promptData{
+		TaskID:    "task-1",
+		Repo:      "owner/repo",
+		Branch:    "feature/x",
+		PRNumber:  7,
+		Objective: "Ship it.",
+		Blockers:  "CI is red.",
+		NotePath:  "/notes/task-1.md",
+	})
+	expected := "You are resuming work on task task-1 in owner/repo on branch feature/x (PR #7).\n" +
+		"\nCurrent Objective:\nShip it.\n" +
+		"\nBlockers:\nCI is red.\n" +
+		"\nThe task note is /notes/task-1.md; keep it up to date as you work."
+	if got != expected {
+		t.Fatalf("unexpected context:\n%s", got)
+	}
+}
+
+func TestRunTaskOpenSessionBootstrapsAgentFromNote(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	notesDir := t.TempDir()
+	configPath := t.TempDir() + "/config.json"
+	if err := os.WriteFile(configPath, []byte(`{"profiles": {"typed": {"argv": ["claude"], "agent": "claude", "prompt_mode": "send-text", "ready_pattern": "(?m)^> $"}}}`), 0o600); err != nil {
+		t.Fatalf("WriteFile config: %v", err)
+	}
+	fake := &fakeTerminalClient{nextPaneID: 2300}
+	useFakeTerminal(t, fake)
+	usePRTitles(t, fakePRTitles{88: "Teach agents about their task"})
+
+	out, err := captureStdout(func() error {
+		return run([]string{"task", "ensure-prepr", "--repo", "zew1me/term-workspaces", "--branch", "feature/bootstrap", "--db", dbPath})
+	})
+	if err != nil {
+		t.Fatalf("ensure-prepr failed: %v", err)
+	}
+	if _, err := captureStdout(func() error {
+		return run([]string{"task", "link-pr", "--repo", "zew1me/term-workspaces", "--branch", "feature/bootstrap", "--pr", "88", "--db", dbPath})
+	}); err != nil {
+		t.Fatalf("link-pr failed: %v", err)
+	}
+
+	// The PR number comes from the task's aliases; only the branch is given.
+	target := []string{"--repo", "zew1me/term-workspaces", "--branch", "feature/bootstrap", "--db", dbPath}
+	out, err = captureStdout(func() error {
+		return run(append([]string{"task", "ensure-note", "--notes-dir", notesDir}, target...))
+	})
+	if err != nil {
+		t.Fatalf("ensure-note failed: %v", err)
+	}
+	notePath := parseKVLine(t, out)["note_path"]
+	note := "# Task State\n\n## Current Objective\nBootstrap agents.\n\n## Next Actions\n- render the prompt\n\n## Blockers\n"
+	if err := os.WriteFile(notePath, []byte(note), 0o600); err != nil {
+		t.Fatalf("WriteFile note: %v", err)
+	}
+
+	openArgs := append([]string{"task", "open-session", "--notes-dir", notesDir, "--config", configPath, "--profile", "codex"}, target...)
+	out, err = captureStdout(func() error { return run(openArgs) })
+	if err != nil {
+		t.Fatalf("open-session failed: %v", err)
+	}
+	if fields := parseKVLine(t, out); fields["prompt"] != "arg" {
+		t.Fatalf("expected prompt=arg, got %q", out)
+	}
+	argv := fake.spawnOpts[0].Argv
+	if len(argv) != 2 || argv[0] != "codex" {
+		t.Fatalf("expected codex plus prompt, got %#v", argv)
+	}
+	for _, want := range []string{"branch feature/bootstrap (PR #88: Teach agents about their task)", "Current Objective:\nBootstrap agents.", "Next Actions:\n- render the prompt", notePath} {
+		if !strings.Contains(argv[1], want) {
+			t.Fatalf("expected prompt to contain %q, got %q", want, argv[1])
+		}
+	}
+	if strings.Contains(argv[1], "Blockers") {
+		t.Fatalf("expected empty blockers section to be left out: %q", argv[1])
+	}
+
+	// --no-context starts the agent bare.
+	fake.panes = nil
+	if _, err := captureStdout(func() error { return run(append(openArgs, "--no-context")) }); err != nil {
+		t.Fatalf("open-session --no-context failed: %v", err)
+	}
+	if got := fake.spawnOpts[1].Argv; !reflect.DeepEqual(got, []string{"codex"}) {
+		t.Fatalf("expected bare codex argv, got %#v", got)
+	}
+
+	// A send-text profile types the prompt into the new pane instead, once
+	// the agent shows its input line.
+	fake.panes = nil
+	fake.screens = map[int64]string{2302: "Welcome to Claude\n> \n"}
+	sendArgs := append([]string{"task", "open-session", "--notes-dir", notesDir, "--config", configPath, "--profile", "typed"}, target...)
+	out, err = captureStdout(func() error { return run(sendArgs) })
+	if err != nil {
+		t.Fatalf("open-session send-text failed: %v", err)
+	}
+	fields := parseKVLine(t, out)
+	if fields["prompt"] != "send-text" {
+		t.Fatalf("expected prompt=send-text, got %q", out)
+	}
+	if got := fake.spawnOpts[2].Argv; !reflect.DeepEqual(got, []string{"claude"}) {
+		t.Fatalf("expected bare claude argv, got %#v", got)
+	}
+	sent := fake.sentText[2302]
+	if len(sent) != 1 || !strings.Contains(sent[0], "Bootstrap agents.") {
+		t.Fatalf("expected prompt sent to pane 2302, got %#v", fake.sentText)
+	}
+}
+
+func TestWaitForAgentReady(t *testing.T) {
+	fake := &fakeTerminalClient{screens: map[int64]string{1: "Welcome\n> \n", 2: "", 3: "starting\n"}}
+	useFakeTerminal(t, fake)
+	ctx := context.Background()
+
+	if err := waitForAgentReady(ctx, fake, 1, regexp.MustCompile(`(?m)^> $`)); err != nil {
+		t.Fatalf("expected pattern match, got %v", err)
+	}
+	if err := waitForAgentReady(ctx, fake, 3, regexp.MustCompile(`(?m)^> $`)); !errors.Is(err, terminal.ErrTimeout) {
+		t.Fatalf("expected timeout waiting for the pattern, got %v", err)
+	}
+	if err := waitForAgentReady(ctx, fake, 3, nil); err != nil {
+		t.Fatalf("expected a settled screen to count as ready, got %v", err)
+	}
+	if err := waitForAgentReady(ctx, fake, 2, nil); !errors.Is(err, terminal.ErrTimeout) {
+		t.Fatalf("expected timeout on a blank screen, got %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"term-workspaces/internal/config"
+	"term-workspaces/internal/git"
+	"term-workspaces/internal/tasks"
+)
+
+type gitClient interface {
+	IsTracked(ctx context.Context, dir, name string) (bool, error)
+	Exclude(ctx context.Context, dir, name string) (bool, error)
+}
+
+var newGitClient = func() gitClient {
+	return git.NewCLIClient()
+}
+
+// contextSyncResult describes one context file refresh.
+type contextSyncResult struct {
+	Path    string
+	Changed bool
+	// Excluded is false when the directory is not in a git work tree.
+	Excluded bool
+}
+
+func runTaskContext(args []string) error {
+	if len(args) == 0 || args[0] != "sync" {
+		return printTaskUsage()
+	}
+	return runTaskContextSync(args[1:])
+}
+
+// refreshContextFile is open-session's context sync. Failures are reported
+// but never keep a session from opening. A session in a remote domain is
+// skipped: its cwd names a directory on the remote host.
+func refreshContextFile(ctx context.Context, store *tasks.SQLiteStore, cfg config.Config, notesDir, taskID, dir, domain string) {
+	if cfg.ContextFile() == "" || remoteDomain(domain) {
+		return
+	}
+	if _, err := syncTaskContext(ctx, store, cfg, notesDir, taskID, dir); err != nil {
+		fmt.Fprintf(os.Stderr, "ttt: context file not synced: %v\n", err)
+	}
+}
+
+// remoteDomain reports whether domain names a multiplexer domain other than
+// the local one.
+func remoteDomain(domain string) bool {
+	domain = strings.TrimSpace(domain)
+	return domain != "" && domain != "local"
+}
+
+func runTaskContextSync(args []string) error {
+	fs := flag.NewFlagSet("task context sync", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	repo := fs.String("repo", "", "GitHub repository in owner/repo format")
+	branch := fs.String("branch", "", "Branch name (optional when using --pr)")
+	prNumber := fs.Int("pr", 0, "Pull request number (optional when using --branch)")
+	dbPath := fs.String("db", defaultDBPath(), "Path to sqlite database")
+	configPath := fs.String("config", defaultConfigPath(), "Path to JSON config file")
+	notesDir := fs.String("notes-dir", defaultNotesDir(), "Directory for task note markdown files")
+	cwd := fs.String("cwd", "", "Directory to write the context file into (default: the session cwd)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *repo == "" {
+		return fmt.Errorf("--repo is required")
+	}
+	if *branch == "" && *prNumber <= 0 {
+		return fmt.Errorf("one of --branch or --pr is required")
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+	if cfg.ContextFile() == "" {
+		return fmt.Errorf(`context files are off; set "context": {"file": "AGENTS.md"} in the config to write them`)
+	}
+
+	store, err := tasks.NewSQLiteStore(*dbPath)
+	if err != nil {
+		return fmt.Errorf("open sqlite task store: %w", err)
+	}
+	defer func() {
+		_ = store.Close()
+	}()
+
+	ctx := context.Background()
+	service := tasks.NewService(store)
+	task, err := resolveTaskForNote(ctx, service, *repo, *branch, *prNumber)
+	if err != nil {
+		return err
+	}
+
+	dir := *cwd
+	if dir == "" {
+		session, found, err := store.GetSessionByTaskID(ctx, task.ID)
+		if err != nil {
+			return fmt.Errorf("load existing session: %w", err)
+		}
+		dir = "."
+		if found && session.Cwd != "" {
+			dir = session.Cwd
+		}
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("resolve --cwd: %w", err)
+	}
+
+	result, err := syncTaskContext(ctx, store, cfg, *notesDir, task.ID, dir)
+	if err != nil {
+		return err
+	}
+	status := "unchanged"
+	if result.Changed {
+		status = "synced"
+	}
+	fmt.Printf("task_id=%s status=%s path=%s excluded=%t\n", task.ID, status, result.Path, result.Excluded)
+	return nil
+}
+
+// syncTaskContext renders the task's context file into dir and keeps it out
+// of git. A file git already tracks, or one with content but no generated
+// block, is neither written nor excluded.
+func syncTaskContext(ctx context.Context, store *tasks.SQLiteStore, cfg config.Config, notesDir, taskID, dir string) (contextSyncResult, error) {
+	name := cfg.ContextFile()
+	if name != filepath.Base(name) || name == "." || name == ".." {
+		return contextSyncResult{}, fmt.Errorf("context file %q must be a plain file name", name)
+	}
+	path := filepath.Join(dir, name)
+
+	gitCLI := newGitClient()
+	inRepo := true
+	tracked, err := gitCLI.IsTracked(ctx, dir, name)
+	switch {
+	case errors.Is(err, git.ErrNotRepository):
+		inRepo = false
+	case err != nil:
+		return contextSyncResult{}, err
+	case tracked:
+		return contextSyncResult{}, fmt.Errorf("%s is tracked by git; set context.file to an untracked name", path)
+	}
+
+	aliases, err := store.ListTaskAliasRows(ctx)
+	if err != nil {
+		return contextSyncResult{}, fmt.Errorf("list task aliases: %w", err)
+	}
+	taskContext := tasks.TaskContext{TaskID: taskID}
+	for _, alias := range aliases {
+		if alias.TaskID == taskID {
+			taskContext.Aliases = append(taskContext.Aliases, alias)
+		}
+	}
+	notePath := tasks.NotePath(notesDir, taskID)
+	// #nosec G304 -- the note path is derived from the notes dir and task ID.
+	content, err := os.ReadFile(notePath)
+	switch {
+	case err == nil:
+		taskContext.NotePath = notePath
+		taskContext.Sections = tasks.NoteSections(string(content))
+	case !errors.Is(err, os.ErrNotExist):
+		return contextSyncResult{}, fmt.Errorf("read task note: %w", err)
+	}
+
+	changed, err := tasks.WriteContextFile(path, tasks.RenderTaskContext(taskContext))
+	switch {
+	case errors.Is(err, tasks.ErrForeignContextFile):
+		return contextSyncResult{}, fmt.Errorf("%w; set context.file to another name, or add the ttt:task-context markers where the block should go", err)
+	case err != nil:
+		return contextSyncResult{}, err
+	}
+	result := contextSyncResult{Path: path, Changed: changed}
+	if inRepo {
+		if _, err := gitCLI.Exclude(ctx, dir, name); err != nil {
+			return contextSyncResult{}, err
+		}
+		result.Excluded = true
+	}
+	return result, nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunTaskContextSyncWritesExcludedContextFile(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dbPath := t.TempDir() + "/state.db"
+	notesDir := t.TempDir()
+	workDir := t.TempDir()
+	if output, err := exec.Command("git", "init", "-q", workDir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v (%s)", err, output)
+	}
+	fake := &fakeTerminalClient{nextPaneID: 2400}
+	useFakeTerminal(t, fake)
+
+	target := []string{"--repo", "zew1me/term-workspaces", "--branch", "feature/context", "--pr", "91", "--db", dbPath, "--notes-dir", notesDir}
+	out, err := captureStdout(func() error { return run(append([]string{"task", "ensure-note"}, target...)) })
+	if err != nil {
+		t.Fatalf("ensure-note failed: %v", err)
+	}
+	notePath := parseKVLine(t, out)["note_path"]
+	if err := os.WriteFile(notePath, []byte("# Task State\n\n## Current Objective\nWrite context files.\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile note: %v", err)
+	}
+
+	// Context files are off until a file name is configured.
+	err = run(append([]string{"task", "context", "sync", "--cwd", workDir}, target...))
+	if err == nil || !strings.Contains(err.Error(), "context files are off") {
+		t.Fatalf("expected context files to be off by default, got %v", err)
+	}
+	configPath := writeContextConfig(t)
+	target = append(target, "--config", configPath)
+
+	out, err = captureStdout(func() error {
+		return run(append([]string{"task", "context", "sync", "--cwd", workDir}, target...))
+	})
+	if err != nil {
+		t.Fatalf("context sync failed: %v", err)
+	}
+	fields := parseKVLine(t, out)
+	contextPath := filepath.Join(workDir, "AGENTS.md")
+	if fields["status"] != "synced" || fields["path"] != contextPath || fields["excluded"] != "true" {
+		t.Fatalf("unexpected sync output: %q", out)
+	}
+	// #nosec G304 -- path is inside t.TempDir.
+	content, err := os.ReadFile(contextPath)
+	if err != nil {
+		t.Fatalf("ReadFile context: %v", err)
+	}
+	for _, want := range []string{"- Task: `" + fields["task_id"] + "`", "https://github.com/zew1me/term-workspaces/pull/91", "Write context files."} {
+		if !strings.Contains(string(content), want) {
+			t.Fatalf("expected context file to contain %q:\n%s", want, content)
+		}
+	}
+	status, err := exec.Command("git", "-C", workDir, "status", "--porcelain").CombinedOutput()
+	if err != nil {
+		t.Fatalf("git status: %v (%s)", err, status)
+	}
+	if strings.TrimSpace(string(status)) != "" {
+		t.Fatalf("expected context file to be ignored by git, got %q", status)
+	}
+
+	// open-session refreshes the file with the latest note.
+	if err := os.WriteFile(notePath, []byte("# Task State\n\n## Current Objective\nRefresh on open.\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile note: %v", err)
+	}
+	if _, err := captureStdout(func() error {
+		return run(append([]string{"task", "open-session", "--cwd", workDir}, target...))
+	}); err != nil {
+		t.Fatalf("open-session failed: %v", err)
+	}
+	// #nosec G304 -- path is inside t.TempDir.
+	content, err = os.ReadFile(contextPath)
+	if err != nil {
+		t.Fatalf("ReadFile context: %v", err)
+	}
+	if !strings.Contains(string(content), "Refresh on open.") || strings.Contains(string(content), "Write context files.") {
+		t.Fatalf("expected refreshed context file:\n%s", content)
+	}
+
+	// A session in a remote domain has a cwd on the remote host, so the
+	// local directory of the same name is not written.
+	remote := []string{"--repo", "zew1me/term-workspaces", "--branch", "feature/remote", "--db", dbPath, "--notes-dir", notesDir, "--config", configPath}
+	out, err = captureStdout(func() error {
+		return run(append([]string{"task", "open-session", "--cwd", workDir, "--domain", "SSH:devbox"}, remote...))
+	})
+	if err != nil {
+		t.Fatalf("open-session in remote domain failed: %v", err)
+	}
+	remoteTaskID := parseKVLine(t, out)["task_id"]
+	// #nosec G304 -- path is inside t.TempDir.
+	content, err = os.ReadFile(contextPath)
+	if err != nil {
+		t.Fatalf("ReadFile context: %v", err)
+	}
+	if remoteTaskID == "" || strings.Contains(string(content), remoteTaskID) {
+		t.Fatalf("expected remote session %q not to write the local file:\n%s", remoteTaskID, content)
+	}
+
+	// A tracked file of the same name is left alone.
+	if output, err := exec.Command("git", "-C", workDir, "add", "-f", "AGENTS.md").CombinedOutput(); err != nil {
+		t.Fatalf("git add: %v (%s)", err, output)
+	}
+	err = run(append([]string{"task", "context", "sync", "--cwd", workDir}, target...))
+	if err == nil || !strings.Contains(err.Error(), "tracked by git") {
+		t.Fatalf("expected tracked file error, got %v", err)
+	}
+}
+
+func TestRunTaskContextSyncLeavesHandWrittenFileAlone(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dbPath := t.TempDir() + "/state.db"
+	workDir := t.TempDir()
+	if output, err := exec.Command("git", "init", "-q", workDir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v (%s)", err, output)
+	}
+	contextPath := filepath.Join(workDir, "AGENTS.md")
+	if err := os.WriteFile(contextPath, []byte("# House rules\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile context: %v", err)
+	}
+	fake := &fakeTerminalClient{nextPaneID: 2500}
+	useFakeTerminal(t, fake)
+
+	target := []string{"--repo", "zew1me/term-workspaces", "--branch", "feature/context", "--db", dbPath, "--notes-dir", t.TempDir(), "--config", writeContextConfig(t)}
+	err := run(append([]string{"task", "context", "sync", "--cwd", workDir}, target...))
+	if err == nil || !strings.Contains(err.Error(), "no ttt task-context block") {
+		t.Fatalf("expected hand-written file error, got %v", err)
+	}
+	// #nosec G304 -- path is inside t.TempDir.
+	content, err := os.ReadFile(contextPath)
+	if err != nil {
+		t.Fatalf("ReadFile context: %v", err)
+	}
+	if string(content) != "# House rules\n" {
+		t.Fatalf("expected file to be untouched:\n%s", content)
+	}
+	status, err := exec.Command("git", "-C", workDir, "status", "--porcelain").CombinedOutput()
+	if err != nil {
+		t.Fatalf("git status: %v (%s)", err, status)
+	}
+	if !strings.Contains(string(status), "AGENTS.md") {
+		t.Fatalf("expected hand-written file not to be excluded, got %q", status)
+	}
+}
+
+// writeContextConfig writes a config that turns AGENTS.md context files on.
+func writeContextConfig(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"context": {"file": "AGENTS.md"}}`), 0o600); err != nil {
+		t.Fatalf("WriteFile config: %v", err)
+	}
+	return path
+}
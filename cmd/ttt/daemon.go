@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"term-workspaces/internal/config"
+	"term-workspaces/internal/tasks"
+	"term-workspaces/internal/terminal"
+	"time"
+)
+
+var errDaemonRunning = errors.New("daemon already running")
+
+type daemonOptions struct {
+	Interval time.Duration
+	Jitter   time.Duration
+	// Attention flags panes waiting for the user on each poll.
+	Attention attentionMatcher
+	// Ticks stops the loop after that many polls; zero runs until ctx ends.
+	Ticks int
+	// NotesDir is where sessions found dead are logged; empty skips it.
+	NotesDir string
+}
+
+func runDaemon(args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	dbPath := fs.String("db", defaultDBPath(), "Path to sqlite database")
+	configPath := fs.String("config", defaultConfigPath(), "Path to JSON config file")
+	interval := fs.Duration("interval", 30*time.Second, "Time between reconcile polls")
+	jitter := fs.Duration("jitter", 5*time.Second, "Maximum random delay added to each interval")
+	ticks := fs.Int("ticks", 0, "Stop after this many polls (0 runs until SIGTERM/SIGINT)")
+	notesDir := fs.String("notes-dir", defaultNotesDir(), "Directory for task note markdown files")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	if *jitter < 0 {
+		return fmt.Errorf("--jitter must not be negative")
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+	attention, err := newAttentionMatcher(cfg)
+	if err != nil {
+		return err
+	}
+	client, err := newTerminalClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	lockPath := daemonLockPath(*dbPath)
+	lock, err := acquireDaemonLock(lockPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = lock.Close()
+	}()
+
+	store, err := tasks.NewSQLiteStore(*dbPath)
+	if err != nil {
+		return fmt.Errorf("open sqlite task store: %w", err)
+	}
+	defer func() {
+		_ = store.Close()
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("status=started pid=%d lock=%s interval=%s jitter=%s\n", os.Getpid(), lockPath, *interval, *jitter)
+	polls := runDaemonLoop(ctx, store, client, daemonOptions{Interval: *interval, Jitter: *jitter, Ticks: *ticks, Attention: attention, NotesDir: *notesDir})
+	fmt.Printf("status=stopped polls=%d\n", polls)
+	return nil
+}
+
+// runDaemonLoop reconciles session health until ctx is cancelled or the tick
+// budget runs out, and returns the number of polls made. Reconcile failures
+// are logged and retried on the next tick rather than stopping the daemon.
+// Remote domain listings are kept across ticks.
+func runDaemonLoop(ctx context.Context, store *tasks.SQLiteStore, client terminal.Client, opts daemonOptions) int {
+	polls := 0
+	domains := make(domainListings)
+	for {
+		if err := reconcileSessionHealth(ctx, store, client, opts.Attention, opts.NotesDir, domains); err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "ttt daemon: reconcile failed: %v\n", err)
+		}
+		polls++
+		if opts.Ticks > 0 && polls >= opts.Ticks {
+			return polls
+		}
+
+		timer := time.NewTimer(daemonDelay(opts))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return polls
+		case <-timer.C:
+		}
+	}
+}
+
+// daemonDelay spreads polls so several daemons (or a daemon and an interactive
+// reconcile) don't hit the multiplexer in lockstep.
+func daemonDelay(opts daemonOptions) time.Duration {
+	if opts.Jitter <= 0 {
+		return opts.Interval
+	}
+	return opts.Interval + rand.N(opts.Jitter)
+}
+
+func daemonLockPath(dbPath string) string {
+	return dbPath + ".daemon.lock"
+}
+
+// acquireDaemonLock takes an exclusive flock on path and records the owning
+// pid in it. The lock is released when the returned file is closed or the
+// process exits, so a crashed daemon never leaves a stale lock behind.
+func acquireDaemonLock(path string) (*os.File, error) {
+	// #nosec G304 -- the lock path is derived from the user-selected db path.
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open daemon lock: %w", err)
+	}
+	// #nosec G115 -- file descriptors fit in an int on supported platforms.
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		owner, _ := os.ReadFile(path) // #nosec G304 -- same lock path as above.
+		_ = file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, fmt.Errorf("%w (pid %s, lock %s)", errDaemonRunning, strings.TrimSpace(string(owner)), path)
+		}
+		return nil, fmt.Errorf("lock daemon lock file: %w", err)
+	}
+	if err := file.Truncate(0); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("truncate daemon lock: %w", err)
+	}
+	if _, err := file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("write daemon pid: %w", err)
+	}
+	return file, nil
+}
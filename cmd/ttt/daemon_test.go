@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"syscall"
+	"term-workspaces/internal/tasks"
+	"term-workspaces/internal/terminal"
+	"testing"
+	"time"
+)
+
+func TestRunDaemonRecordsSessionTransitions(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	fake := &fakeTerminalClient{nextPaneID: 1500}
+	useFakeTerminal(t, fake)
+
+	if _, err := captureStdout(func() error {
+		return run([]string{
+			"task", "open-session",
+			"--repo", "zew1me/term-workspaces",
+			"--branch", "feature/daemon",
+			"--db", dbPath,
+		})
+	}); err != nil {
+		t.Fatalf("open-session failed: %v", err)
+	}
+
+	// The pane disappears between polls.
+	fake.onList = func(calls int) {
+		if calls == 2 {
+			fake.panes = nil
+		}
+	}
+	out, err := captureStdout(func() error {
+		return run([]string{"daemon", "--db", dbPath, "--interval", "1ms", "--jitter", "0s", "--ticks", "2"})
+	})
+	if err != nil {
+		t.Fatalf("daemon failed: %v", err)
+	}
+	if !strings.Contains(out, "status=stopped polls=2") {
+		t.Fatalf("unexpected daemon output: %q", out)
+	}
+
+	store, err := tasks.NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	defer func() {
+		_ = store.Close()
+	}()
+	events, err := store.ListEvents(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	transitions := make([]string, 0, len(events))
+	for _, event := range events {
+		transitions = append(transitions, event.From+"->"+event.To+" "+event.Detail)
+	}
+	expected := []string{"->open spawned", "open->closed pane missing"}
+	if strings.Join(transitions, "|") != strings.Join(expected, "|") {
+		t.Fatalf("unexpected transitions: %#v", transitions)
+	}
+}
+
+func TestRunDaemonReusesRemoteDomainListings(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	fake := &fakeTerminalClient{nextPaneID: 1550}
+	useFakeTerminal(t, fake)
+
+	out, err := captureStdout(func() error {
+		return run([]string{
+			"task", "open-session",
+			"--repo", "zew1me/term-workspaces",
+			"--branch", "feature/remote-daemon",
+			"--db", dbPath,
+			"--domain", "SSHMUX:devbox",
+		})
+	})
+	if err != nil {
+		t.Fatalf("open-session failed: %v", err)
+	}
+	taskID := parseKVLine(t, out)["task_id"]
+
+	// The domain is detached, so every tick has to ask its host.
+	fake.panes = nil
+	fake.domainPanes = map[string][]terminal.Pane{
+		"SSHMUX:devbox": {{PaneID: 3, UserVars: map[string]string{terminal.TaskIDUserVar: taskID}}},
+	}
+	if _, err := captureStdout(func() error {
+		return run([]string{"daemon", "--db", dbPath, "--interval", "1ms", "--jitter", "0s", "--ticks", "3"})
+	}); err != nil {
+		t.Fatalf("daemon failed: %v", err)
+	}
+	if fake.domainCalls != 1 {
+		t.Fatalf("expected one remote listing across ticks, got %d", fake.domainCalls)
+	}
+}
+
+func TestDomainMayHoldSessionRefreshesListingOlderThanSpawn(t *testing.T) {
+	fake := &fakeTerminalClient{domainPanes: map[string][]terminal.Pane{
+		"SSHMUX:devbox": {{PaneID: 3, UserVars: map[string]string{terminal.TaskIDUserVar: "task_new"}}},
+	}}
+	listedAt := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	// The cached listing predates the session's pane.
+	cache := domainListings{"SSHMUX:devbox": {panes: []terminal.Pane{}, at: listedAt}}
+	session := tasks.TaskSession{TaskID: "task_new", Domain: "SSHMUX:devbox", PaneTagged: true, SpawnedAt: listedAt.Add(10 * time.Second)}
+
+	if !domainMayHoldSession(context.Background(), fake, cache, session, listedAt.Add(20*time.Second)) {
+		t.Fatalf("expected the fresh listing to hold the session")
+	}
+	if fake.domainCalls != 1 {
+		t.Fatalf("expected the stale listing to be refreshed, got %d calls", fake.domainCalls)
+	}
+	// A listing taken after the spawn is reused within the TTL.
+	if !domainMayHoldSession(context.Background(), fake, cache, session, listedAt.Add(time.Minute)) || fake.domainCalls != 1 {
+		t.Fatalf("expected the cached listing to be reused, got %d calls", fake.domainCalls)
+	}
+	// An expired one is not.
+	domainMayHoldSession(context.Background(), fake, cache, session, listedAt.Add(20*time.Second+domainListingTTL))
+	if fake.domainCalls != 2 {
+		t.Fatalf("expected the expired listing to be refreshed, got %d calls", fake.domainCalls)
+	}
+}
+
+func TestRunDaemonStopsOnSIGTERM(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	fake := &fakeTerminalClient{}
+	fake.onList = func(calls int) {
+		if calls == 1 {
+			_ = syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+		}
+	}
+	useFakeTerminal(t, fake)
+
+	out, err := captureStdout(func() error {
+		return run([]string{"daemon", "--db", dbPath, "--interval", "5ms", "--jitter", "1ms"})
+	})
+	if err != nil {
+		t.Fatalf("daemon failed: %v", err)
+	}
+	if !strings.Contains(out, "status=stopped") {
+		t.Fatalf("expected clean stop, got %q", out)
+	}
+}
+
+func TestRunDaemonRefusesSecondInstance(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	useFakeTerminal(t, &fakeTerminalClient{})
+
+	lock, err := acquireDaemonLock(daemonLockPath(dbPath))
+	if err != nil {
+		t.Fatalf("acquireDaemonLock: %v", err)
+	}
+	_, err = captureStdout(func() error {
+		return run([]string{"daemon", "--db", dbPath, "--ticks", "1"})
+	})
+	if !errors.Is(err, errDaemonRunning) {
+		t.Fatalf("expected errDaemonRunning, got %v", err)
+	}
+
+	// Releasing the lock lets the next daemon start.
+	_ = lock.Close()
+	if _, err := captureStdout(func() error {
+		return run([]string{"daemon", "--db", dbPath, "--ticks", "1"})
+	}); err != nil {
+		t.Fatalf("daemon after release failed: %v", err)
+	}
+}
+
+func TestRunDaemonLoopKeepsPollingAfterReconcileErrors(t *testing.T) {
+	store := newDaemonTestStore(t)
+	fake := &fakeTerminalClient{listErr: errors.New("boom")}
+
+	polls := runDaemonLoop(context.Background(), store, fake, daemonOptions{Interval: time.Millisecond, Ticks: 3})
+	if polls != 3 || fake.listCalls != 3 {
+		t.Fatalf("expected 3 polls despite errors, got polls=%d listCalls=%d", polls, fake.listCalls)
+	}
+}
+
+func newDaemonTestStore(t *testing.T) *tasks.SQLiteStore {
+	t.Helper()
+
+	store, err := tasks.NewSQLiteStore(t.TempDir() + "/state.db")
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = store.Close()
+	})
+	return store
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"term-workspaces/internal/wezterm"
+)
+
+func runWezTerm(args []string) error {
+	if len(args) == 0 {
+		return printWezTermUsage()
+	}
+
+	switch args[0] {
+	case "export-lua":
+		return runWezTermExportLua(args[1:])
+	default:
+		return printWezTermUsage()
+	}
+}
+
+func runWezTermExportLua(args []string) error {
+	fs := flag.NewFlagSet("wezterm export-lua", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	defaults := wezterm.DefaultLuaPluginOptions()
+	output := fs.String("output", defaultWezTermLuaPath(), "Path to write the Lua module ('-' for stdout)")
+	tttPath := fs.String("ttt-path", defaults.TTTPath, "ttt executable invoked from WezTerm (use an absolute path if WezTerm's PATH lacks it)")
+	dbPath := fs.String("db", "", "Path to sqlite database passed to ttt (empty uses ttt's default)")
+	key := fs.String("key", defaults.Key, "Key that opens the task switcher")
+	mods := fs.String("mods", defaults.Mods, "Modifiers for the switcher key binding")
+	title := fs.String("title", defaults.Title, "Task switcher title")
+	label := fs.String("label", defaults.Label, "Choice label format; supports {alias}, {task}, {repo}, {status}")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	rendered, err := wezterm.RenderLuaPlugin(wezterm.LuaPluginOptions{
+		TTTPath: *tttPath,
+		DBPath:  *dbPath,
+		Key:     *key,
+		Mods:    *mods,
+		Title:   *title,
+		Label:   *label,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *output == "-" {
+		_, err := os.Stdout.Write(rendered)
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(*output), 0o750); err != nil {
+		return fmt.Errorf("create lua output dir: %w", err)
+	}
+	if err := os.WriteFile(*output, rendered, 0o600); err != nil {
+		return fmt.Errorf("write lua module: %w", err)
+	}
+
+	fmt.Printf("status=exported lua_path=%s require=%s\n", *output, luaModuleName(*output))
+	return nil
+}
+
+func defaultWezTermLuaPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "ttt.lua"
+	}
+	return filepath.Join(home, ".config", "wezterm", "ttt.lua")
+}
+
+func luaModuleName(path string) string {
+	base := filepath.Base(path)
+	return base[:len(base)-len(filepath.Ext(base))]
+}
+
+func printWezTermUsage() error {
+	fmt.Println("ttt wezterm usage:")
+	fmt.Println("  ttt wezterm export-lua [--output path|-] [--ttt-path path] [--db path] [--key k] [--mods mods] [--title text] [--label format]")
+	return nil
+}
@@ -3,20 +3,36 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"term-workspaces/internal/config"
+	"term-workspaces/internal/kitty"
 	"term-workspaces/internal/tasks"
+	"term-workspaces/internal/terminal"
+	"term-workspaces/internal/tmux"
 	"term-workspaces/internal/ui"
 	"term-workspaces/internal/wezterm"
 	"time"
 )
 
-var newWezTermClient = func() wezterm.Client {
-	return wezterm.NewCLIClient()
+var newTerminalClient = func(cfg config.Config) (terminal.Client, error) {
+	switch backend := cfg.TerminalBackend(); backend {
+	case config.BackendWezTerm:
+		return wezterm.NewCLIClient(cfg.WezTerm.SSHHosts), nil
+	case config.BackendTmux:
+		return tmux.NewCLIClient(), nil
+	case config.BackendKitty:
+		return kitty.NewCLIClient(cfg.Kitty.Socket), nil
+	default:
+		return nil, fmt.Errorf("unsupported terminal backend %q (supported: wezterm, tmux, kitty)", backend)
+	}
 }
 
 func main() {
@@ -36,6 +52,14 @@ func run(args []string) error {
 		return runTask(args[1:])
 	case "ui":
 		return runUI(args[1:])
+	case "wezterm":
+		return runWezTerm(args[1:])
+	case "daemon":
+		return runDaemon(args[1:])
+	case "note":
+		return runNote(args[1:])
+	case "report":
+		return runReport(args[1:])
 	default:
 		return printUsage()
 	}
@@ -94,18 +118,25 @@ func buildUIModelFromStore(ctx context.Context, store *tasks.SQLiteStore) (ui.Mo
 	}
 
 	openSessions := filterOpenSessions(sessions)
+	sortByAttention(openSessions)
 	openRows := make([]string, 0, len(openSessions))
 	for _, session := range openSessions {
-		openRows = append(openRows, fmt.Sprintf("task=%s pane=%d workspace=%s cwd=%s",
+		marker := ""
+		if session.NeedsAttention() {
+			marker = "[!] "
+		}
+		openRows = append(openRows, fmt.Sprintf("%stask=%s pane=%d agent=%s workspace=%s cwd=%s",
+			marker,
 			session.TaskID,
 			session.PaneID,
+			agentStateDisplay(session.AgentState),
 			session.Workspace,
 			session.Cwd,
 		))
 	}
 
 	eventRows := []string{
-		fmt.Sprintf("[ok] loaded tasks=%d aliases=%d sessions=%d open=%d", len(taskRows), len(aliases), len(sessions), len(openSessions)),
+		fmt.Sprintf("[ok] loaded tasks=%d aliases=%d sessions=%d open=%d attention=%d", len(taskRows), len(aliases), len(sessions), len(openSessions), countNeedsAttention(openSessions)),
 	}
 
 	return ui.NewModelFromSections(ui.Sections{
@@ -115,6 +146,16 @@ func buildUIModelFromStore(ctx context.Context, store *tasks.SQLiteStore) (ui.Mo
 	}), nil
 }
 
+func countNeedsAttention(sessions []tasks.TaskSession) int {
+	count := 0
+	for _, session := range sessions {
+		if session.NeedsAttention() {
+			count++
+		}
+	}
+	return count
+}
+
 func primaryAliasDisplay(aliases []tasks.TaskAliasRow) string {
 	if len(aliases) == 0 {
 		return "alias=<none>"
@@ -128,6 +169,13 @@ func primaryAliasDisplay(aliases []tasks.TaskAliasRow) string {
 	return aliases[0].AliasValue
 }
 
+func agentStateDisplay(state tasks.AgentState) string {
+	if state == "" {
+		return string(tasks.AgentStateUnknown)
+	}
+	return string(state)
+}
+
 func sessionDisplay(session *tasks.TaskSession) string {
 	if session == nil {
 		return "none"
@@ -141,10 +189,14 @@ func runTask(args []string) error {
 	}
 
 	switch args[0] {
+	case "attention":
+		return runTaskAttention(args[1:])
 	case "dashboard":
 		return runTaskDashboard(args[1:])
 	case "close-session":
 		return runTaskCloseSession(args[1:])
+	case "context":
+		return runTaskContext(args[1:])
 	case "ensure-prepr":
 		return runTaskEnsurePrePR(args[1:])
 	case "ensure-note":
@@ -157,6 +209,8 @@ func runTask(args []string) error {
 		return runTaskOpenNote(args[1:])
 	case "sessions":
 		return runTaskSessions(args[1:])
+	case "set-meta":
+		return runTaskSetMeta(args[1:])
 	case "link-pr":
 		return runTaskLinkPR(args[1:])
 	default:
@@ -182,6 +236,10 @@ type dashboardTaskMergedEntry struct {
 	Task    tasks.Task           `json:"task"`
 	Aliases []tasks.TaskAliasRow `json:"aliases"`
 	Session *tasks.TaskSession   `json:"session,omitempty"`
+	// Note summarizes the task note; nil when the task has no note yet.
+	Note *tasks.NoteSummary `json:"note,omitempty"`
+	// Related are the tasks linked to or from the task's note.
+	Related []tasks.RelatedTask `json:"related,omitempty"`
 }
 
 func runTaskDashboard(args []string) error {
@@ -190,6 +248,8 @@ func runTaskDashboard(args []string) error {
 
 	dbPath := fs.String("db", defaultDBPath(), "Path to sqlite database")
 	jsonOutput := fs.Bool("json", true, "Emit machine-readable JSON")
+	notesDir := fs.String("notes-dir", defaultNotesDir(), "Directory for task note markdown files")
+	blockedOnly := fs.Bool("blocked", false, "Only list tasks whose note has open blockers")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -203,6 +263,11 @@ func runTaskDashboard(args []string) error {
 	}()
 
 	ctx := context.Background()
+	// Refreshing the note index also syncs note front matter into the tasks.
+	// The dashboard still works from what is stored when that fails.
+	if _, err := store.RefreshNoteIndex(ctx, *notesDir); err != nil {
+		fmt.Fprintf(os.Stderr, "ttt: note index not refreshed: %v\n", err)
+	}
 	byRepo, err := store.ListTaskAliasGroupCounts(ctx, "repo")
 	if err != nil {
 		return fmt.Errorf("dashboard repo groups: %w", err)
@@ -239,24 +304,67 @@ func runTaskDashboard(args []string) error {
 		Aliases:      aliases,
 		Tasks:        mergeDashboardTaskRows(taskRows, aliases, sessions),
 	}
+	if err := attachNoteSummaries(payload.Tasks, *notesDir); err != nil {
+		return err
+	}
+	links, err := store.ListTaskLinks(ctx)
+	if err != nil {
+		return fmt.Errorf("dashboard links: %w", err)
+	}
+	for i := range payload.Tasks {
+		if related := tasks.RelatedTasks(links, payload.Tasks[i].Task.ID); len(related) > 0 {
+			payload.Tasks[i].Related = related
+		}
+	}
+	blocked := filterBlockedTasks(payload.Tasks)
+	if *blockedOnly {
+		payload.Tasks = blocked
+	}
 
 	if *jsonOutput {
 		return writeJSON(payload)
 	}
 
-	fmt.Printf("repos=%d alias_types=%d session_statuses=%d aliases=%d sessions=%d open_sessions=%d tasks=%d\n",
-		len(byRepo), len(byAliasType), len(bySessionStatus), len(aliases), len(sessions), len(payload.OpenSessions), len(payload.Tasks))
+	fmt.Printf("repos=%d alias_types=%d session_statuses=%d aliases=%d sessions=%d open_sessions=%d tasks=%d blocked=%d\n",
+		len(byRepo), len(byAliasType), len(bySessionStatus), len(aliases), len(sessions), len(payload.OpenSessions), len(payload.Tasks), len(blocked))
+	for _, entry := range blocked {
+		fmt.Printf("task_id=%s blockers=%d blocker=%s\n", entry.Task.ID, entry.Note.BlockerCount, entry.Note.OpenBlockers[0])
+	}
 	return nil
 }
 
+// attachNoteSummaries fills in the note summary of each task that has a note.
+func attachNoteSummaries(entries []dashboardTaskMergedEntry, notesDir string) error {
+	for i := range entries {
+		summary, err := tasks.ReadNoteSummary(notesDir, entries[i].Task.ID)
+		if err != nil {
+			return fmt.Errorf("dashboard note for %s: %w", entries[i].Task.ID, err)
+		}
+		entries[i].Note = summary
+	}
+	return nil
+}
+
+func filterBlockedTasks(entries []dashboardTaskMergedEntry) []dashboardTaskMergedEntry {
+	blocked := make([]dashboardTaskMergedEntry, 0)
+	for _, entry := range entries {
+		if entry.Note != nil && entry.Note.BlockerCount > 0 {
+			blocked = append(blocked, entry)
+		}
+	}
+	return blocked
+}
+
 func runTaskSessions(args []string) error {
 	fs := flag.NewFlagSet("task sessions", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
 
 	dbPath := fs.String("db", defaultDBPath(), "Path to sqlite database")
+	configPath := fs.String("config", defaultConfigPath(), "Path to JSON config file")
 	jsonOutput := fs.Bool("json", false, "Emit machine-readable JSON")
 	groupBy := fs.String("group-by", "", "Group sessions by metadata: status")
-	reconcile := fs.Bool("reconcile", false, "Reconcile session health against live WezTerm panes before output")
+	reconcile := fs.Bool("reconcile", false, "Reconcile session health against live terminal panes before output")
+	notesDir := fs.String("notes-dir", defaultNotesDir(), "Directory for task note markdown files")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -270,7 +378,19 @@ func runTaskSessions(args []string) error {
 	}()
 	ctx := context.Background()
 	if *reconcile {
-		if err := reconcileSessionHealth(ctx, store, newWezTermClient()); err != nil {
+		cfg, err := config.Load(*configPath)
+		if err != nil {
+			return err
+		}
+		attention, err := newAttentionMatcher(cfg)
+		if err != nil {
+			return err
+		}
+		client, err := newTerminalClient(cfg)
+		if err != nil {
+			return err
+		}
+		if err := reconcileSessionHealth(ctx, store, client, attention, *notesDir, make(domainListings)); err != nil {
 			return fmt.Errorf("reconcile sessions: %w", err)
 		}
 	}
@@ -309,16 +429,19 @@ func runTaskSessions(args []string) error {
 		return writeJSON(sessions)
 	}
 
-	fmt.Println("task_id\tstatus\tworkspace\tpane_id\tcwd\tcommand\tcodex_session_id")
+	fmt.Println("task_id\tstatus\tagent_state\tworkspace\tdomain\tpane_id\tcwd\tcommand\tagent_kind\tagent_session_id")
 	for _, session := range sessions {
-		fmt.Printf("%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+		fmt.Printf("%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
 			session.TaskID,
 			session.Status,
+			session.AgentState,
 			session.Workspace,
+			session.Domain,
 			session.PaneID,
 			session.Cwd,
 			session.Command,
-			session.CodexSessionID,
+			session.AgentKind,
+			session.AgentSessionID,
 		)
 	}
 	return nil
@@ -332,9 +455,14 @@ func runTaskOpenSession(args []string) error {
 	branch := fs.String("branch", "", "Branch name (optional when using --pr)")
 	prNumber := fs.Int("pr", 0, "Pull request number (optional when using --branch)")
 	dbPath := fs.String("db", defaultDBPath(), "Path to sqlite database")
+	configPath := fs.String("config", defaultConfigPath(), "Path to JSON config file")
 	cwd := fs.String("cwd", ".", "Working directory for spawned session")
 	workspace := fs.String("workspace", "", "Override workspace name")
-	command := fs.String("command", "codex", "Session command metadata label")
+	domain := fs.String("domain", "", "WezTerm multiplexer domain to spawn into (overrides repo config; ignored by tmux and kitty)")
+	profile := fs.String("profile", "", "Profile to run in a spawned pane (codex, claude, shell or one from config)")
+	command := fs.String("command", "", "Deprecated alias for --profile")
+	notesDir := fs.String("notes-dir", defaultNotesDir(), "Directory for task note markdown files")
+	noContext := fs.Bool("no-context", false, "Don't prompt a fresh agent with the task note and PR title")
 
 	if err := fs.Parse(args); err != nil {
 		return err
@@ -346,6 +474,11 @@ func runTaskOpenSession(args []string) error {
 		return fmt.Errorf("one of --branch or --pr is required")
 	}
 
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+
 	store, err := tasks.NewSQLiteStore(*dbPath)
 	if err != nil {
 		return fmt.Errorf("open sqlite task store: %w", err)
@@ -360,35 +493,72 @@ func runTaskOpenSession(args []string) error {
 		return err
 	}
 
-	client := newWezTermClient()
+	targetCwd, err := filepath.Abs(*cwd)
+	if err != nil {
+		return fmt.Errorf("resolve --cwd: %w", err)
+	}
+
+	client, err := newTerminalClient(cfg)
+	if err != nil {
+		return err
+	}
 	ctx := context.Background()
+	snapshotTaskNote(ctx, store, *notesDir, task.ID, "open-session")
 	now := time.Now().UTC()
 	existing, found, err := store.GetSessionByTaskID(ctx, task.ID)
 	if err != nil {
 		return fmt.Errorf("load existing session: %w", err)
 	}
+	var previousSession *tasks.TaskSession
+	if found {
+		previousSession = &existing
+	}
+	profileName := selectProfile(cfg, *repo, *profile, *command, previousSession)
+	selectedProfile, ok := cfg.Profile(profileName)
+	if !ok {
+		return fmt.Errorf("unknown profile %q (available: %s)", profileName, strings.Join(profileNames(cfg), ", "))
+	}
 
 	if found && existing.PaneID > 0 {
 		panes, err := client.ListPanes(ctx)
 		if err != nil {
 			return fmt.Errorf("list panes for liveness check: %w", err)
 		}
-		if !paneIDPresent(panes, existing.PaneID) {
+		_, alive := sessionPane(panes, existing)
+		if alive {
+			refreshContextFile(ctx, store, cfg, *notesDir, task.ID, existing.Cwd, existing.Domain)
+			err := client.ActivatePane(ctx, existing.PaneID)
+			switch {
+			case err == nil:
+				previous := existing.Status
+				existing.Status = tasks.SessionStatusOpen
+				existing.LastSeenAt = now
+				existing.UpdatedAt = now
+				if err := persistSession(ctx, store, existing, previous, "activated"); err != nil {
+					return fmt.Errorf("persist activated session: %w", err)
+				}
+				logSessionEvent(*notesDir, tasks.SessionLogActivated, existing, existing.PaneID, now)
+				fmt.Printf("task_id=%s status=activated pane_id=%d workspace=%s\n", task.ID, existing.PaneID, existing.Workspace)
+				return nil
+			case errors.Is(err, terminal.ErrPaneNotFound):
+				// The pane vanished between listing and activation.
+				alive = false
+			default:
+				return fmt.Errorf("activate pane %d: %w", existing.PaneID, err)
+			}
+		}
+		if !alive {
+			previous := existing.Status
+			deadPaneID := existing.PaneID
+			discoverAgentSession(&existing)
 			existing.Status = tasks.SessionStatusClosed
+			existing.AgentState = ""
 			existing.PaneID = 0
 			existing.UpdatedAt = now
-			if err := store.UpsertSession(ctx, existing); err != nil {
+			if err := persistSession(ctx, store, existing, previous, "pane gone"); err != nil {
 				return fmt.Errorf("persist stale session: %w", err)
 			}
-		} else if err := client.ActivatePane(ctx, existing.PaneID); err == nil {
-			existing.Status = tasks.SessionStatusOpen
-			existing.LastSeenAt = now
-			existing.UpdatedAt = now
-			if err := store.UpsertSession(ctx, existing); err != nil {
-				return fmt.Errorf("persist activated session: %w", err)
-			}
-			fmt.Printf("task_id=%s status=activated pane_id=%d workspace=%s\n", task.ID, existing.PaneID, existing.Workspace)
-			return nil
+			logSessionEvent(*notesDir, tasks.SessionLogDead, existing, deadPaneID, now)
 		}
 	}
 
@@ -401,7 +571,38 @@ func runTaskOpenSession(args []string) error {
 		}
 	}
 
-	paneID, err := client.Spawn(ctx, targetWorkspace, *cwd)
+	targetDomain := strings.TrimSpace(*domain)
+	if targetDomain == "" {
+		if found && strings.TrimSpace(existing.Domain) != "" {
+			targetDomain = existing.Domain
+		} else {
+			targetDomain = cfg.Repo(*repo).Domain
+		}
+	}
+
+	data := promptData{TaskID: task.ID, Repo: *repo, Branch: *branch, PRNumber: *prNumber}
+	if err := fillTaskAliases(ctx, store, task.ID, &data.Branch, &data.PRNumber); err != nil {
+		return err
+	}
+	if !*noContext && promptsAgent(selectedProfile) && !resumesSession(selectedProfile, previousSession, targetCwd) {
+		if err := loadTaskContext(ctx, *notesDir, &data); err != nil {
+			return err
+		}
+	}
+	launch, err := planSessionLaunch(cfg, profileName, previousSession, targetCwd, data)
+	if err != nil {
+		return err
+	}
+
+	refreshContextFile(ctx, store, cfg, *notesDir, task.ID, targetCwd, targetDomain)
+	paneID, err := client.Spawn(ctx, terminal.SpawnOptions{
+		Workspace: targetWorkspace,
+		Cwd:       targetCwd,
+		Domain:    targetDomain,
+		UserVars:  map[string]string{terminal.TaskIDUserVar: task.ID},
+		Argv:      launch.Argv,
+		Env:       launch.Env,
+	})
 	if err != nil {
 		return fmt.Errorf("spawn session pane: %w", err)
 	}
@@ -409,26 +610,54 @@ func runTaskOpenSession(args []string) error {
 	session := tasks.TaskSession{
 		TaskID:         task.ID,
 		Workspace:      targetWorkspace,
+		Domain:         targetDomain,
 		PaneID:         paneID,
-		Cwd:            *cwd,
-		Command:        *command,
+		PaneTagged:     true,
+		Cwd:            targetCwd,
+		Command:        strings.Join(launch.Argv, " "),
+		Profile:        launch.Profile,
 		Status:         tasks.SessionStatusOpen,
-		CodexSessionID: "",
+		AgentState:     tasks.AgentStateUnknown,
+		AgentKind:      launch.AgentKind,
+		AgentSessionID: launch.AgentSessionID,
+		SpawnedAt:      now,
 		LastSeenAt:     now,
 		CreatedAt:      now,
 		UpdatedAt:      now,
 	}
+	var previous tasks.SessionStatus
 	if found {
+		previous = existing.Status
 		session.CreatedAt = existing.CreatedAt
 		if session.CreatedAt.IsZero() {
 			session.CreatedAt = now
 		}
 	}
-	if err := store.UpsertSession(ctx, session); err != nil {
+	if err := persistSession(ctx, store, session, previous, "spawned"); err != nil {
 		return fmt.Errorf("persist spawned session: %w", err)
 	}
+	logSessionEvent(*notesDir, tasks.SessionLogSpawned, session, paneID, now)
+	if launch.SendText != "" {
+		// The pane is already up; a prompt that can't be typed into it is
+		// reported but doesn't fail the open. An agent not seen ready still
+		// gets the prompt, as it may just draw an unexpected screen.
+		if err := waitForAgentReady(ctx, client, paneID, launch.Ready); err != nil {
+			fmt.Fprintf(os.Stderr, "ttt: agent in pane %d not seen ready, sending prompt anyway: %v\n", paneID, err)
+		}
+		if err := client.SendText(ctx, paneID, launch.SendText); err != nil {
+			fmt.Fprintf(os.Stderr, "ttt: send initial prompt to pane %d: %v\n", paneID, err)
+		}
+	}
 
-	fmt.Printf("task_id=%s status=spawned pane_id=%d workspace=%s\n", task.ID, paneID, targetWorkspace)
+	if launch.resumed() {
+		fmt.Printf("task_id=%s status=spawned pane_id=%d workspace=%s profile=%s agent=%s resumed=%s\n", task.ID, paneID, targetWorkspace, launch.Profile, launch.AgentKind, launch.AgentSessionID)
+		return nil
+	}
+	if launch.PromptMode != "" {
+		fmt.Printf("task_id=%s status=spawned pane_id=%d workspace=%s profile=%s prompt=%s\n", task.ID, paneID, targetWorkspace, launch.Profile, launch.PromptMode)
+		return nil
+	}
+	fmt.Printf("task_id=%s status=spawned pane_id=%d workspace=%s profile=%s\n", task.ID, paneID, targetWorkspace, launch.Profile)
 	return nil
 }
 
@@ -440,6 +669,8 @@ func runTaskCloseSession(args []string) error {
 	branch := fs.String("branch", "", "Branch name (optional when using --pr)")
 	prNumber := fs.Int("pr", 0, "Pull request number (optional when using --branch)")
 	dbPath := fs.String("db", defaultDBPath(), "Path to sqlite database")
+	configPath := fs.String("config", defaultConfigPath(), "Path to JSON config file")
+	notesDir := fs.String("notes-dir", defaultNotesDir(), "Directory for task note markdown files")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -450,6 +681,11 @@ func runTaskCloseSession(args []string) error {
 		return fmt.Errorf("one of --branch or --pr is required")
 	}
 
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+
 	store, err := tasks.NewSQLiteStore(*dbPath)
 	if err != nil {
 		return fmt.Errorf("open sqlite task store: %w", err)
@@ -474,21 +710,32 @@ func runTaskCloseSession(args []string) error {
 		return nil
 	}
 
-	client := newWezTermClient()
-	if session.PaneID > 0 {
-		if err := client.KillPane(ctx, session.PaneID); err != nil {
-			return fmt.Errorf("kill pane %d: %w", session.PaneID, err)
-		}
+	client, err := newTerminalClient(cfg)
+	if err != nil {
+		return err
+	}
+	if err := killSessionPanes(ctx, client, session); err != nil {
+		return err
 	}
 
 	now := time.Now().UTC()
+	previous := session.Status
+	closedPaneID := session.PaneID
+	discoverAgentSession(&session)
 	session.Status = tasks.SessionStatusClosed
 	// Policy: retain workspace/cwd/command metadata but clear stale pane binding.
 	session.PaneID = 0
+	session.AuxPaneIDs = nil
+	session.AgentState = ""
+	session.AttentionAt = time.Time{}
 	session.UpdatedAt = now
-	if err := store.UpsertSession(ctx, session); err != nil {
+	if err := persistSession(ctx, store, session, previous, "closed"); err != nil {
 		return fmt.Errorf("persist closed session: %w", err)
 	}
+	if previous != tasks.SessionStatusClosed {
+		logSessionEvent(*notesDir, tasks.SessionLogClosed, session, closedPaneID, now)
+	}
+	commitTaskNote(ctx, cfg, store, *notesDir, task.ID, "task close-session")
 
 	fmt.Printf("task_id=%s status=closed workspace=%s\n", task.ID, session.Workspace)
 	return nil
@@ -618,6 +865,8 @@ func runTaskEnsureNote(args []string) error {
 	prNumber := fs.Int("pr", 0, "Pull request number (optional when using --branch)")
 	dbPath := fs.String("db", defaultDBPath(), "Path to sqlite database")
 	notesDir := fs.String("notes-dir", defaultNotesDir(), "Directory for task note markdown files")
+	templatesDir := fs.String("templates-dir", defaultTemplatesDir(), "Directory of note templates (<owner>/<repo>/ for per-repo ones)")
+	configPath := fs.String("config", defaultConfigPath(), "Path to JSON config file")
 
 	if err := fs.Parse(args); err != nil {
 		return err
@@ -629,6 +878,11 @@ func runTaskEnsureNote(args []string) error {
 		return fmt.Errorf("one of --branch or --pr is required")
 	}
 
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+
 	store, err := tasks.NewSQLiteStore(*dbPath)
 	if err != nil {
 		return fmt.Errorf("open sqlite task store: %w", err)
@@ -643,10 +897,15 @@ func runTaskEnsureNote(args []string) error {
 		return err
 	}
 
-	path, created, err := tasks.EnsureTaskNote(*notesDir, task.ID)
+	data, err := noteDataForTask(context.Background(), store, *notesDir, task, *repo, *branch, *prNumber)
+	if err != nil {
+		return err
+	}
+	path, created, err := tasks.EnsureTaskNote(*notesDir, *templatesDir, data)
 	if err != nil {
 		return fmt.Errorf("ensure task note: %w", err)
 	}
+	initNotesRepo(context.Background(), cfg, *notesDir)
 
 	status := "existing"
 	if created {
@@ -665,6 +924,10 @@ func runTaskOpenNote(args []string) error {
 	prNumber := fs.Int("pr", 0, "Pull request number (optional when using --branch)")
 	dbPath := fs.String("db", defaultDBPath(), "Path to sqlite database")
 	notesDir := fs.String("notes-dir", defaultNotesDir(), "Directory for task note markdown files")
+	templatesDir := fs.String("templates-dir", defaultTemplatesDir(), "Directory of note templates (<owner>/<repo>/ for per-repo ones)")
+	configPath := fs.String("config", defaultConfigPath(), "Path to JSON config file")
+	section := fs.String("section", "", "Open the note at this section heading, e.g. \"Next Actions\"")
+	inSession := fs.Bool("in-session", false, "Open the editor in a split beside the task's live session pane")
 	dryRun := fs.Bool("dry-run", false, "Print editor command without launching")
 
 	if err := fs.Parse(args); err != nil {
@@ -677,6 +940,11 @@ func runTaskOpenNote(args []string) error {
 		return fmt.Errorf("one of --branch or --pr is required")
 	}
 
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+
 	store, err := tasks.NewSQLiteStore(*dbPath)
 	if err != nil {
 		return fmt.Errorf("open sqlite task store: %w", err)
@@ -691,18 +959,86 @@ func runTaskOpenNote(args []string) error {
 		return err
 	}
 
-	path, _, err := tasks.EnsureTaskNote(*notesDir, task.ID)
+	data, err := noteDataForTask(context.Background(), store, *notesDir, task, *repo, *branch, *prNumber)
+	if err != nil {
+		return err
+	}
+	path, _, err := tasks.EnsureTaskNote(*notesDir, *templatesDir, data)
 	if err != nil {
 		return fmt.Errorf("ensure task note: %w", err)
 	}
+	initNotesRepo(context.Background(), cfg, *notesDir)
 
-	editorName, editorArgs := tasks.ResolveEditorCommand(os.Getenv("EDITOR"), path)
+	line := 0
+	if strings.TrimSpace(*section) != "" {
+		// #nosec G304 -- the note path is derived from the notes dir and task ID.
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read task note: %w", err)
+		}
+		var found bool
+		line, found = tasks.NoteSectionLine(string(content), *section)
+		if !found {
+			return fmt.Errorf("note %s has no %q section", path, *section)
+		}
+	}
+
+	sources := tasks.EditorSources{
+		Visual:     os.Getenv("VISUAL"),
+		Editor:     os.Getenv("EDITOR"),
+		Configured: cfg.Editor,
+		GOOS:       runtime.GOOS,
+	}
+	editorName, editorArgs, err := tasks.ResolveEditorCommand(sources, path, line)
+	if err != nil {
+		return err
+	}
+	// A platform opener hands the note to a desktop app and exits at once,
+	// leaving nothing to run in the split.
+	if *inSession && sources.Command() == "" {
+		return fmt.Errorf("--in-session needs $VISUAL, $EDITOR or the editor config key; %s opens the note outside the terminal", editorName)
+	}
 	if *dryRun {
 		fmt.Printf("task_id=%s status=dry_run note_path=%s editor=%s args=%v\n", task.ID, path, editorName, editorArgs)
 		return nil
 	}
 
-	// #nosec G204 -- editor command is intentionally user-configurable via $EDITOR.
+	if *inSession {
+		ctx := context.Background()
+		client, err := newTerminalClient(cfg)
+		if err != nil {
+			return err
+		}
+		// The split runs this command again without --in-session, so the
+		// edit there is snapshotted and committed like any other.
+		self, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("locate ttt executable: %w", err)
+		}
+		argv := []string{"env", "VISUAL=" + sources.Command(), self, "task", "open-note",
+			"--repo", *repo, "--db", *dbPath, "--config", *configPath,
+			"--notes-dir", *notesDir, "--templates-dir", *templatesDir}
+		if *branch != "" {
+			argv = append(argv, "--branch", *branch)
+		}
+		if *prNumber > 0 {
+			argv = append(argv, "--pr", strconv.Itoa(*prNumber))
+		}
+		if strings.TrimSpace(*section) != "" {
+			argv = append(argv, "--section", *section)
+		}
+		session, paneID, err := openNoteInSession(ctx, store, client, task.ID, argv)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("task_id=%s status=split pane_id=%d session_pane_id=%d note_path=%s editor=%s\n", task.ID, paneID, session.PaneID, path, editorName)
+		return nil
+	}
+
+	// Snapshot before and after editing so each editing session is its own
+	// version.
+	snapshotTaskNote(context.Background(), store, *notesDir, task.ID, "open-note")
+	// #nosec G204 -- editor command is intentionally user-configurable via $VISUAL/$EDITOR.
 	command := exec.Command(editorName, editorArgs...)
 	command.Stdin = os.Stdin
 	command.Stdout = os.Stdout
@@ -710,6 +1046,14 @@ func runTaskOpenNote(args []string) error {
 	if err := command.Run(); err != nil {
 		return fmt.Errorf("open note with editor: %w", err)
 	}
+	// A platform opener returns before the note is edited; the edit is
+	// snapshotted by the next open-note or open-session instead.
+	if sources.Command() == "" {
+		fmt.Printf("task_id=%s status=handed_off note_path=%s editor=%s\n", task.ID, path, editorName)
+		return nil
+	}
+	snapshotTaskNote(context.Background(), store, *notesDir, task.ID, "open-note")
+	commitTaskNote(context.Background(), cfg, store, *notesDir, task.ID, "task open-note")
 
 	fmt.Printf("task_id=%s status=opened note_path=%s editor=%s\n", task.ID, path, editorName)
 	return nil
@@ -762,6 +1106,14 @@ func defaultDBPath() string {
 	return filepath.Join(home, "Library", "Application Support", "ttt", "state.db")
 }
 
+func defaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".ttt/config.json"
+	}
+	return filepath.Join(home, "Library", "Application Support", "ttt", "config.json")
+}
+
 func defaultNotesDir() string {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -770,6 +1122,38 @@ func defaultNotesDir() string {
 	return filepath.Join(home, "Library", "Application Support", "ttt", "notes")
 }
 
+func defaultTemplatesDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".ttt/templates"
+	}
+	return filepath.Join(home, "Library", "Application Support", "ttt", "templates")
+}
+
+// noteDataForTask gathers the note template fields for task. The PR title is
+// only looked up when the note does not exist yet and will be rendered.
+func noteDataForTask(ctx context.Context, store *tasks.SQLiteStore, notesDir string, task tasks.Task, repo, branch string, prNumber int) (tasks.NoteData, error) {
+	if err := fillTaskAliases(ctx, store, task.ID, &branch, &prNumber); err != nil {
+		return tasks.NoteData{}, err
+	}
+	data := tasks.NoteData{
+		TaskID:    task.ID,
+		Repo:      repo,
+		Branch:    branch,
+		PRNumber:  prNumber,
+		AliasType: tasks.AliasTypePrePR,
+		CreatedAt: task.CreatedAt,
+	}
+	if prNumber <= 0 {
+		return data, nil
+	}
+	data.AliasType = tasks.AliasTypePR
+	if _, err := os.Stat(tasks.NotePath(notesDir, task.ID)); errors.Is(err, os.ErrNotExist) {
+		data.PRTitle = lookupPRTitle(ctx, repo, prNumber)
+	}
+	return data, nil
+}
+
 func workspaceForTaskID(taskID string) string {
 	sanitized := strings.NewReplacer("/", "-", ":", "-", "#", "-").Replace(taskID)
 	return "task-" + sanitized
@@ -815,61 +1199,214 @@ func mergeDashboardTaskRows(
 	return result
 }
 
-func paneIDPresent(panes []wezterm.Pane, paneID int64) bool {
+// sessionPane finds the session's pane among the listed panes, provided it
+// still carries the session's task ID. Pane IDs are reused after a terminal
+// restart, so a bare ID match may be an unrelated pane. Sessions opened
+// before panes were tagged still match an untagged pane by ID alone, until
+// the session is reopened, and so does every pane of a listing without user
+// vars.
+func sessionPane(panes []terminal.Pane, session tasks.TaskSession) (terminal.Pane, bool) {
 	for _, pane := range panes {
-		if pane.PaneID == paneID {
-			return true
+		if pane.PaneID != session.PaneID {
+			continue
 		}
+		if pane.UserVarsUnknown || (!session.PaneTagged && pane.UserVars[terminal.TaskIDUserVar] == "") {
+			return pane, true
+		}
+		return pane, pane.BelongsToTask(session.TaskID)
 	}
-	return false
+	return terminal.Pane{}, false
 }
 
-func reconcileSessionHealth(ctx context.Context, store *tasks.SQLiteStore, client wezterm.Client) error {
-	panes, err := client.ListPanes(ctx)
+// reconcileSessionHealth syncs session status with the live panes. Sessions
+// found dead are logged in their note when notesDir is set. Remote domains
+// are asked through domains, which the daemon keeps across polls.
+func reconcileSessionHealth(ctx context.Context, store *tasks.SQLiteStore, client terminal.Client, attention attentionMatcher, notesDir string, domains domainListings) error {
+	sessions, err := store.ListSessions(ctx)
 	if err != nil {
 		return err
 	}
-	sessions, err := store.ListSessions(ctx)
+	panes, err := client.ListPanes(ctx)
+	if errors.Is(err, terminal.ErrMuxUnavailable) {
+		return markSessionsUnknown(ctx, store, sessions)
+	}
 	if err != nil {
 		return err
 	}
-	paneSet := make(map[int64]struct{}, len(panes))
+	attachedDomains := make(map[string]struct{})
 	for _, pane := range panes {
-		paneSet[pane.PaneID] = struct{}{}
+		if pane.Domain != "" {
+			attachedDomains[pane.Domain] = struct{}{}
+		}
 	}
 
 	now := time.Now().UTC()
 	for _, session := range sessions {
 		original := session.Status
 		next := original
+		detail := ""
+		pane, alive := sessionPane(panes, session)
 		switch {
 		case session.PaneID <= 0:
 			if session.Status != tasks.SessionStatusClosed {
 				next = tasks.SessionStatusUnknown
+				detail = "no pane recorded"
 			}
-		case paneIDPresentMap(paneSet, session.PaneID):
+		case alive:
 			next = tasks.SessionStatusOpen
+			detail = "pane listed"
 			session.LastSeenAt = now
+			session.AgentState = detectAgentState(ctx, client, pane, attention, attention.runsAgent(session))
+		case session.Domain != "" && !domainAttached(attachedDomains, session.Domain) &&
+			domainMayHoldSession(ctx, client, domains, session, now):
+			// Panes in a detached remote domain are not listed, but may still
+			// be alive on the remote side; don't claim they are gone.
+			next = tasks.SessionStatusUnknown
+			detail = "domain detached"
 		default:
 			next = tasks.SessionStatusClosed
+			detail = "pane missing"
+			// The conversation only matters for a respawn, so the agents'
+			// logs are scanned once, when the pane goes, not on every poll.
+			if original != tasks.SessionStatusClosed {
+				discoverAgentSession(&session)
+			}
+		}
+		// An open session that stays open is still written so last_seen_at
+		// tracks the most recent poll.
+		if next == original && next != tasks.SessionStatusOpen {
+			continue
+		}
+		if next != original {
+			session.Status = next
+			session.UpdatedAt = now
+			if next == tasks.SessionStatusClosed {
+				session.AgentState = ""
+				// Editors split beside the dead pane may hold unsaved
+				// edits, so they are left open but no longer tracked.
+				session.AuxPaneIDs = nil
+			}
+		}
+		updateAttention(&session, now)
+		if err := persistSession(ctx, store, session, original, detail); err != nil {
+			return err
 		}
-		if next == original {
+		if next == tasks.SessionStatusClosed && original != tasks.SessionStatusClosed {
+			logSessionEvent(notesDir, tasks.SessionLogDead, session, session.PaneID, now)
+		}
+	}
+	return nil
+}
+
+// markSessionsUnknown handles an unreachable multiplexer: panes can't be
+// listed, which says nothing about whether they are still alive.
+func markSessionsUnknown(ctx context.Context, store *tasks.SQLiteStore, sessions []tasks.TaskSession) error {
+	now := time.Now().UTC()
+	for _, session := range sessions {
+		if session.Status == tasks.SessionStatusClosed || session.Status == tasks.SessionStatusUnknown {
 			continue
 		}
-		session.Status = next
+		previous := session.Status
+		session.Status = tasks.SessionStatusUnknown
 		session.UpdatedAt = now
-		if err := store.UpsertSession(ctx, session); err != nil {
+		updateAttention(&session, now)
+		if err := persistSession(ctx, store, session, previous, "multiplexer unavailable"); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func paneIDPresentMap(panes map[int64]struct{}, paneID int64) bool {
-	_, ok := panes[paneID]
+// persistSession upserts session and, when its status differs from previous,
+// records the transition as a session_status event.
+func persistSession(ctx context.Context, store *tasks.SQLiteStore, session tasks.TaskSession, previous tasks.SessionStatus, detail string) error {
+	if err := store.UpsertSession(ctx, session); err != nil {
+		return err
+	}
+	if session.Status == previous {
+		return nil
+	}
+	return store.AppendEvent(ctx, tasks.TaskEvent{
+		TaskID:    session.TaskID,
+		Kind:      tasks.EventKindSessionStatus,
+		From:      string(previous),
+		To:        string(session.Status),
+		Detail:    detail,
+		CreatedAt: session.UpdatedAt,
+	})
+}
+
+// logSessionEvent appends a session lifecycle line to the task note's
+// Session Context section. A failed write is reported but not fatal.
+func logSessionEvent(notesDir, event string, session tasks.TaskSession, paneID int64, at time.Time) {
+	if notesDir == "" {
+		return
+	}
+	entry := tasks.SessionLogEntry{
+		At:        at,
+		Event:     event,
+		PaneID:    paneID,
+		Workspace: session.Workspace,
+		Cwd:       session.Cwd,
+		Profile:   session.Profile,
+	}
+	if _, err := tasks.AppendSessionLogFile(tasks.NotePath(notesDir, session.TaskID), entry); err != nil {
+		fmt.Fprintf(os.Stderr, "ttt: session log not written: %v\n", err)
+	}
+}
+
+func domainAttached(domains map[string]struct{}, domain string) bool {
+	_, ok := domains[domain]
 	return ok
 }
 
+// domainListing is one ListDomainPanes answer and when it was taken.
+type domainListing struct {
+	panes []terminal.Pane
+	err   error
+	at    time.Time
+}
+
+// domainListings holds ListDomainPanes answers by domain. Asking an SSHMUX
+// domain means an ssh round trip, so an answer is reused for
+// domainListingTTL, failures included.
+type domainListings map[string]domainListing
+
+const domainListingTTL = 2 * time.Minute
+
+// domainMayHoldSession reports whether session's pane may still be alive in
+// a domain with no listed panes. Backends that can ask the domain itself
+// settle it; otherwise, or when the domain can't be reached, the domain is
+// assumed detached with the pane alive.
+func domainMayHoldSession(ctx context.Context, client terminal.Client, cache domainListings, session tasks.TaskSession, now time.Time) bool {
+	lister, ok := client.(terminal.DomainPaneLister)
+	if !ok {
+		return true
+	}
+	// Panes of sessions opened before tagging have nothing to look for.
+	if !session.PaneTagged {
+		return true
+	}
+	listing, ok := cache[session.Domain]
+	// A listing taken before the pane was spawned can't show it.
+	if !ok || now.Sub(listing.at) >= domainListingTTL || listing.at.Before(session.SpawnedAt) {
+		listing = domainListing{at: now}
+		listing.panes, listing.err = lister.ListDomainPanes(ctx, session.Domain)
+		cache[session.Domain] = listing
+	}
+	if listing.err != nil {
+		return true
+	}
+	// The domain's own pane IDs differ from ours, so a listing without user
+	// vars can't rule the session out.
+	for _, pane := range listing.panes {
+		if pane.UserVarsUnknown || pane.BelongsToTask(session.TaskID) {
+			return true
+		}
+	}
+	return false
+}
+
 func resolveTaskForNote(ctx context.Context, service *tasks.Service, repo, branch string, prNumber int) (tasks.Task, error) {
 	switch {
 	case branch != "" && prNumber > 0:
@@ -899,28 +1436,42 @@ func resolveTaskForNote(ctx context.Context, service *tasks.Service, repo, branc
 func printUsage() error {
 	fmt.Println("ttt usage:")
 	fmt.Println("  ttt ui [--preview] [--db path]")
+	fmt.Println("  ttt daemon [--db path] [--config path] [--notes-dir path] [--interval 30s] [--jitter 5s] [--ticks n]")
+	fmt.Println("  ttt note search [--db path] [--notes-dir path] [--limit n] [--json] <query>")
+	fmt.Println("  ttt note links --repo owner/repo [--branch feature/name] [--pr 123] [--db path] [--notes-dir path] [--json]")
+	fmt.Println("  ttt note history --repo owner/repo [--branch feature/name] [--pr 123] [--db path] [--json]")
+	fmt.Println("  ttt note diff --repo owner/repo [--branch feature/name] [--pr 123] --since version|time [--to version] [--db path] [--notes-dir path]")
+	fmt.Println("  ttt note sync [--config path] [--notes-dir path] [--remote url] [--json]")
+	fmt.Println("  ttt report standup [--since 24h|3d|YYYY-MM-DD] [--db path] [--notes-dir path] [--json]")
+	fmt.Println("  ttt wezterm export-lua [--output path|-] [--ttt-path path] [--db path] [--key k] [--mods mods] [--title text] [--label format]")
+	fmt.Println("  ttt task attention [--db path] [--config path] [--notes-dir path] [--reconcile] [--json]")
 	fmt.Println("  ttt task ensure-prepr --repo owner/repo --branch feature/name [--db path]")
-	fmt.Println("  ttt task close-session --repo owner/repo [--branch feature/name] [--pr 123] [--db path]")
-	fmt.Println("  ttt task dashboard [--db path] [--json]")
-	fmt.Println("  ttt task ensure-note --repo owner/repo [--branch feature/name] [--pr 123] [--db path] [--notes-dir path]")
+	fmt.Println("  ttt task close-session --repo owner/repo [--branch feature/name] [--pr 123] [--db path] [--config path] [--notes-dir path]")
+	fmt.Println("  ttt task context sync --repo owner/repo [--branch feature/name] [--pr 123] [--db path] [--config path] [--notes-dir path] [--cwd path]")
+	fmt.Println("  ttt task dashboard [--db path] [--notes-dir path] [--blocked] [--json]")
+	fmt.Println("  ttt task ensure-note --repo owner/repo [--branch feature/name] [--pr 123] [--db path] [--config path] [--notes-dir path] [--templates-dir path]")
 	fmt.Println("  ttt task list [--db path] [--group-by repo|alias_type] [--json]")
-	fmt.Println("  ttt task open-session --repo owner/repo [--branch feature/name] [--pr 123] [--db path] [--cwd path] [--workspace name] [--command label]")
-	fmt.Println("  ttt task open-note --repo owner/repo [--branch feature/name] [--pr 123] [--db path] [--notes-dir path] [--dry-run]")
-	fmt.Println("  ttt task sessions [--db path] [--group-by status] [--reconcile] [--json]")
+	fmt.Println("  ttt task open-session --repo owner/repo [--branch feature/name] [--pr 123] [--db path] [--config path] [--cwd path] [--workspace name] [--domain name] [--profile name] [--notes-dir path] [--no-context]")
+	fmt.Println("  ttt task open-note --repo owner/repo [--branch feature/name] [--pr 123] [--db path] [--config path] [--notes-dir path] [--templates-dir path] [--section heading] [--in-session] [--dry-run]")
+	fmt.Println("  ttt task sessions [--db path] [--config path] [--notes-dir path] [--group-by status] [--reconcile] [--json]")
+	fmt.Println("  ttt task set-meta --repo owner/repo [--branch feature/name] [--pr 123] [--db path] [--notes-dir path] [--title text] [--tags a,b] [--priority p] [--status s] [--due YYYY-MM-DD] [--json]")
 	fmt.Println("  ttt task link-pr --repo owner/repo --branch feature/name --pr 123 [--db path]")
 	return nil
 }
 
 func printTaskUsage() error {
 	fmt.Println("ttt task usage:")
+	fmt.Println("  ttt task attention [--db path] [--config path] [--notes-dir path] [--reconcile] [--json]")
 	fmt.Println("  ttt task ensure-prepr --repo owner/repo --branch feature/name [--db path]")
-	fmt.Println("  ttt task close-session --repo owner/repo [--branch feature/name] [--pr 123] [--db path]")
-	fmt.Println("  ttt task dashboard [--db path] [--json]")
-	fmt.Println("  ttt task ensure-note --repo owner/repo [--branch feature/name] [--pr 123] [--db path] [--notes-dir path]")
+	fmt.Println("  ttt task close-session --repo owner/repo [--branch feature/name] [--pr 123] [--db path] [--config path] [--notes-dir path]")
+	fmt.Println("  ttt task context sync --repo owner/repo [--branch feature/name] [--pr 123] [--db path] [--config path] [--notes-dir path] [--cwd path]")
+	fmt.Println("  ttt task dashboard [--db path] [--notes-dir path] [--blocked] [--json]")
+	fmt.Println("  ttt task ensure-note --repo owner/repo [--branch feature/name] [--pr 123] [--db path] [--config path] [--notes-dir path] [--templates-dir path]")
 	fmt.Println("  ttt task list [--db path] [--group-by repo|alias_type] [--json]")
-	fmt.Println("  ttt task open-session --repo owner/repo [--branch feature/name] [--pr 123] [--db path] [--cwd path] [--workspace name] [--command label]")
-	fmt.Println("  ttt task open-note --repo owner/repo [--branch feature/name] [--pr 123] [--db path] [--notes-dir path] [--dry-run]")
-	fmt.Println("  ttt task sessions [--db path] [--group-by status] [--reconcile] [--json]")
+	fmt.Println("  ttt task open-session --repo owner/repo [--branch feature/name] [--pr 123] [--db path] [--config path] [--cwd path] [--workspace name] [--domain name] [--profile name] [--notes-dir path] [--no-context]")
+	fmt.Println("  ttt task open-note --repo owner/repo [--branch feature/name] [--pr 123] [--db path] [--config path] [--notes-dir path] [--templates-dir path] [--section heading] [--in-session] [--dry-run]")
+	fmt.Println("  ttt task sessions [--db path] [--config path] [--notes-dir path] [--group-by status] [--reconcile] [--json]")
+	fmt.Println("  ttt task set-meta --repo owner/repo [--branch feature/name] [--pr 123] [--db path] [--notes-dir path] [--title text] [--tags a,b] [--priority p] [--status s] [--due YYYY-MM-DD] [--json]")
 	fmt.Println("  ttt task link-pr --repo owner/repo --branch feature/name --pr 123 [--db path]")
 	return nil
 }
@@ -4,12 +4,23 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
+	"term-workspaces/internal/agent"
+	"term-workspaces/internal/config"
+	"term-workspaces/internal/github"
+	"term-workspaces/internal/kitty"
+	"term-workspaces/internal/tasks"
+	"term-workspaces/internal/terminal"
+	"term-workspaces/internal/tmux"
 	"term-workspaces/internal/wezterm"
 	"testing"
+	"time"
 )
 
 func TestRunTaskEnsurePrePRCreatedThenExisting(t *testing.T) {
@@ -72,11 +83,9 @@ func TestRunUIPreview(t *testing.T) {
 
 func TestRunUIPreviewUsesRealStoreData(t *testing.T) {
 	dbPath := t.TempDir() + "/state.db"
-	fake := &fakeWezTermClient{nextPaneID: 1200}
+	fake := &fakeTerminalClient{nextPaneID: 1200}
 
-	originalFactory := newWezTermClient
-	newWezTermClient = func() wezterm.Client { return fake }
-	t.Cleanup(func() { newWezTermClient = originalFactory })
+	useFakeTerminal(t, fake)
 
 	if _, err := captureStdout(func() error {
 		return run([]string{
@@ -141,6 +150,7 @@ func TestRunTaskLinkPRReusesPrePRTaskID(t *testing.T) {
 }
 
 func TestRunTaskEnsureNoteCreatesThenReusesFile(t *testing.T) {
+	useTestUserState(t)
 	dbPath := t.TempDir() + "/state.db"
 	notesDir := t.TempDir() + "/notes"
 
@@ -195,6 +205,7 @@ func TestRunTaskEnsureNoteCreatesThenReusesFile(t *testing.T) {
 }
 
 func TestRunTaskEnsureNoteViaPRAlias(t *testing.T) {
+	useTestUserState(t)
 	dbPath := t.TempDir() + "/state.db"
 	notesDir := t.TempDir() + "/notes"
 
@@ -245,7 +256,49 @@ func TestRunTaskEnsureNoteViaPRAlias(t *testing.T) {
 	}
 }
 
+func TestRunTaskEnsureNoteRendersRepoTemplate(t *testing.T) {
+	useTestUserState(t)
+	usePRTitles(t, fakePRTitles{405: "Render note templates"})
+	dbPath := t.TempDir() + "/state.db"
+	notesDir := t.TempDir() + "/notes"
+	templatesDir := t.TempDir()
+	repoTemplates := filepath.Join(templatesDir, "zew1me", "term-workspaces")
+	if err := os.MkdirAll(repoTemplates, 0o750); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	template := "# PR #{{.PRNumber}}: {{.PRTitle}}\n\nBranch {{.Branch}}, task {{.TaskID}}\n"
+	if err := os.WriteFile(filepath.Join(repoTemplates, "pr.md"), []byte(template), 0o600); err != nil {
+		t.Fatalf("WriteFile template: %v", err)
+	}
+
+	out, err := captureStdout(func() error {
+		return run([]string{
+			"task", "ensure-note",
+			"--repo", "zew1me/term-workspaces",
+			"--branch", "feature/templates",
+			"--pr", "405",
+			"--db", dbPath,
+			"--notes-dir", notesDir,
+			"--templates-dir", templatesDir,
+		})
+	})
+	if err != nil {
+		t.Fatalf("ensure-note failed: %v", err)
+	}
+	fields := parseKVLine(t, out)
+	// #nosec G304 -- note_path is inside t.TempDir.
+	content, err := os.ReadFile(fields["note_path"])
+	if err != nil {
+		t.Fatalf("ReadFile note: %v", err)
+	}
+	expected := "# PR #405: Render note templates\n\nBranch feature/templates, task " + fields["task_id"] + "\n"
+	if string(content) != expected {
+		t.Fatalf("unexpected note:\n%s", content)
+	}
+}
+
 func TestRunTaskEnsureNoteFailsWhenPRAliasMissing(t *testing.T) {
+	useTestUserState(t)
 	dbPath := t.TempDir() + "/state.db"
 	notesDir := t.TempDir() + "/notes"
 
@@ -265,6 +318,7 @@ func TestRunTaskEnsureNoteFailsWhenPRAliasMissing(t *testing.T) {
 }
 
 func TestRunTaskOpenNoteDryRunUsesEditorEnv(t *testing.T) {
+	useTestUserState(t)
 	dbPath := t.TempDir() + "/state.db"
 	notesDir := t.TempDir() + "/notes"
 	t.Setenv("EDITOR", "vim -u NONE")
@@ -291,6 +345,62 @@ func TestRunTaskOpenNoteDryRunUsesEditorEnv(t *testing.T) {
 	}
 }
 
+func TestRunTaskOpenNoteJumpsToSection(t *testing.T) {
+	useTestUserState(t)
+	dbPath := t.TempDir() + "/state.db"
+	notesDir := t.TempDir() + "/notes"
+	configPath := t.TempDir() + "/config.json"
+	if err := os.WriteFile(configPath, []byte(`{"editor": "nano -w"}`), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	t.Setenv("EDITOR", "")
+
+	openNote := func(extra ...string) string {
+		t.Helper()
+		out, err := captureStdout(func() error {
+			return run(append([]string{
+				"task", "open-note",
+				"--repo", "zew1me/term-workspaces",
+				"--branch", "feature/section",
+				"--db", dbPath,
+				"--notes-dir", notesDir,
+				"--config", configPath,
+				"--dry-run",
+			}, extra...))
+		})
+		if err != nil {
+			t.Fatalf("open-note dry-run failed: %v", err)
+		}
+		return out
+	}
+
+	// The built-in template puts the Next Actions body on line 8.
+	out := openNote("--section", "Next Actions")
+	if fields := parseKVLine(t, out); fields["editor"] != "nano" || !strings.Contains(out, "args=[-w +8 ") {
+		t.Fatalf("expected configured nano at the Next Actions section, got %q", out)
+	}
+
+	t.Setenv("VISUAL", `"/opt/VS Code/bin/code" --wait`)
+	out = openNote("--section", "next actions")
+	if !strings.Contains(out, "editor=/opt/VS Code/bin/code") || !strings.Contains(out, "--wait --goto ") || !strings.Contains(out, ".md:8]") {
+		t.Fatalf("expected $VISUAL to win with a VS Code line jump, got %q", out)
+	}
+
+	if _, err := captureStdout(func() error {
+		return run([]string{
+			"task", "open-note",
+			"--repo", "zew1me/term-workspaces",
+			"--branch", "feature/section",
+			"--db", dbPath,
+			"--notes-dir", notesDir,
+			"--section", "Retro",
+			"--dry-run",
+		})
+	}); err == nil || !strings.Contains(err.Error(), `no "Retro" section`) {
+		t.Fatalf("expected missing section error, got %v", err)
+	}
+}
+
 func TestRunTaskListIncludesCreatedAliases(t *testing.T) {
 	dbPath := t.TempDir() + "/state.db"
 
@@ -459,28 +569,117 @@ func TestRunTaskListGroupByAliasTypeJSON(t *testing.T) {
 	}
 }
 
-type fakeWezTermClient struct {
+type fakeTerminalClient struct {
 	spawnCalls    int
 	activateCalls int
 	killCalls     int
 	nextPaneID    int64
-	spawned       []wezterm.Pane
-	panes         []wezterm.Pane
+	spawnOpts     []terminal.SpawnOptions
+	spawned       []terminal.Pane
+	panes         []terminal.Pane
 	listErr       error
 	activateErr   error
 	killErr       error
+	listCalls     int
+	onList        func(calls int)
+	screens       map[int64]string
+	getTextErr    error
+	sentText      map[int64][]string
+	splitOpts     []terminal.SplitOptions
+	splitParents  []int64
+	domainPanes   map[string][]terminal.Pane
+	domainCalls   int
+}
+
+// fakePRTitles stands in for `gh` so tests never reach GitHub.
+type fakePRTitles map[int]string
+
+func (f fakePRTitles) PRTitle(_ context.Context, repo string, number int) (string, error) {
+	title, ok := f[number]
+	if !ok {
+		return "", fmt.Errorf("no pull request %s#%d", repo, number)
+	}
+	return title, nil
+}
+
+// fakePRStates stands in for `gh pr view --json state,...`.
+type fakePRStates map[int]github.PRState
+
+func (f fakePRStates) PRState(_ context.Context, repo string, number int) (github.PRState, error) {
+	state, ok := f[number]
+	if !ok {
+		return github.PRState{}, fmt.Errorf("no pull request %s#%d", repo, number)
+	}
+	return state, nil
+}
+
+func usePRStates(t *testing.T, states fakePRStates) {
+	t.Helper()
+
+	originalFetcher := newPRStateFetcher
+	newPRStateFetcher = func() prStateFetcher { return states }
+	t.Cleanup(func() { newPRStateFetcher = originalFetcher })
+}
+
+func useFakeTerminal(t *testing.T, fake terminal.Client) {
+	t.Helper()
+
+	useTestUserState(t)
+	// Keep sessions opened with the default --cwd out of the source tree.
+	t.Chdir(t.TempDir())
+	// Keep agent session discovery away from the developer's real logs.
+	t.Setenv("CODEX_HOME", t.TempDir())
+	t.Setenv("CLAUDE_CONFIG_DIR", t.TempDir())
+
+	originalPoll, originalTimeout := agentReadyPoll, agentReadyTimeout
+	agentReadyPoll, agentReadyTimeout = time.Millisecond, 200*time.Millisecond
+	t.Cleanup(func() { agentReadyPoll, agentReadyTimeout = originalPoll, originalTimeout })
+
+	originalFactory := newTerminalClient
+	newTerminalClient = func(config.Config) (terminal.Client, error) { return fake, nil }
+	t.Cleanup(func() { newTerminalClient = originalFactory })
+}
+
+// useTestUserState points the default config, notes and template paths at
+// a temporary HOME and keeps `gh` out of tests.
+func useTestUserState(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("VISUAL", "")
+	usePRTitles(t, fakePRTitles{})
+	usePRStates(t, fakePRStates{})
 }
 
-func (f *fakeWezTermClient) Spawn(_ context.Context, workspace string, _ string) (int64, error) {
+func usePRTitles(t *testing.T, titles fakePRTitles) {
+	t.Helper()
+
+	originalFetcher := newPRTitleFetcher
+	newPRTitleFetcher = func() prTitleFetcher { return titles }
+	t.Cleanup(func() { newPRTitleFetcher = originalFetcher })
+}
+
+func (f *fakeTerminalClient) Spawn(_ context.Context, opts terminal.SpawnOptions) (int64, error) {
 	f.spawnCalls++
+	f.spawnOpts = append(f.spawnOpts, opts)
 	paneID := f.nextPaneID + int64(f.spawnCalls-1)
-	pane := wezterm.Pane{PaneID: paneID, Workspace: workspace}
+	pane := terminal.Pane{PaneID: paneID, Workspace: opts.Workspace, Domain: opts.Domain, UserVars: opts.UserVars}
 	f.spawned = append(f.spawned, pane)
 	f.panes = append(f.panes, pane)
 	return paneID, nil
 }
 
-func (f *fakeWezTermClient) ActivatePane(_ context.Context, _ int64) error {
+// SplitPane hands out IDs 100 above nextPaneID so splits never collide with
+// spawned panes.
+func (f *fakeTerminalClient) SplitPane(_ context.Context, paneID int64, opts terminal.SplitOptions) (int64, error) {
+	f.splitOpts = append(f.splitOpts, opts)
+	f.splitParents = append(f.splitParents, paneID)
+	newPaneID := f.nextPaneID + 100 + int64(len(f.splitOpts)-1)
+	f.panes = append(f.panes, terminal.Pane{PaneID: newPaneID, UserVars: opts.UserVars})
+	return newPaneID, nil
+}
+
+func (f *fakeTerminalClient) ActivatePane(_ context.Context, _ int64) error {
 	f.activateCalls++
 	if f.activateErr != nil {
 		return f.activateErr
@@ -488,12 +687,12 @@ func (f *fakeWezTermClient) ActivatePane(_ context.Context, _ int64) error {
 	return nil
 }
 
-func (f *fakeWezTermClient) KillPane(_ context.Context, paneID int64) error {
+func (f *fakeTerminalClient) KillPane(_ context.Context, paneID int64) error {
 	f.killCalls++
 	if f.killErr != nil {
 		return f.killErr
 	}
-	filtered := make([]wezterm.Pane, 0, len(f.panes))
+	filtered := make([]terminal.Pane, 0, len(f.panes))
 	for _, pane := range f.panes {
 		if pane.PaneID == paneID {
 			continue
@@ -504,20 +703,48 @@ func (f *fakeWezTermClient) KillPane(_ context.Context, paneID int64) error {
 	return nil
 }
 
-func (f *fakeWezTermClient) ListPanes(_ context.Context) ([]wezterm.Pane, error) {
+func (f *fakeTerminalClient) ListPanes(_ context.Context) ([]terminal.Pane, error) {
+	f.listCalls++
+	if f.onList != nil {
+		f.onList(f.listCalls)
+	}
 	if f.listErr != nil {
 		return nil, f.listErr
 	}
-	return append([]wezterm.Pane(nil), f.panes...), nil
+	return append([]terminal.Pane(nil), f.panes...), nil
+}
+
+func (f *fakeTerminalClient) GetText(_ context.Context, paneID int64) (string, error) {
+	if f.getTextErr != nil {
+		return "", f.getTextErr
+	}
+	return f.screens[paneID], nil
+}
+
+// ListDomainPanes answers for the domains in domainPanes only; any other
+// domain can't be asked, as with backends that don't support it.
+func (f *fakeTerminalClient) ListDomainPanes(_ context.Context, domain string) ([]terminal.Pane, error) {
+	f.domainCalls++
+	panes, ok := f.domainPanes[domain]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", terminal.ErrDomainUnsupported, domain)
+	}
+	return panes, nil
+}
+
+func (f *fakeTerminalClient) SendText(_ context.Context, paneID int64, text string) error {
+	if f.sentText == nil {
+		f.sentText = map[int64][]string{}
+	}
+	f.sentText[paneID] = append(f.sentText[paneID], text)
+	return nil
 }
 
 func TestRunTaskOpenSessionSpawnThenActivate(t *testing.T) {
 	dbPath := t.TempDir() + "/state.db"
-	fake := &fakeWezTermClient{nextPaneID: 7001}
+	fake := &fakeTerminalClient{nextPaneID: 7001}
 
-	originalFactory := newWezTermClient
-	newWezTermClient = func() wezterm.Client { return fake }
-	t.Cleanup(func() { newWezTermClient = originalFactory })
+	useFakeTerminal(t, fake)
 
 	out1, err := captureStdout(func() error {
 		return run([]string{
@@ -565,11 +792,9 @@ func TestRunTaskOpenSessionSpawnThenActivate(t *testing.T) {
 
 func TestRunTaskOpenSessionRespawnsWhenStoredPaneMissing(t *testing.T) {
 	dbPath := t.TempDir() + "/state.db"
-	fake := &fakeWezTermClient{nextPaneID: 8001}
+	fake := &fakeTerminalClient{nextPaneID: 8001}
 
-	originalFactory := newWezTermClient
-	newWezTermClient = func() wezterm.Client { return fake }
-	t.Cleanup(func() { newWezTermClient = originalFactory })
+	useFakeTerminal(t, fake)
 
 	out1, err := captureStdout(func() error {
 		return run([]string{
@@ -618,11 +843,9 @@ func TestRunTaskOpenSessionRespawnsWhenStoredPaneMissing(t *testing.T) {
 
 func TestRunTaskSessionsJSONAndGroupedStatus(t *testing.T) {
 	dbPath := t.TempDir() + "/state.db"
-	fake := &fakeWezTermClient{nextPaneID: 42}
+	fake := &fakeTerminalClient{nextPaneID: 42}
 
-	originalFactory := newWezTermClient
-	newWezTermClient = func() wezterm.Client { return fake }
-	t.Cleanup(func() { newWezTermClient = originalFactory })
+	useFakeTerminal(t, fake)
 
 	if _, err := captureStdout(func() error {
 		return run([]string{
@@ -666,11 +889,9 @@ func TestRunTaskSessionsJSONAndGroupedStatus(t *testing.T) {
 
 func TestRunTaskDashboardJSON(t *testing.T) {
 	dbPath := t.TempDir() + "/state.db"
-	fake := &fakeWezTermClient{nextPaneID: 88}
+	fake := &fakeTerminalClient{nextPaneID: 88}
 
-	originalFactory := newWezTermClient
-	newWezTermClient = func() wezterm.Client { return fake }
-	t.Cleanup(func() { newWezTermClient = originalFactory })
+	useFakeTerminal(t, fake)
 
 	if _, err := captureStdout(func() error {
 		return run([]string{
@@ -711,13 +932,66 @@ func TestRunTaskDashboardJSON(t *testing.T) {
 	}
 }
 
+func TestRunTaskDashboardSummarizesNotes(t *testing.T) {
+	useTestUserState(t)
+	dbPath := t.TempDir() + "/state.db"
+	notesDir := t.TempDir()
+
+	paths := make(map[string]string)
+	for _, branch := range []string{"feature/blocked", "feature/clear"} {
+		out, err := captureStdout(func() error {
+			return run([]string{
+				"task", "ensure-note",
+				"--repo", "zew1me/term-workspaces",
+				"--branch", branch,
+				"--db", dbPath,
+				"--notes-dir", notesDir,
+			})
+		})
+		if err != nil {
+			t.Fatalf("ensure-note %s failed: %v", branch, err)
+		}
+		paths[branch] = parseKVLine(t, strings.TrimSpace(out))["note_path"]
+	}
+	blockedNote := "## Current Objective\nShip it.\n\n## Next Actions\n- [x] write code\n- [ ] get review\n\n## Blockers\n- waiting on infra\n"
+	if err := os.WriteFile(paths["feature/blocked"], []byte(blockedNote), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	out, err := captureStdout(func() error {
+		return run([]string{"task", "dashboard", "--db", dbPath, "--notes-dir", notesDir, "--blocked"})
+	})
+	if err != nil {
+		t.Fatalf("task dashboard --blocked failed: %v", err)
+	}
+	var payload dashboardPayload
+	if err := json.Unmarshal([]byte(out), &payload); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v (%q)", err, out)
+	}
+	if len(payload.Tasks) != 1 || payload.Tasks[0].Note == nil {
+		t.Fatalf("expected one blocked task, got %#v", payload.Tasks)
+	}
+	note := payload.Tasks[0].Note
+	if note.Objective != "Ship it." || note.BlockerCount != 1 || len(note.OpenNextActions) != 1 || note.OpenNextActions[0] != "get review" {
+		t.Fatalf("unexpected note summary: %#v", note)
+	}
+
+	out, err = captureStdout(func() error {
+		return run([]string{"task", "dashboard", "--db", dbPath, "--notes-dir", notesDir, "--json=false"})
+	})
+	if err != nil {
+		t.Fatalf("task dashboard --json=false failed: %v", err)
+	}
+	if !strings.Contains(out, "tasks=2 blocked=1") || !strings.Contains(out, "blockers=1 blocker=waiting on infra") {
+		t.Fatalf("unexpected dashboard text output: %q", out)
+	}
+}
+
 func TestRunTaskCloseSessionClosesAndClearsPane(t *testing.T) {
 	dbPath := t.TempDir() + "/state.db"
-	fake := &fakeWezTermClient{nextPaneID: 300}
+	fake := &fakeTerminalClient{nextPaneID: 300}
 
-	originalFactory := newWezTermClient
-	newWezTermClient = func() wezterm.Client { return fake }
-	t.Cleanup(func() { newWezTermClient = originalFactory })
+	useFakeTerminal(t, fake)
 
 	if _, err := captureStdout(func() error {
 		return run([]string{
@@ -772,11 +1046,9 @@ func TestRunTaskCloseSessionClosesAndClearsPane(t *testing.T) {
 
 func TestRunTaskSessionsReconcileUpdatesSessionStatus(t *testing.T) {
 	dbPath := t.TempDir() + "/state.db"
-	fake := &fakeWezTermClient{nextPaneID: 900}
+	fake := &fakeTerminalClient{nextPaneID: 900}
 
-	originalFactory := newWezTermClient
-	newWezTermClient = func() wezterm.Client { return fake }
-	t.Cleanup(func() { newWezTermClient = originalFactory })
+	useFakeTerminal(t, fake)
 
 	if _, err := captureStdout(func() error {
 		return run([]string{
@@ -810,6 +1082,813 @@ func TestRunTaskSessionsReconcileUpdatesSessionStatus(t *testing.T) {
 	}
 }
 
+func TestRunTaskOpenSessionUsesRepoDomainFromConfig(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	configPath := t.TempDir() + "/config.json"
+	if err := os.WriteFile(configPath, []byte(`{"repos": {"zew1me/term-workspaces": {"domain": "SSH:devbox"}}}`), 0o600); err != nil {
+		t.Fatalf("WriteFile config: %v", err)
+	}
+	fake := &fakeTerminalClient{nextPaneID: 500}
+
+	useFakeTerminal(t, fake)
+
+	if _, err := captureStdout(func() error {
+		return run([]string{
+			"task", "open-session",
+			"--repo", "zew1me/term-workspaces",
+			"--branch", "feature/remote",
+			"--db", dbPath,
+			"--config", configPath,
+		})
+	}); err != nil {
+		t.Fatalf("open-session run failed: %v", err)
+	}
+	if len(fake.spawnOpts) != 1 || fake.spawnOpts[0].Domain != "SSH:devbox" {
+		t.Fatalf("expected spawn into configured domain, got %#v", fake.spawnOpts)
+	}
+
+	// An explicit --domain flag wins over the repo config on respawn.
+	fake.panes = nil
+	if _, err := captureStdout(func() error {
+		return run([]string{
+			"task", "open-session",
+			"--repo", "zew1me/term-workspaces",
+			"--branch", "feature/remote",
+			"--db", dbPath,
+			"--config", configPath,
+			"--domain", "unix",
+		})
+	}); err != nil {
+		t.Fatalf("second open-session run failed: %v", err)
+	}
+	if len(fake.spawnOpts) != 2 || fake.spawnOpts[1].Domain != "unix" {
+		t.Fatalf("expected respawn into flag domain, got %#v", fake.spawnOpts)
+	}
+
+	out, err := captureStdout(func() error {
+		return run([]string{"task", "sessions", "--db", dbPath, "--json"})
+	})
+	if err != nil {
+		t.Fatalf("task sessions --json failed: %v", err)
+	}
+	var sessions []map[string]any
+	if err := json.Unmarshal([]byte(out), &sessions); err != nil {
+		t.Fatalf("json.Unmarshal sessions failed: %v (%q)", err, out)
+	}
+	if len(sessions) != 1 || sessions[0]["domain"] != "unix" {
+		t.Fatalf("expected persisted domain=unix, got %#v", sessions)
+	}
+}
+
+func TestRunTaskSessionsReconcileToleratesDetachedDomain(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	fake := &fakeTerminalClient{nextPaneID: 610}
+
+	useFakeTerminal(t, fake)
+
+	for _, branch := range []string{"feature/detached", "feature/attached"} {
+		if _, err := captureStdout(func() error {
+			return run([]string{
+				"task", "open-session",
+				"--repo", "zew1me/term-workspaces",
+				"--branch", branch,
+				"--db", dbPath,
+				"--domain", "SSH:" + strings.TrimPrefix(branch, "feature/"),
+			})
+		}); err != nil {
+			t.Fatalf("open-session %s failed: %v", branch, err)
+		}
+	}
+
+	// The "detached" domain disappears from the listing entirely, while the
+	// "attached" domain is still listed but has lost the task pane.
+	fake.panes = []terminal.Pane{{PaneID: 9999, Workspace: "other", Domain: "SSH:attached"}}
+
+	out, err := captureStdout(func() error {
+		return run([]string{"task", "sessions", "--db", dbPath, "--reconcile", "--json"})
+	})
+	if err != nil {
+		t.Fatalf("task sessions --reconcile failed: %v", err)
+	}
+	var sessions []map[string]any
+	if err := json.Unmarshal([]byte(out), &sessions); err != nil {
+		t.Fatalf("json.Unmarshal sessions failed: %v (%q)", err, out)
+	}
+	statuses := map[string]any{}
+	for _, session := range sessions {
+		statuses[session["domain"].(string)] = session["status"]
+	}
+	if statuses["SSH:detached"] != "unknown" {
+		t.Fatalf("expected detached domain session to be unknown, got %#v", statuses)
+	}
+	if statuses["SSH:attached"] != "closed" {
+		t.Fatalf("expected attached domain session to be closed, got %#v", statuses)
+	}
+}
+
+func TestRunTaskSessionsReconcileAsksDomainWithNoListedPanes(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	fake := &fakeTerminalClient{nextPaneID: 620}
+
+	useFakeTerminal(t, fake)
+
+	taskIDs := map[string]string{}
+	for _, branch := range []string{"feature/gone", "feature/detached"} {
+		out, err := captureStdout(func() error {
+			return run([]string{
+				"task", "open-session",
+				"--repo", "zew1me/term-workspaces",
+				"--branch", branch,
+				"--db", dbPath,
+				"--domain", "SSHMUX:" + strings.TrimPrefix(branch, "feature/"),
+			})
+		})
+		if err != nil {
+			t.Fatalf("open-session %s failed: %v", branch, err)
+		}
+		taskIDs[branch] = parseKVLine(t, out)["task_id"]
+	}
+
+	// Neither domain lists a pane here. The "gone" domain is attached but
+	// its last pane exited; the "detached" domain still holds the task pane
+	// on its host, under the host's own pane ID.
+	fake.panes = nil
+	fake.domainPanes = map[string][]terminal.Pane{
+		"SSHMUX:gone": {},
+		"SSHMUX:detached": {{
+			PaneID:   3,
+			UserVars: map[string]string{terminal.TaskIDUserVar: taskIDs["feature/detached"]},
+		}},
+	}
+
+	out, err := captureStdout(func() error {
+		return run([]string{"task", "sessions", "--db", dbPath, "--reconcile", "--json"})
+	})
+	if err != nil {
+		t.Fatalf("task sessions --reconcile failed: %v", err)
+	}
+	var sessions []map[string]any
+	if err := json.Unmarshal([]byte(out), &sessions); err != nil {
+		t.Fatalf("json.Unmarshal sessions failed: %v (%q)", err, out)
+	}
+	statuses := map[string]any{}
+	for _, session := range sessions {
+		statuses[session["domain"].(string)] = session["status"]
+	}
+	expected := map[string]any{"SSHMUX:gone": "closed", "SSHMUX:detached": "unknown"}
+	if !reflect.DeepEqual(statuses, expected) {
+		t.Fatalf("unexpected statuses: %#v", statuses)
+	}
+}
+
+func TestRunTaskOpenSessionRespawnsWhenActivateReportsPaneNotFound(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	fake := &fakeTerminalClient{nextPaneID: 1300}
+	useFakeTerminal(t, fake)
+
+	args := []string{
+		"task", "open-session",
+		"--repo", "zew1me/term-workspaces",
+		"--branch", "feature/activate-race",
+		"--db", dbPath,
+	}
+	if _, err := captureStdout(func() error { return run(args) }); err != nil {
+		t.Fatalf("first open-session run failed: %v", err)
+	}
+
+	fake.activateErr = fmt.Errorf("wezterm activate-pane: %w", terminal.ErrPaneNotFound)
+	out, err := captureStdout(func() error { return run(args) })
+	if err != nil {
+		t.Fatalf("second open-session run failed: %v", err)
+	}
+	if fields := parseKVLine(t, out); fields["status"] != "spawned" {
+		t.Fatalf("expected respawn after pane-not-found, got %q", out)
+	}
+	if fake.spawnCalls != 2 {
+		t.Fatalf("expected two spawn calls, got %d", fake.spawnCalls)
+	}
+}
+
+func TestRunTaskOpenSessionRespawnsWhenPaneIDReusedByOtherPane(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	fake := &fakeTerminalClient{nextPaneID: 1400}
+	useFakeTerminal(t, fake)
+
+	args := []string{
+		"task", "open-session",
+		"--repo", "zew1me/term-workspaces",
+		"--branch", "feature/reused-pane",
+		"--db", dbPath,
+	}
+	if _, err := captureStdout(func() error { return run(args) }); err != nil {
+		t.Fatalf("first open-session run failed: %v", err)
+	}
+	if got := fake.spawnOpts[0].UserVars[terminal.TaskIDUserVar]; got == "" {
+		t.Fatalf("expected spawn to tag pane with %s, got %#v", terminal.TaskIDUserVar, fake.spawnOpts[0])
+	}
+
+	// After a terminal restart the same pane ID belongs to an untagged pane.
+	fake.panes = []terminal.Pane{{PaneID: 1400, Workspace: "other"}}
+
+	out, err := captureStdout(func() error {
+		return run([]string{"task", "sessions", "--db", dbPath, "--reconcile", "--json"})
+	})
+	if err != nil {
+		t.Fatalf("task sessions --reconcile failed: %v", err)
+	}
+	var sessions []map[string]any
+	if err := json.Unmarshal([]byte(out), &sessions); err != nil {
+		t.Fatalf("json.Unmarshal sessions failed: %v (%q)", err, out)
+	}
+	if len(sessions) != 1 || sessions[0]["status"] != "closed" {
+		t.Fatalf("expected reused pane to reconcile as closed, got %#v", sessions)
+	}
+
+	out, err = captureStdout(func() error { return run(args) })
+	if err != nil {
+		t.Fatalf("second open-session run failed: %v", err)
+	}
+	if fields := parseKVLine(t, out); fields["status"] != "spawned" {
+		t.Fatalf("expected respawn instead of activating a foreign pane, got %q", out)
+	}
+	if fake.activateCalls != 0 {
+		t.Fatalf("expected no activation of the reused pane, got %d", fake.activateCalls)
+	}
+}
+
+func TestRunTaskSessionsReconcileKeepsUntaggedPaneOfOlderSession(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	fake := &fakeTerminalClient{nextPaneID: 1450}
+	useFakeTerminal(t, fake)
+
+	if _, err := captureStdout(func() error {
+		return run([]string{"task", "open-session", "--repo", "zew1me/term-workspaces", "--branch", "feature/legacy-pane", "--db", dbPath})
+	}); err != nil {
+		t.Fatalf("open-session failed: %v", err)
+	}
+
+	// A session opened by an older build recorded a pane spawned without the
+	// task ID user var.
+	ctx := context.Background()
+	store, err := tasks.NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	sessions, err := store.ListSessions(ctx)
+	if err != nil || len(sessions) != 1 || !sessions[0].PaneTagged {
+		t.Fatalf("expected one tagged session, got %#v err=%v", sessions, err)
+	}
+	legacy := sessions[0]
+	legacy.PaneTagged = false
+	if err := store.UpsertSession(ctx, legacy); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+	_ = store.Close()
+	fake.panes = []terminal.Pane{{PaneID: 1450, Workspace: legacy.Workspace}}
+
+	out, err := captureStdout(func() error {
+		return run([]string{"task", "sessions", "--db", dbPath, "--reconcile", "--json"})
+	})
+	if err != nil {
+		t.Fatalf("task sessions --reconcile failed: %v", err)
+	}
+	var listed []map[string]any
+	if err := json.Unmarshal([]byte(out), &listed); err != nil {
+		t.Fatalf("json.Unmarshal sessions failed: %v (%q)", err, out)
+	}
+	if len(listed) != 1 || listed[0]["status"] != "open" {
+		t.Fatalf("expected the older session's untagged pane to stay open, got %#v", listed)
+	}
+
+	// A pane tagged for another task is still foreign.
+	fake.panes = []terminal.Pane{{PaneID: 1450, UserVars: map[string]string{terminal.TaskIDUserVar: "task_other"}}}
+	out, err = captureStdout(func() error {
+		return run([]string{"task", "sessions", "--db", dbPath, "--reconcile", "--json"})
+	})
+	if err != nil {
+		t.Fatalf("task sessions --reconcile failed: %v", err)
+	}
+	if err := json.Unmarshal([]byte(out), &listed); err != nil {
+		t.Fatalf("json.Unmarshal sessions failed: %v (%q)", err, out)
+	}
+	if len(listed) != 1 || listed[0]["status"] != "closed" {
+		t.Fatalf("expected a pane of another task to reconcile as closed, got %#v", listed)
+	}
+}
+
+func TestRunTaskOpenSessionMatchesPaneByIDWithoutUserVars(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	fake := &fakeTerminalClient{nextPaneID: 1480}
+	useFakeTerminal(t, fake)
+
+	args := []string{"task", "open-session", "--repo", "zew1me/term-workspaces", "--branch", "feature/no-user-vars", "--db", dbPath}
+	if _, err := captureStdout(func() error { return run(args) }); err != nil {
+		t.Fatalf("first open-session run failed: %v", err)
+	}
+	// Stock WezTerm lists panes without their user vars.
+	fake.panes = []terminal.Pane{{PaneID: 1480, Workspace: "ttt-term-workspaces", UserVarsUnknown: true}}
+
+	out, err := captureStdout(func() error {
+		return run([]string{"task", "sessions", "--db", dbPath, "--reconcile", "--json"})
+	})
+	if err != nil {
+		t.Fatalf("task sessions --reconcile failed: %v", err)
+	}
+	var sessions []map[string]any
+	if err := json.Unmarshal([]byte(out), &sessions); err != nil {
+		t.Fatalf("json.Unmarshal sessions failed: %v (%q)", err, out)
+	}
+	if len(sessions) != 1 || sessions[0]["status"] != "open" {
+		t.Fatalf("expected the pane to be matched by ID, got %#v", sessions)
+	}
+
+	out, err = captureStdout(func() error { return run(args) })
+	if err != nil {
+		t.Fatalf("second open-session run failed: %v", err)
+	}
+	if fields := parseKVLine(t, out); fields["status"] != "activated" || fake.spawnCalls != 1 {
+		t.Fatalf("expected activation without a duplicate spawn, got %q (%d spawns)", out, fake.spawnCalls)
+	}
+}
+
+func TestRunTaskOpenSessionResumesDiscoveredCodexSession(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	workDir := t.TempDir()
+	fake := &fakeTerminalClient{nextPaneID: 1700}
+	useFakeTerminal(t, fake)
+
+	args := []string{
+		"task", "open-session",
+		"--repo", "zew1me/term-workspaces",
+		"--branch", "feature/codex-resume",
+		"--db", dbPath,
+		"--cwd", workDir,
+	}
+	out, err := captureStdout(func() error { return run(append(args, "--profile", "codex")) })
+	if err != nil {
+		t.Fatalf("first open-session run failed: %v", err)
+	}
+	if fields := parseKVLine(t, out); fields["resumed"] != "" || !reflect.DeepEqual(fake.spawnOpts[0].Argv, []string{"codex"}) {
+		t.Fatalf("expected a fresh first spawn, got %q %#v", out, fake.spawnOpts[0])
+	}
+
+	// Codex starts in the pane and writes its rollout log under today's date.
+	sessionsDir := filepath.Join(os.Getenv("CODEX_HOME"), "sessions", time.Now().Format("2006/01/02"))
+	if err := os.MkdirAll(sessionsDir, 0o750); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	meta := fmt.Sprintf(`{"type":"session_meta","payload":{"id":"codex-abc","timestamp":%q,"cwd":%q}}`+"\n",
+		time.Now().UTC().Format(time.RFC3339Nano), workDir)
+	if err := os.WriteFile(filepath.Join(sessionsDir, "rollout-2026-03-01T09-00-00-codex-abc.jsonl"), []byte(meta), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	// The pane dies; reconcile notices and records the Codex session.
+	fake.panes = nil
+	out, err = captureStdout(func() error {
+		return run([]string{"task", "sessions", "--db", dbPath, "--reconcile", "--json"})
+	})
+	if err != nil {
+		t.Fatalf("task sessions --reconcile failed: %v", err)
+	}
+	var sessions []map[string]any
+	if err := json.Unmarshal([]byte(out), &sessions); err != nil {
+		t.Fatalf("json.Unmarshal sessions failed: %v (%q)", err, out)
+	}
+	if len(sessions) != 1 || sessions[0]["agent_session_id"] != "codex-abc" || sessions[0]["agent_kind"] != "codex" || sessions[0]["status"] != "closed" {
+		t.Fatalf("expected closed session with codex id, got %#v", sessions)
+	}
+
+	out, err = captureStdout(func() error { return run(args) })
+	if err != nil {
+		t.Fatalf("second open-session run failed: %v", err)
+	}
+	if fields := parseKVLine(t, out); fields["status"] != "spawned" || fields["resumed"] != "codex-abc" {
+		t.Fatalf("expected resumed spawn, got %q", out)
+	}
+	if got := fake.spawnOpts[1].Argv; !reflect.DeepEqual(got, []string{"codex", "resume", "codex-abc"}) {
+		t.Fatalf("unexpected respawn argv: %#v", got)
+	}
+
+	// A profile without an agent gets a fresh pane in the same cwd.
+	fake.panes = nil
+	if _, err := captureStdout(func() error {
+		return run([]string{"task", "sessions", "--db", dbPath, "--reconcile"})
+	}); err != nil {
+		t.Fatalf("task sessions --reconcile failed: %v", err)
+	}
+	out, err = captureStdout(func() error { return run(append(args, "--profile", "shell")) })
+	if err != nil {
+		t.Fatalf("shell open-session run failed: %v", err)
+	}
+	if fields := parseKVLine(t, out); fields["resumed"] != "" || fake.spawnOpts[2].Argv != nil {
+		t.Fatalf("expected a fresh shell spawn, got %q %#v", out, fake.spawnOpts[2])
+	}
+}
+
+func TestRunTaskCloseSessionThenReopenResumesClaudeConversation(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	workDir := t.TempDir()
+	fake := &fakeTerminalClient{nextPaneID: 1800}
+	useFakeTerminal(t, fake)
+
+	target := []string{
+		"--repo", "zew1me/term-workspaces",
+		"--branch", "feature/claude-resume",
+		"--db", dbPath,
+	}
+	openArgs := append([]string{"task", "open-session", "--cwd", workDir, "--profile", "claude"}, target...)
+	if _, err := captureStdout(func() error { return run(openArgs) }); err != nil {
+		t.Fatalf("first open-session run failed: %v", err)
+	}
+
+	// Claude Code starts in the pane and writes its project transcript.
+	projectDir := filepath.Join(os.Getenv("CLAUDE_CONFIG_DIR"), "projects", agent.ClaudeProjectDirName(workDir))
+	if err := os.MkdirAll(projectDir, 0o750); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	line := fmt.Sprintf(`{"type":"user","sessionId":"claude-xyz","cwd":%q,"timestamp":%q}`+"\n",
+		workDir, time.Now().UTC().Format(time.RFC3339Nano))
+	if err := os.WriteFile(filepath.Join(projectDir, "claude-xyz.jsonl"), []byte(line), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if _, err := captureStdout(func() error {
+		return run(append([]string{"task", "close-session"}, target...))
+	}); err != nil {
+		t.Fatalf("close-session failed: %v", err)
+	}
+
+	out, err := captureStdout(func() error { return run(openArgs) })
+	if err != nil {
+		t.Fatalf("second open-session run failed: %v", err)
+	}
+	if fields := parseKVLine(t, out); fields["agent"] != "claude" || fields["resumed"] != "claude-xyz" {
+		t.Fatalf("expected resumed claude spawn, got %q", out)
+	}
+	if got := fake.spawnOpts[1].Argv; !reflect.DeepEqual(got, []string{"claude", "--resume", "claude-xyz"}) {
+		t.Fatalf("unexpected respawn argv: %#v", got)
+	}
+}
+
+func TestRunTaskOpenSessionLaunchesConfiguredProfile(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	configPath := t.TempDir() + "/config.json"
+	configJSON := `{
+  "profiles": {
+    "review": {
+      "argv": ["codex", "--model", "o3"],
+      "env": {"REVIEW_MODE": "strict"},
+      "prompt_template": "Review {{.Repo}} on {{.Branch}} (task {{.TaskID}})",
+      "agent": "codex"
+    }
+  }
+}`
+	if err := os.WriteFile(configPath, []byte(configJSON), 0o600); err != nil {
+		t.Fatalf("WriteFile config: %v", err)
+	}
+	fake := &fakeTerminalClient{nextPaneID: 1900}
+	useFakeTerminal(t, fake)
+
+	args := []string{
+		"task", "open-session",
+		"--repo", "zew1me/term-workspaces",
+		"--branch", "feature/review",
+		"--db", dbPath,
+		"--config", configPath,
+	}
+	out, err := captureStdout(func() error { return run(append(args, "--profile", "review")) })
+	if err != nil {
+		t.Fatalf("open-session --profile failed: %v", err)
+	}
+	fields := parseKVLine(t, out)
+	if fields["profile"] != "review" {
+		t.Fatalf("expected profile=review, got %q", out)
+	}
+	wantArgv := []string{"codex", "--model", "o3", "Review zew1me/term-workspaces on feature/review (task " + fields["task_id"] + ")"}
+	if got := fake.spawnOpts[0].Argv; !reflect.DeepEqual(got, wantArgv) {
+		t.Fatalf("unexpected spawn argv: %#v", got)
+	}
+	if got := fake.spawnOpts[0].Env; !reflect.DeepEqual(got, map[string]string{"REVIEW_MODE": "strict"}) {
+		t.Fatalf("unexpected spawn env: %#v", got)
+	}
+
+	// The profile is remembered, so a respawn without --profile reuses it.
+	fake.panes = nil
+	out, err = captureStdout(func() error { return run(args) })
+	if err != nil {
+		t.Fatalf("second open-session run failed: %v", err)
+	}
+	if fields := parseKVLine(t, out); fields["profile"] != "review" {
+		t.Fatalf("expected remembered profile, got %q", out)
+	}
+	if got := fake.spawnOpts[1].Argv; !reflect.DeepEqual(got, wantArgv) {
+		t.Fatalf("unexpected respawn argv: %#v", got)
+	}
+}
+
+func TestRunTaskOpenSessionCommandFlagAliasesProfile(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	fake := &fakeTerminalClient{nextPaneID: 2000}
+	useFakeTerminal(t, fake)
+
+	out, err := captureStdout(func() error {
+		return run([]string{
+			"task", "open-session",
+			"--repo", "zew1me/term-workspaces",
+			"--branch", "feature/legacy-command",
+			"--db", dbPath,
+			"--command", "claude",
+		})
+	})
+	if err != nil {
+		t.Fatalf("open-session --command failed: %v", err)
+	}
+	if fields := parseKVLine(t, out); fields["profile"] != "claude" {
+		t.Fatalf("expected profile=claude, got %q", out)
+	}
+	if got := fake.spawnOpts[0].Argv; !reflect.DeepEqual(got, []string{"claude"}) {
+		t.Fatalf("unexpected spawn argv: %#v", got)
+	}
+}
+
+func TestRunTaskOpenSessionRejectsUnknownProfile(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	fake := &fakeTerminalClient{nextPaneID: 2100}
+	useFakeTerminal(t, fake)
+
+	err := run([]string{
+		"task", "open-session",
+		"--repo", "zew1me/term-workspaces",
+		"--branch", "feature/typo",
+		"--db", dbPath,
+		"--profile", "codx",
+	})
+	if err == nil || !strings.Contains(err.Error(), `unknown profile "codx"`) {
+		t.Fatalf("expected unknown profile error, got %v", err)
+	}
+	if len(fake.spawnOpts) != 0 {
+		t.Fatalf("expected no spawn for unknown profile, got %#v", fake.spawnOpts)
+	}
+}
+
+func TestRunTaskOpenSessionFailsOnUnexpectedActivateError(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	fake := &fakeTerminalClient{nextPaneID: 1400}
+	useFakeTerminal(t, fake)
+
+	args := []string{
+		"task", "open-session",
+		"--repo", "zew1me/term-workspaces",
+		"--branch", "feature/activate-timeout",
+		"--db", dbPath,
+	}
+	if _, err := captureStdout(func() error { return run(args) }); err != nil {
+		t.Fatalf("first open-session run failed: %v", err)
+	}
+
+	fake.activateErr = fmt.Errorf("wezterm activate-pane: %w", terminal.ErrTimeout)
+	_, err := captureStdout(func() error { return run(args) })
+	if !errors.Is(err, terminal.ErrTimeout) {
+		t.Fatalf("expected activate timeout to surface, got %v", err)
+	}
+	if fake.spawnCalls != 1 {
+		t.Fatalf("expected no respawn on unexpected activate error, got %d spawn calls", fake.spawnCalls)
+	}
+}
+
+func TestRunTaskCloseSessionToleratesAlreadyGonePane(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	fake := &fakeTerminalClient{nextPaneID: 1500}
+	useFakeTerminal(t, fake)
+
+	if _, err := captureStdout(func() error {
+		return run([]string{
+			"task", "open-session",
+			"--repo", "zew1me/term-workspaces",
+			"--branch", "feature/close-gone",
+			"--db", dbPath,
+		})
+	}); err != nil {
+		t.Fatalf("open-session run failed: %v", err)
+	}
+
+	fake.killErr = fmt.Errorf("wezterm kill-pane: %w", terminal.ErrPaneNotFound)
+	out, err := captureStdout(func() error {
+		return run([]string{
+			"task", "close-session",
+			"--repo", "zew1me/term-workspaces",
+			"--branch", "feature/close-gone",
+			"--db", dbPath,
+		})
+	})
+	if err != nil {
+		t.Fatalf("close-session run failed: %v", err)
+	}
+	if fields := parseKVLine(t, out); fields["status"] != "closed" {
+		t.Fatalf("expected status=closed, got %q", out)
+	}
+}
+
+func TestRunTaskCloseSessionLeavesReusedPaneAlone(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	fake := &fakeTerminalClient{nextPaneID: 1550}
+	useFakeTerminal(t, fake)
+
+	args := []string{"--repo", "zew1me/term-workspaces", "--branch", "feature/close-reused", "--db", dbPath}
+	if _, err := captureStdout(func() error { return run(append([]string{"task", "open-session"}, args...)) }); err != nil {
+		t.Fatalf("open-session run failed: %v", err)
+	}
+
+	// After a terminal restart the session's pane ID belongs to another task.
+	fake.panes = []terminal.Pane{{PaneID: 1550, UserVars: map[string]string{terminal.TaskIDUserVar: "task_other"}}}
+	out, err := captureStdout(func() error { return run(append([]string{"task", "close-session"}, args...)) })
+	if err != nil {
+		t.Fatalf("close-session run failed: %v", err)
+	}
+	if fields := parseKVLine(t, out); fields["status"] != "closed" {
+		t.Fatalf("expected status=closed, got %q", out)
+	}
+	if fake.killCalls != 0 || len(fake.panes) != 1 {
+		t.Fatalf("expected the reused pane left alone, got %d kills and panes %#v", fake.killCalls, fake.panes)
+	}
+}
+
+func TestRunTaskSessionsReconcileMarksUnknownWhenMuxUnavailable(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	fake := &fakeTerminalClient{nextPaneID: 1600}
+	useFakeTerminal(t, fake)
+
+	if _, err := captureStdout(func() error {
+		return run([]string{
+			"task", "open-session",
+			"--repo", "zew1me/term-workspaces",
+			"--branch", "feature/mux-down",
+			"--db", dbPath,
+		})
+	}); err != nil {
+		t.Fatalf("open-session run failed: %v", err)
+	}
+
+	fake.listErr = fmt.Errorf("wezterm list: %w", terminal.ErrMuxUnavailable)
+	out, err := captureStdout(func() error {
+		return run([]string{"task", "sessions", "--db", dbPath, "--reconcile", "--json"})
+	})
+	if err != nil {
+		t.Fatalf("task sessions --reconcile failed: %v", err)
+	}
+	var sessions []map[string]any
+	if err := json.Unmarshal([]byte(out), &sessions); err != nil {
+		t.Fatalf("json.Unmarshal sessions failed: %v (%q)", err, out)
+	}
+	if len(sessions) != 1 || sessions[0]["status"] != "unknown" {
+		t.Fatalf("expected status=unknown while mux is unavailable, got %#v", sessions)
+	}
+}
+
+func TestRunTaskSessionsReconcileDetectsAgentState(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	fake := &fakeTerminalClient{nextPaneID: 1600}
+	useFakeTerminal(t, fake)
+
+	for _, open := range []struct{ branch, profile string }{
+		{"feature/agent-running", "codex"},
+		{"feature/agent-exited", "codex"},
+		{"feature/agent-screen", "codex"},
+		{"feature/shell-idle", "shell"},
+	} {
+		if _, err := captureStdout(func() error {
+			return run([]string{
+				"task", "open-session",
+				"--repo", "zew1me/term-workspaces",
+				"--branch", open.branch,
+				"--profile", open.profile,
+				"--db", dbPath,
+			})
+		}); err != nil {
+			t.Fatalf("open-session %s failed: %v", open.branch, err)
+		}
+	}
+
+	// The first two backends report the foreground process; the third only
+	// exposes the screen, which ends at a shell prompt. A shell profile's
+	// idle prompt is not an agent exiting.
+	fake.panes[0].ForegroundProcess = "codex"
+	fake.panes[1].ForegroundProcess = "-zsh"
+	fake.panes[3].ForegroundProcess = "-zsh"
+	fake.screens = map[int64]string{1602: "codex exited\n~/src/term-workspaces main ❯ \n\n"}
+
+	out, err := captureStdout(func() error {
+		return run([]string{"task", "sessions", "--db", dbPath, "--reconcile", "--json"})
+	})
+	if err != nil {
+		t.Fatalf("task sessions --reconcile failed: %v", err)
+	}
+	var sessions []map[string]any
+	if err := json.Unmarshal([]byte(out), &sessions); err != nil {
+		t.Fatalf("json.Unmarshal sessions failed: %v (%q)", err, out)
+	}
+	states := map[float64]any{}
+	for _, session := range sessions {
+		states[session["pane_id"].(float64)] = session["agent_state"]
+	}
+	expected := map[float64]any{1600: "running", 1601: "exited_to_shell", 1602: "exited_to_shell", 1603: "unknown"}
+	if !reflect.DeepEqual(states, expected) {
+		t.Fatalf("unexpected agent states: %#v", states)
+	}
+
+	store, err := tasks.NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	defer func() {
+		_ = store.Close()
+	}()
+	model, err := buildUIModelFromStore(context.Background(), store)
+	if err != nil {
+		t.Fatalf("buildUIModelFromStore: %v", err)
+	}
+	if view := model.SelectTab(1).View(); !strings.Contains(view, "pane=1600 agent=running") {
+		t.Fatalf("expected agent state in Open Sessions tab: %q", view)
+	}
+}
+
+func TestNewTerminalClientSelectsBackendFromConfig(t *testing.T) {
+	client, err := newTerminalClient(config.Config{Backend: "tmux"})
+	if err != nil {
+		t.Fatalf("newTerminalClient(tmux): %v", err)
+	}
+	if _, ok := client.(*tmux.CLIClient); !ok {
+		t.Fatalf("expected tmux client, got %T", client)
+	}
+
+	client, err = newTerminalClient(config.Config{Backend: "kitty", Kitty: config.KittyConfig{Socket: "unix:/tmp/kitty"}})
+	if err != nil {
+		t.Fatalf("newTerminalClient(kitty): %v", err)
+	}
+	if _, ok := client.(*kitty.CLIClient); !ok {
+		t.Fatalf("expected kitty client, got %T", client)
+	}
+
+	client, err = newTerminalClient(config.Config{})
+	if err != nil {
+		t.Fatalf("newTerminalClient(default): %v", err)
+	}
+	if _, ok := client.(*wezterm.CLIClient); !ok {
+		t.Fatalf("expected wezterm client by default, got %T", client)
+	}
+}
+
+func TestRunTaskOpenSessionRejectsUnknownBackend(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	configPath := t.TempDir() + "/config.json"
+	if err := os.WriteFile(configPath, []byte(`{"backend": "screen"}`), 0o600); err != nil {
+		t.Fatalf("WriteFile config: %v", err)
+	}
+
+	err := run([]string{
+		"task", "open-session",
+		"--repo", "zew1me/term-workspaces",
+		"--branch", "feature/backend",
+		"--db", dbPath,
+		"--config", configPath,
+	})
+	if err == nil || !strings.Contains(err.Error(), "unsupported terminal backend") {
+		t.Fatalf("expected unsupported backend error, got %v", err)
+	}
+}
+
+func TestRunWezTermExportLuaWritesModule(t *testing.T) {
+	outputPath := t.TempDir() + "/wezterm/ttt.lua"
+
+	out, err := captureStdout(func() error {
+		return run([]string{
+			"wezterm", "export-lua",
+			"--output", outputPath,
+			"--key", "k",
+			"--mods", "CMD",
+			"--label", "{repo}: {alias}",
+		})
+	})
+	if err != nil {
+		t.Fatalf("wezterm export-lua failed: %v", err)
+	}
+	if !strings.Contains(out, "require=ttt") {
+		t.Fatalf("expected require hint in output: %q", out)
+	}
+
+	// #nosec G304 -- outputPath is generated in test setup via t.TempDir.
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("ReadFile(%q): %v", outputPath, err)
+	}
+	for _, want := range []string{`key = "k"`, `mods = "CMD"`, `label = "{repo}: {alias}"`, "act.InputSelector", `"open-session"`} {
+		if !strings.Contains(string(content), want) {
+			t.Fatalf("expected %q in generated lua:\n%s", want, content)
+		}
+	}
+}
+
 func TestWorkspaceForTaskIDDeterministic(t *testing.T) {
 	taskID := "task_1700000000000_1"
 	first := workspaceForTaskID(taskID)
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"term-workspaces/internal/config"
+	"term-workspaces/internal/tasks"
+	"time"
+)
+
+type setMetaResult struct {
+	TaskID   string             `json:"task_id"`
+	Metadata tasks.NoteMetadata `json:"metadata"`
+	NotePath string             `json:"note_path"`
+	// Note is "updated", "unchanged" or "missing".
+	Note string `json:"note"`
+}
+
+// runTaskSetMeta changes task metadata and writes it back into the note's
+// front matter. Only the flags given change; the note's current front matter
+// fills in the rest. A rewritten note is versioned and, in notes git mode,
+// committed like any other note edit.
+func runTaskSetMeta(args []string) error {
+	fs := flag.NewFlagSet("task set-meta", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	repo := fs.String("repo", "", "GitHub repository in owner/repo format")
+	branch := fs.String("branch", "", "Branch name (optional when using --pr)")
+	prNumber := fs.Int("pr", 0, "Pull request number (optional when using --branch)")
+	dbPath := fs.String("db", defaultDBPath(), "Path to sqlite database")
+	configPath := fs.String("config", defaultConfigPath(), "Path to JSON config file")
+	notesDir := fs.String("notes-dir", defaultNotesDir(), "Directory for task note markdown files")
+	title := fs.String("title", "", "Task title (empty clears it)")
+	tags := fs.String("tags", "", "Comma-separated tags (empty clears them)")
+	priority := fs.String("priority", "", "Task priority (empty clears it)")
+	status := fs.String("status", "", "Task status (empty clears it)")
+	due := fs.String("due", "", "Due date as YYYY-MM-DD (empty clears it)")
+	jsonOutput := fs.Bool("json", false, "Emit machine-readable JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+
+	store, err := tasks.NewSQLiteStore(*dbPath)
+	if err != nil {
+		return fmt.Errorf("open sqlite task store: %w", err)
+	}
+	defer func() {
+		_ = store.Close()
+	}()
+
+	ctx := context.Background()
+	task, err := lookupTask(ctx, tasks.NewService(store), *repo, *branch, *prNumber)
+	if err != nil {
+		return err
+	}
+
+	notePath := tasks.NotePath(*notesDir, task.ID)
+	// #nosec G304 -- the note path is derived from the notes dir and task ID.
+	content, err := os.ReadFile(notePath)
+	noteExists := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("read task note: %w", err)
+	}
+
+	meta := task.Metadata
+	if noteExists {
+		noteMeta, ok, err := tasks.ParseNoteMetadata(string(content))
+		if err != nil {
+			return fmt.Errorf("%s: %w", notePath, err)
+		}
+		if ok {
+			meta = noteMeta
+		}
+	}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "title":
+			meta.Title = strings.TrimSpace(*title)
+		case "tags":
+			meta.Tags = splitTags(*tags)
+		case "priority":
+			meta.Priority = strings.TrimSpace(*priority)
+		case "status":
+			meta.Status = strings.TrimSpace(*status)
+		case "due":
+			meta.Due = strings.TrimSpace(*due)
+		}
+	})
+	if err := meta.Validate(); err != nil {
+		return err
+	}
+
+	if err := store.UpdateTaskMetadata(ctx, task.ID, meta, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	result := setMetaResult{TaskID: task.ID, Metadata: meta, NotePath: notePath, Note: "missing"}
+	if noteExists {
+		result.Note = "unchanged"
+		if updated := tasks.SetNoteMetadata(string(content), meta); updated != string(content) {
+			if err := os.WriteFile(notePath, []byte(updated), 0o600); err != nil {
+				return fmt.Errorf("write task note: %w", err)
+			}
+			result.Note = "updated"
+			snapshotTaskNote(ctx, store, *notesDir, task.ID, "set-meta")
+			commitTaskNote(ctx, cfg, store, *notesDir, task.ID, "task set-meta")
+		}
+	}
+
+	if *jsonOutput {
+		return writeJSON(result)
+	}
+	fmt.Printf("task_id=%s status=updated note=%s note_path=%s\n", result.TaskID, result.Note, result.NotePath)
+	return nil
+}
+
+func splitTags(value string) []string {
+	tags := make([]string, 0)
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRunTaskSetMetaWritesFrontMatterAndDashboardReadsIt(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	notesDir := t.TempDir()
+	useTestUserState(t)
+
+	out, err := captureStdout(func() error {
+		return run([]string{
+			"task", "ensure-note",
+			"--repo", "zew1me/term-workspaces",
+			"--branch", "feature/meta",
+			"--db", dbPath,
+			"--notes-dir", notesDir,
+		})
+	})
+	if err != nil {
+		t.Fatalf("ensure-note failed: %v", err)
+	}
+	notePath := parseKVLine(t, out)["note_path"]
+	body := "# Task State\n\n## Status\nWiring metadata.\n"
+	if err := os.WriteFile(notePath, []byte("---\ntitle: Metadata\npriority: low\nowner: me\n---\n"+body), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	out, err = captureStdout(func() error {
+		return run([]string{
+			"task", "set-meta",
+			"--repo", "zew1me/term-workspaces",
+			"--branch", "feature/meta",
+			"--db", dbPath,
+			"--notes-dir", notesDir,
+			"--tags", "cli, notes",
+			"--priority", "high",
+			"--due", "2026-04-01",
+		})
+	})
+	if err != nil {
+		t.Fatalf("set-meta failed: %v", err)
+	}
+	if kv := parseKVLine(t, out); kv["note"] != "updated" || kv["note_path"] != notePath {
+		t.Fatalf("unexpected set-meta output: %q", out)
+	}
+	content, err := os.ReadFile(notePath)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	expected := "---\ntitle: Metadata\npriority: high\nowner: me\ntags: [cli, notes]\ndue: 2026-04-01\n---\n" + body
+	if string(content) != expected {
+		t.Fatalf("unexpected note:\n%s", content)
+	}
+	// The rewritten note is kept as a version.
+	out, err = captureStdout(func() error {
+		return run([]string{"note", "history", "--repo", "zew1me/term-workspaces", "--branch", "feature/meta", "--db", dbPath})
+	})
+	if err != nil {
+		t.Fatalf("note history failed: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); !strings.Contains(lines[len(lines)-1], " source=set-meta ") {
+		t.Fatalf("expected a set-meta version last, got %q", out)
+	}
+
+	if err := run([]string{"task", "set-meta", "--repo", "zew1me/term-workspaces", "--branch", "feature/meta", "--db", dbPath, "--notes-dir", notesDir, "--due", "soon"}); err == nil {
+		t.Fatalf("expected invalid due date to fail")
+	}
+
+	// An edit to the note itself reaches the task on the next read.
+	edited := strings.Replace(string(content), "priority: high", "priority: urgent", 1)
+	if err := os.WriteFile(notePath, []byte(edited), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	out, err = captureStdout(func() error {
+		return run([]string{"task", "dashboard", "--db", dbPath, "--notes-dir", notesDir, "--json"})
+	})
+	if err != nil {
+		t.Fatalf("dashboard failed: %v", err)
+	}
+	var payload dashboardPayload
+	if err := json.Unmarshal([]byte(out), &payload); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	if len(payload.Tasks) != 1 {
+		t.Fatalf("expected one task, got %#v", payload.Tasks)
+	}
+	meta := payload.Tasks[0].Task.Metadata
+	if meta.Title != "Metadata" || meta.Priority != "urgent" || meta.Due != "2026-04-01" || strings.Join(meta.Tags, ",") != "cli,notes" {
+		t.Fatalf("unexpected dashboard metadata: %#v", meta)
+	}
+}
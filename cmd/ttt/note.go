@@ -0,0 +1,383 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"term-workspaces/internal/tasks"
+	"time"
+)
+
+type noteSearchResult struct {
+	TaskID   string               `json:"task_id"`
+	Rank     float64              `json:"rank"`
+	Snippet  string               `json:"snippet"`
+	NotePath string               `json:"note_path"`
+	Aliases  []tasks.TaskAliasRow `json:"aliases"`
+}
+
+func runNote(args []string) error {
+	if len(args) == 0 {
+		return printNoteUsage()
+	}
+
+	switch args[0] {
+	case "search":
+		return runNoteSearch(args[1:])
+	case "links":
+		return runNoteLinks(args[1:])
+	case "sync":
+		return runNoteSync(args[1:])
+	case "history":
+		return runNoteHistory(args[1:])
+	case "diff":
+		return runNoteDiff(args[1:])
+	default:
+		return printNoteUsage()
+	}
+}
+
+func runNoteSearch(args []string) error {
+	fs := flag.NewFlagSet("note search", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	dbPath := fs.String("db", defaultDBPath(), "Path to sqlite database")
+	notesDir := fs.String("notes-dir", defaultNotesDir(), "Directory for task note markdown files")
+	limit := fs.Int("limit", 10, "Maximum number of tasks to return")
+	jsonOutput := fs.Bool("json", false, "Emit machine-readable JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	query := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if query == "" {
+		return fmt.Errorf("search query is required")
+	}
+
+	store, err := tasks.NewSQLiteStore(*dbPath)
+	if err != nil {
+		return fmt.Errorf("open sqlite task store: %w", err)
+	}
+	defer func() {
+		_ = store.Close()
+	}()
+
+	ctx := context.Background()
+	if _, err := store.RefreshNoteIndex(ctx, *notesDir); err != nil {
+		return err
+	}
+	hits, err := store.SearchNotes(ctx, query, *limit)
+	if err != nil {
+		return err
+	}
+	aliases, err := store.ListTaskAliasRows(ctx)
+	if err != nil {
+		return fmt.Errorf("list task aliases: %w", err)
+	}
+	aliasesByTask := make(map[string][]tasks.TaskAliasRow)
+	for _, alias := range aliases {
+		aliasesByTask[alias.TaskID] = append(aliasesByTask[alias.TaskID], alias)
+	}
+
+	results := make([]noteSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		results = append(results, noteSearchResult{
+			TaskID:   hit.TaskID,
+			Rank:     hit.Rank,
+			Snippet:  strings.Join(strings.Fields(hit.Snippet), " "),
+			NotePath: tasks.NotePath(*notesDir, hit.TaskID),
+			Aliases:  aliasesByTask[hit.TaskID],
+		})
+	}
+
+	if *jsonOutput {
+		return writeJSON(results)
+	}
+	if len(results) == 0 {
+		fmt.Println("no matching notes")
+		return nil
+	}
+	for _, result := range results {
+		alias := "<none>"
+		if len(result.Aliases) > 0 {
+			alias = primaryAliasDisplay(result.Aliases)
+		}
+		fmt.Printf("task_id=%s alias=%s snippet=%s\n", result.TaskID, alias, result.Snippet)
+	}
+	return nil
+}
+
+// snapshotTaskNote records the task note as a new version when it changed
+// since the last snapshot. Failures only warn.
+func snapshotTaskNote(ctx context.Context, store *tasks.SQLiteStore, notesDir, taskID, source string) {
+	path := tasks.NotePath(notesDir, taskID)
+	_, _, err := store.SnapshotNoteFile(ctx, taskID, path, source, time.Now().UTC())
+	switch {
+	case errors.Is(err, tasks.ErrNoteMetadataNotSynced):
+		// The version was saved; only the front matter didn't make it.
+		fmt.Fprintf(os.Stderr, "ttt: %v\n", err)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "ttt: note version not saved: %v\n", err)
+	}
+}
+
+type noteLinksResult struct {
+	TaskID    string           `json:"task_id"`
+	Links     []tasks.TaskLink `json:"links"`
+	Backlinks []tasks.TaskLink `json:"backlinks"`
+}
+
+func runNoteLinks(args []string) error {
+	fs := flag.NewFlagSet("note links", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	repo := fs.String("repo", "", "GitHub repository in owner/repo format")
+	branch := fs.String("branch", "", "Branch name (optional when using --pr)")
+	prNumber := fs.Int("pr", 0, "Pull request number (optional when using --branch)")
+	dbPath := fs.String("db", defaultDBPath(), "Path to sqlite database")
+	notesDir := fs.String("notes-dir", defaultNotesDir(), "Directory for task note markdown files")
+	jsonOutput := fs.Bool("json", false, "Emit machine-readable JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := tasks.NewSQLiteStore(*dbPath)
+	if err != nil {
+		return fmt.Errorf("open sqlite task store: %w", err)
+	}
+	defer func() {
+		_ = store.Close()
+	}()
+
+	ctx := context.Background()
+	task, err := lookupTask(ctx, tasks.NewService(store), *repo, *branch, *prNumber)
+	if err != nil {
+		return err
+	}
+	if _, err := store.RefreshNoteIndex(ctx, *notesDir); err != nil {
+		return err
+	}
+	links, err := store.ListTaskLinks(ctx)
+	if err != nil {
+		return err
+	}
+
+	result := noteLinksResult{TaskID: task.ID, Links: make([]tasks.TaskLink, 0), Backlinks: make([]tasks.TaskLink, 0)}
+	for _, link := range links {
+		if link.FromTaskID == task.ID {
+			result.Links = append(result.Links, link)
+		}
+		if link.ToTaskID == task.ID {
+			result.Backlinks = append(result.Backlinks, link)
+		}
+	}
+
+	if *jsonOutput {
+		return writeJSON(result)
+	}
+	if len(result.Links) == 0 && len(result.Backlinks) == 0 {
+		fmt.Println("no note links")
+		return nil
+	}
+	// An unresolved link has an empty linked_task_id.
+	for _, link := range result.Links {
+		fmt.Printf("task_id=%s link=%s linked_task_id=%s\n", task.ID, link.AliasValue, link.ToTaskID)
+	}
+	for _, link := range result.Backlinks {
+		fmt.Printf("task_id=%s backlink=%s linked_task_id=%s\n", task.ID, link.AliasValue, link.FromTaskID)
+	}
+	return nil
+}
+
+func runNoteHistory(args []string) error {
+	fs := flag.NewFlagSet("note history", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	repo := fs.String("repo", "", "GitHub repository in owner/repo format")
+	branch := fs.String("branch", "", "Branch name (optional when using --pr)")
+	prNumber := fs.Int("pr", 0, "Pull request number (optional when using --branch)")
+	dbPath := fs.String("db", defaultDBPath(), "Path to sqlite database")
+	jsonOutput := fs.Bool("json", false, "Emit machine-readable JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := tasks.NewSQLiteStore(*dbPath)
+	if err != nil {
+		return fmt.Errorf("open sqlite task store: %w", err)
+	}
+	defer func() {
+		_ = store.Close()
+	}()
+
+	ctx := context.Background()
+	task, err := lookupTask(ctx, tasks.NewService(store), *repo, *branch, *prNumber)
+	if err != nil {
+		return err
+	}
+	versions, err := store.ListNoteVersions(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+
+	if *jsonOutput {
+		return writeJSON(versions)
+	}
+	if len(versions) == 0 {
+		fmt.Println("no note versions")
+		return nil
+	}
+	for _, version := range versions {
+		fmt.Printf("task_id=%s version=%d created_at=%s source=%s lines=%d\n",
+			version.TaskID, version.Version, version.CreatedAt.Format(time.RFC3339), version.Source, strings.Count(version.Content, "\n"))
+	}
+	return nil
+}
+
+func runNoteDiff(args []string) error {
+	fs := flag.NewFlagSet("note diff", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	repo := fs.String("repo", "", "GitHub repository in owner/repo format")
+	branch := fs.String("branch", "", "Branch name (optional when using --pr)")
+	prNumber := fs.Int("pr", 0, "Pull request number (optional when using --branch)")
+	dbPath := fs.String("db", defaultDBPath(), "Path to sqlite database")
+	notesDir := fs.String("notes-dir", defaultNotesDir(), "Directory for task note markdown files")
+	since := fs.String("since", "", "Version number or time (RFC3339 or YYYY-MM-DD) to diff from")
+	to := fs.Int("to", 0, "Version to diff to (default: the current note)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*since) == "" {
+		return fmt.Errorf("--since is required")
+	}
+
+	store, err := tasks.NewSQLiteStore(*dbPath)
+	if err != nil {
+		return fmt.Errorf("open sqlite task store: %w", err)
+	}
+	defer func() {
+		_ = store.Close()
+	}()
+
+	ctx := context.Background()
+	task, err := lookupTask(ctx, tasks.NewService(store), *repo, *branch, *prNumber)
+	if err != nil {
+		return err
+	}
+	versions, err := store.ListNoteVersions(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+
+	fromName, fromContent, err := noteDiffBase(task.ID, versions, *since)
+	if err != nil {
+		return err
+	}
+	var toName, toContent string
+	if *to > 0 {
+		version, ok := findNoteVersion(versions, *to)
+		if !ok {
+			return fmt.Errorf("task %s has no note version %d", task.ID, *to)
+		}
+		toName, toContent = noteVersionLabel(version), version.Content
+	} else {
+		path := tasks.NotePath(*notesDir, task.ID)
+		// #nosec G304 -- the note path is derived from the notes dir and task ID.
+		content, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("read task note: %w", err)
+		}
+		toName, toContent = path, string(content)
+	}
+
+	fmt.Print(tasks.UnifiedDiff(fromName, toName, fromContent, toContent))
+	return nil
+}
+
+// noteDiffBase picks the version --since names: a version number, or the
+// latest version saved at or before a time. A time before the first version
+// diffs from an empty note.
+func noteDiffBase(taskID string, versions []tasks.NoteVersion, since string) (string, string, error) {
+	if number, err := strconv.Atoi(since); err == nil {
+		version, ok := findNoteVersion(versions, number)
+		if !ok {
+			return "", "", fmt.Errorf("task %s has no note version %d", taskID, number)
+		}
+		return noteVersionLabel(version), version.Content, nil
+	}
+
+	cutoff, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		cutoff, err = time.ParseInLocation(time.DateOnly, since, time.Local)
+		if err != nil {
+			return "", "", fmt.Errorf("--since must be a version number, RFC3339 time or YYYY-MM-DD date: %q", since)
+		}
+	}
+	base := "/dev/null"
+	content := ""
+	for _, version := range versions {
+		if version.CreatedAt.After(cutoff) {
+			break
+		}
+		base, content = noteVersionLabel(version), version.Content
+	}
+	return base, content, nil
+}
+
+func findNoteVersion(versions []tasks.NoteVersion, number int) (tasks.NoteVersion, bool) {
+	for _, version := range versions {
+		if version.Version == number {
+			return version, true
+		}
+	}
+	return tasks.NoteVersion{}, false
+}
+
+func noteVersionLabel(version tasks.NoteVersion) string {
+	return fmt.Sprintf("%s@v%d\t%s", version.TaskID, version.Version, version.CreatedAt.Format(time.RFC3339))
+}
+
+// lookupTask finds an existing task by PR or branch without creating one.
+func lookupTask(ctx context.Context, service *tasks.Service, repo, branch string, prNumber int) (tasks.Task, error) {
+	if repo == "" {
+		return tasks.Task{}, fmt.Errorf("--repo is required")
+	}
+	if branch == "" && prNumber <= 0 {
+		return tasks.Task{}, fmt.Errorf("one of --branch or --pr is required")
+	}
+
+	var (
+		task  tasks.Task
+		found bool
+		err   error
+		alias string
+	)
+	if prNumber > 0 {
+		task, found, err = service.GetTaskByPR(ctx, repo, prNumber)
+		alias = tasks.PRAliasValue(repo, prNumber)
+	} else {
+		task, found, err = service.GetTaskByPrePR(ctx, repo, branch)
+		alias = tasks.PrePRAliasValue(repo, branch)
+	}
+	if err != nil {
+		return tasks.Task{}, fmt.Errorf("resolve task: %w", err)
+	}
+	if !found {
+		return tasks.Task{}, fmt.Errorf("no task found for %s", alias)
+	}
+	return task, nil
+}
+
+func printNoteUsage() error {
+	fmt.Println("ttt note usage:")
+	fmt.Println("  ttt note search [--db path] [--notes-dir path] [--limit n] [--json] <query>")
+	fmt.Println("  ttt note links --repo owner/repo [--branch feature/name] [--pr 123] [--db path] [--notes-dir path] [--json]")
+	fmt.Println("  ttt note history --repo owner/repo [--branch feature/name] [--pr 123] [--db path] [--json]")
+	fmt.Println("  ttt note diff --repo owner/repo [--branch feature/name] [--pr 123] --since version|time [--to version] [--db path] [--notes-dir path]")
+	fmt.Println("  ttt note sync [--config path] [--notes-dir path] [--remote url] [--json]")
+	return nil
+}
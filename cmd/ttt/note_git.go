@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"term-workspaces/internal/config"
+	"term-workspaces/internal/git"
+	"term-workspaces/internal/tasks"
+)
+
+// notesGitClient versions the notes directory (see config.NotesConfig).
+type notesGitClient interface {
+	Init(ctx context.Context, dir string) (bool, error)
+	Commit(ctx context.Context, dir, message string, paths ...string) (bool, error)
+	Sync(ctx context.Context, dir, remoteURL, message string) (git.SyncResult, error)
+}
+
+var newNotesGitClient = func() notesGitClient {
+	return git.NewCLIClient()
+}
+
+// initNotesRepo makes notesDir a git repository when notes git mode is on.
+// Notes work without history, so a failure is reported but not fatal.
+func initNotesRepo(ctx context.Context, cfg config.Config, notesDir string) {
+	if !cfg.Notes.Git {
+		return
+	}
+	if _, err := newNotesGitClient().Init(ctx, notesDir); err != nil {
+		fmt.Fprintf(os.Stderr, "ttt: notes repository not initialized: %v\n", err)
+	}
+}
+
+// commitTaskNote commits the task's note, and nothing else, when notes git
+// mode is on and the note changed. command names the ttt command that saved
+// it.
+func commitTaskNote(ctx context.Context, cfg config.Config, store *tasks.SQLiteStore, notesDir, taskID, command string) {
+	if !cfg.Notes.Git {
+		return
+	}
+	path := tasks.NotePath(notesDir, taskID)
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+
+	client := newNotesGitClient()
+	if _, err := client.Init(ctx, notesDir); err != nil {
+		fmt.Fprintf(os.Stderr, "ttt: notes repository not initialized: %v\n", err)
+		return
+	}
+	message, err := noteCommitMessage(ctx, store, taskID, command)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ttt: note not committed: %v\n", err)
+		return
+	}
+	if _, err := client.Commit(ctx, notesDir, message, filepath.Base(path)); err != nil {
+		fmt.Fprintf(os.Stderr, "ttt: note not committed: %v\n", err)
+	}
+}
+
+// noteCommitMessage names the task by its aliases, falling back to its ID.
+func noteCommitMessage(ctx context.Context, store *tasks.SQLiteStore, taskID, command string) (string, error) {
+	rows, err := store.ListTaskAliasRows(ctx)
+	if err != nil {
+		return "", fmt.Errorf("list task aliases: %w", err)
+	}
+	aliases := make([]string, 0)
+	for _, row := range rows {
+		if row.TaskID == taskID {
+			aliases = append(aliases, row.AliasValue)
+		}
+	}
+	sort.Strings(aliases)
+	subject := taskID
+	if len(aliases) > 0 {
+		subject = strings.Join(aliases, ", ")
+	}
+	return fmt.Sprintf("Update note: %s\n\nTask %s, saved by ttt %s.", subject, taskID, command), nil
+}
+
+func runNoteSync(args []string) error {
+	fs := flag.NewFlagSet("note sync", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	configPath := fs.String("config", defaultConfigPath(), "Path to JSON config file")
+	notesDir := fs.String("notes-dir", defaultNotesDir(), "Directory for task note markdown files")
+	remote := fs.String("remote", "", "Remote URL to sync with (default: notes.remote from the config, then the existing origin)")
+	jsonOutput := fs.Bool("json", false, "Emit machine-readable JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+	// Sync never turns the notes dir into a repository on its own.
+	if !cfg.Notes.Git {
+		return fmt.Errorf(`notes git mode is off; set "notes": {"git": true} in the config to sync notes`)
+	}
+	remoteURL := strings.TrimSpace(*remote)
+	if remoteURL == "" {
+		remoteURL = strings.TrimSpace(cfg.Notes.Remote)
+	}
+
+	if err := os.MkdirAll(*notesDir, 0o750); err != nil {
+		return fmt.Errorf("create notes dir: %w", err)
+	}
+	ctx := context.Background()
+	client := newNotesGitClient()
+	if _, err := client.Init(ctx, *notesDir); err != nil {
+		return fmt.Errorf("initialize notes repository: %w", err)
+	}
+	result, err := client.Sync(ctx, *notesDir, remoteURL, "Sync notes")
+	if err != nil {
+		return fmt.Errorf("sync notes: %w", err)
+	}
+
+	if *jsonOutput {
+		return writeJSON(result)
+	}
+	fmt.Printf("status=synced notes_dir=%s branch=%s committed=%t pulled=%t pushed=%t\n",
+		*notesDir, result.Branch, result.Committed, result.Pulled, result.Pushed)
+	return nil
+}
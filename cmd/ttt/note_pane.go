@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"term-workspaces/internal/tasks"
+	"term-workspaces/internal/terminal"
+	"time"
+)
+
+// openNoteInSession splits the task session's live pane and runs argv (the
+// editor command) beside it, recording the new pane as an auxiliary pane of
+// the session.
+func openNoteInSession(ctx context.Context, store *tasks.SQLiteStore, client terminal.Client, taskID string, argv []string) (tasks.TaskSession, int64, error) {
+	session, found, err := store.GetSessionByTaskID(ctx, taskID)
+	if err != nil {
+		return tasks.TaskSession{}, 0, fmt.Errorf("load existing session: %w", err)
+	}
+	if !found || session.Status != tasks.SessionStatusOpen || session.PaneID <= 0 {
+		return tasks.TaskSession{}, 0, fmt.Errorf("task %s has no open session; run `ttt task open-session` first", taskID)
+	}
+	panes, err := client.ListPanes(ctx)
+	if err != nil {
+		return tasks.TaskSession{}, 0, fmt.Errorf("list panes for liveness check: %w", err)
+	}
+	if _, alive := sessionPane(panes, session); !alive {
+		return tasks.TaskSession{}, 0, fmt.Errorf("task %s session pane %d is gone; run `ttt task open-session` first", taskID, session.PaneID)
+	}
+
+	paneID, err := client.SplitPane(ctx, session.PaneID, terminal.SplitOptions{
+		Cwd:      session.Cwd,
+		UserVars: map[string]string{terminal.TaskIDUserVar: taskID},
+		Argv:     argv,
+	})
+	if err != nil {
+		return tasks.TaskSession{}, 0, err
+	}
+
+	// Drop aux panes that have since closed (an editor that exited) so the
+	// list does not grow without bound.
+	session.AuxPaneIDs = append(liveAuxPanes(panes, session), paneID)
+	session.UpdatedAt = time.Now().UTC()
+	if err := store.UpsertSession(ctx, session); err != nil {
+		return tasks.TaskSession{}, 0, fmt.Errorf("persist session aux panes: %w", err)
+	}
+	return session, paneID, nil
+}
+
+// liveAuxPanes returns the session's aux panes that are still listed and
+// still tagged with its task.
+func liveAuxPanes(panes []terminal.Pane, session tasks.TaskSession) []int64 {
+	live := make([]int64, 0, len(session.AuxPaneIDs))
+	for _, paneID := range session.AuxPaneIDs {
+		if _, ok := sessionPane(panes, tasks.TaskSession{TaskID: session.TaskID, PaneID: paneID, PaneTagged: true}); ok {
+			live = append(live, paneID)
+		}
+	}
+	return live
+}
+
+// killSessionPanes kills the session's pane and aux panes that are still
+// alive. Panes whose IDs were reused by other programs after a terminal
+// restart are left alone.
+func killSessionPanes(ctx context.Context, client terminal.Client, session tasks.TaskSession) error {
+	if session.PaneID <= 0 && len(session.AuxPaneIDs) == 0 {
+		return nil
+	}
+	panes, err := client.ListPanes(ctx)
+	if err != nil {
+		return fmt.Errorf("list panes for session cleanup: %w", err)
+	}
+	for _, paneID := range liveAuxPanes(panes, session) {
+		if err := client.KillPane(ctx, paneID); err != nil && !errors.Is(err, terminal.ErrPaneNotFound) {
+			return fmt.Errorf("kill aux pane %d: %w", paneID, err)
+		}
+	}
+	if _, alive := sessionPane(panes, session); !alive || session.PaneID <= 0 {
+		return nil
+	}
+	if err := client.KillPane(ctx, session.PaneID); err != nil && !errors.Is(err, terminal.ErrPaneNotFound) {
+		return fmt.Errorf("kill pane %d: %w", session.PaneID, err)
+	}
+	return nil
+}
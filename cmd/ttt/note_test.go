@@ -0,0 +1,544 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"term-workspaces/internal/tasks"
+	"term-workspaces/internal/terminal"
+	"testing"
+)
+
+func TestRunNoteSearchRanksTasksWithAliases(t *testing.T) {
+	useTestUserState(t)
+	dbPath := t.TempDir() + "/state.db"
+	notesDir := t.TempDir()
+
+	paths := make(map[string]string)
+	for _, branch := range []string{"feature/cache", "feature/login"} {
+		out, err := captureStdout(func() error {
+			return run([]string{
+				"task", "ensure-note",
+				"--repo", "zew1me/term-workspaces",
+				"--branch", branch,
+				"--db", dbPath,
+				"--notes-dir", notesDir,
+			})
+		})
+		if err != nil {
+			t.Fatalf("ensure-note %s failed: %v", branch, err)
+		}
+		paths[branch] = parseKVLine(t, strings.TrimSpace(out))["note_path"]
+	}
+	if err := os.WriteFile(paths["feature/cache"], []byte("## Status\nDebugging the flaky cache test.\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	out, err := captureStdout(func() error {
+		return run([]string{"note", "search", "--db", dbPath, "--notes-dir", notesDir, "--json", "flaky", "cache"})
+	})
+	if err != nil {
+		t.Fatalf("note search failed: %v", err)
+	}
+	var results []noteSearchResult
+	if err := json.Unmarshal([]byte(out), &results); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v (%q)", err, out)
+	}
+	if len(results) != 1 || results[0].NotePath != paths["feature/cache"] || !strings.Contains(results[0].Snippet, "[flaky] [cache]") {
+		t.Fatalf("unexpected search results: %#v", results)
+	}
+	if len(results[0].Aliases) != 1 || results[0].Aliases[0].Branch != "feature/cache" {
+		t.Fatalf("expected branch alias on result, got %#v", results[0].Aliases)
+	}
+
+	out, err = captureStdout(func() error {
+		return run([]string{"note", "search", "--db", dbPath, "--notes-dir", notesDir, "nothing-matches"})
+	})
+	if err != nil {
+		t.Fatalf("note search failed: %v", err)
+	}
+	if strings.TrimSpace(out) != "no matching notes" {
+		t.Fatalf("unexpected empty search output: %q", out)
+	}
+
+	if err := run([]string{"note", "search", "--db", dbPath}); err == nil {
+		t.Fatalf("expected missing query error")
+	}
+}
+
+func TestNoteHistoryAndDiffTrackEdits(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	notesDir := t.TempDir()
+	fake := &fakeTerminalClient{nextPaneID: 4100}
+	useFakeTerminal(t, fake)
+
+	editor := t.TempDir() + "/editor.sh"
+	if err := os.WriteFile(editor, []byte("#!/bin/sh\necho '- [ ] edited in editor' >> \"$1\"\n"), 0o700); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	t.Setenv("EDITOR", editor)
+
+	taskArgs := []string{"--repo", "zew1me/term-workspaces", "--branch", "feature/history", "--db", dbPath}
+	out, err := captureStdout(func() error {
+		return run(append([]string{"task", "open-note", "--notes-dir", notesDir}, taskArgs...))
+	})
+	if err != nil {
+		t.Fatalf("open-note failed: %v", err)
+	}
+	notePath := parseKVLine(t, strings.TrimSpace(out))["note_path"]
+
+	var versions []tasks.NoteVersion
+	out, err = captureStdout(func() error {
+		return run(append([]string{"note", "history", "--json"}, taskArgs...))
+	})
+	if err != nil {
+		t.Fatalf("note history failed: %v", err)
+	}
+	if err := json.Unmarshal([]byte(out), &versions); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v (%q)", err, out)
+	}
+	if len(versions) != 2 || versions[0].Source != "open-note" || !strings.HasSuffix(versions[1].Content, "- [ ] edited in editor\n") {
+		t.Fatalf("expected template and edited versions, got %#v", versions)
+	}
+
+	out, err = captureStdout(func() error {
+		return run(append([]string{"note", "diff", "--since", "1", "--notes-dir", notesDir}, taskArgs...))
+	})
+	if err != nil {
+		t.Fatalf("note diff failed: %v", err)
+	}
+	if !strings.HasPrefix(out, "--- "+versions[0].TaskID+"@v1\t") || !strings.Contains(out, "\n+++ "+notePath+"\n") || !strings.Contains(out, "\n+- [ ] edited in editor") {
+		t.Fatalf("unexpected diff output:\n%s", out)
+	}
+	out, err = captureStdout(func() error {
+		return run(append([]string{"note", "diff", "--since", "2", "--notes-dir", notesDir}, taskArgs...))
+	})
+	if err != nil || out != "" {
+		t.Fatalf("expected no diff since latest version, got %q err=%v", out, err)
+	}
+
+	// Edits made outside ttt are captured when a session opens.
+	file, err := os.OpenFile(notePath, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	_, _ = file.WriteString("outside edit\n")
+	_ = file.Close()
+	if _, err := captureStdout(func() error {
+		return run(append([]string{"task", "open-session", "--notes-dir", notesDir}, taskArgs...))
+	}); err != nil {
+		t.Fatalf("open-session failed: %v", err)
+	}
+	out, err = captureStdout(func() error {
+		return run(append([]string{"note", "history"}, taskArgs...))
+	})
+	if err != nil {
+		t.Fatalf("note history failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[2], " source=open-session ") {
+		t.Fatalf("expected open-session snapshot, got %q", out)
+	}
+
+	out, err = captureStdout(func() error {
+		return run(append([]string{"note", "diff", "--since", "2000-01-01", "--to", "1", "--notes-dir", notesDir}, taskArgs...))
+	})
+	if err != nil || !strings.HasPrefix(out, "--- /dev/null\n") {
+		t.Fatalf("expected diff from empty note, got %q err=%v", out, err)
+	}
+	if err := run(append([]string{"note", "diff", "--since", "9"}, taskArgs...)); err == nil {
+		t.Fatalf("expected unknown version error")
+	}
+}
+
+func TestRunTaskOpenNoteHandsOffToPlatformOpener(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("fakes xdg-open")
+	}
+	dbPath := t.TempDir() + "/state.db"
+	notesDir := t.TempDir()
+	useTestUserState(t)
+	t.Setenv("EDITOR", "")
+	// xdg-open returns at once; the edit lands after ttt has moved on.
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "xdg-open"), []byte("#!/bin/sh\nexit 0\n"), 0o700); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	taskArgs := []string{"--repo", "zew1me/term-workspaces", "--branch", "feature/desktop", "--db", dbPath}
+	out, err := captureStdout(func() error {
+		return run(append([]string{"task", "open-note", "--notes-dir", notesDir}, taskArgs...))
+	})
+	if err != nil {
+		t.Fatalf("open-note failed: %v", err)
+	}
+	if fields := parseKVLine(t, strings.TrimSpace(out)); fields["status"] != "handed_off" || fields["editor"] != "xdg-open" {
+		t.Fatalf("unexpected open-note output: %q", out)
+	}
+
+	out, err = captureStdout(func() error {
+		return run(append([]string{"note", "history"}, taskArgs...))
+	})
+	if err != nil {
+		t.Fatalf("note history failed: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 1 {
+		t.Fatalf("expected only the pre-edit version, got %q", out)
+	}
+}
+
+func TestRunTaskOpenNoteInSessionSplitsAndCloseSessionCleansUp(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	notesDir := t.TempDir()
+	fake := &fakeTerminalClient{nextPaneID: 6100}
+	useFakeTerminal(t, fake)
+	t.Setenv("EDITOR", "vim")
+
+	taskArgs := []string{"--repo", "zew1me/term-workspaces", "--branch", "feature/split", "--db", dbPath, "--notes-dir", notesDir}
+	openNote := func() (string, error) {
+		return captureStdout(func() error {
+			return run(append([]string{"task", "open-note", "--in-session"}, taskArgs...))
+		})
+	}
+	if _, err := openNote(); err == nil || !strings.Contains(err.Error(), "no open session") {
+		t.Fatalf("expected missing session error, got %v", err)
+	}
+
+	if _, err := captureStdout(func() error {
+		return run(append([]string{"task", "open-session", "--cwd", t.TempDir()}, taskArgs...))
+	}); err != nil {
+		t.Fatalf("open-session failed: %v", err)
+	}
+	out, err := openNote()
+	if err != nil {
+		t.Fatalf("open-note --in-session failed: %v", err)
+	}
+	fields := parseKVLine(t, out)
+	if fields["status"] != "split" || fields["pane_id"] != "6200" || fields["session_pane_id"] != "6100" {
+		t.Fatalf("unexpected open-note output: %q", out)
+	}
+	if len(fake.splitOpts) != 1 || fake.splitParents[0] != 6100 {
+		t.Fatalf("expected one split of the session pane, got %#v %#v", fake.splitParents, fake.splitOpts)
+	}
+	split := fake.splitOpts[0]
+	if split.Cwd != fake.spawnOpts[0].Cwd || split.UserVars[terminal.TaskIDUserVar] == "" {
+		t.Fatalf("unexpected split options: %#v", split)
+	}
+	// The split reruns open-note with the same editor, so the edit there is
+	// snapshotted like any other.
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatalf("os.Executable: %v", err)
+	}
+	if len(split.Argv) < 5 || split.Argv[0] != "env" || split.Argv[1] != "VISUAL=vim" || split.Argv[2] != self ||
+		strings.Join(split.Argv[3:5], " ") != "task open-note" || slices.Contains(split.Argv, "--in-session") ||
+		!strings.Contains(strings.Join(split.Argv, " "), "--notes-dir "+notesDir) {
+		t.Fatalf("unexpected split argv: %#v", split.Argv)
+	}
+
+	// A closed editor pane is pruned when the next one opens.
+	if err := fake.KillPane(context.Background(), 6200); err != nil {
+		t.Fatalf("KillPane: %v", err)
+	}
+	if _, err := openNote(); err != nil {
+		t.Fatalf("second open-note --in-session failed: %v", err)
+	}
+	store, err := tasks.NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	sessions, err := store.ListSessions(context.Background())
+	_ = store.Close()
+	if err != nil || len(sessions) != 1 || len(sessions[0].AuxPaneIDs) != 1 || sessions[0].AuxPaneIDs[0] != 6201 {
+		t.Fatalf("expected only the live aux pane tracked, got %#v err=%v", sessions, err)
+	}
+
+	fake.killCalls = 0
+	if _, err := captureStdout(func() error {
+		return run([]string{"task", "close-session", "--repo", "zew1me/term-workspaces", "--branch", "feature/split", "--db", dbPath})
+	}); err != nil {
+		t.Fatalf("close-session failed: %v", err)
+	}
+	if fake.killCalls != 2 || len(fake.panes) != 0 {
+		t.Fatalf("expected aux and session panes killed, kills=%d panes=%#v", fake.killCalls, fake.panes)
+	}
+}
+
+func TestRunTaskOpenNoteInSessionRejectsPlatformOpener(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	fake := &fakeTerminalClient{nextPaneID: 6300}
+	useFakeTerminal(t, fake)
+	t.Setenv("EDITOR", "")
+
+	taskArgs := []string{"--repo", "zew1me/term-workspaces", "--branch", "feature/opener", "--db", dbPath, "--notes-dir", t.TempDir()}
+	if _, err := captureStdout(func() error {
+		return run(append([]string{"task", "open-session", "--cwd", t.TempDir()}, taskArgs...))
+	}); err != nil {
+		t.Fatalf("open-session failed: %v", err)
+	}
+	err := run(append([]string{"task", "open-note", "--in-session"}, taskArgs...))
+	if err == nil || !strings.Contains(err.Error(), "--in-session needs") {
+		t.Fatalf("expected platform opener to be rejected, got %v", err)
+	}
+	if len(fake.splitOpts) != 0 {
+		t.Fatalf("expected no split, got %#v", fake.splitOpts)
+	}
+}
+
+func TestReconcileClearsAuxPanesOfClosedSession(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	fake := &fakeTerminalClient{nextPaneID: 6400}
+	useFakeTerminal(t, fake)
+	t.Setenv("EDITOR", "vim")
+
+	taskArgs := []string{"--repo", "zew1me/term-workspaces", "--branch", "feature/aux-reconcile", "--db", dbPath, "--notes-dir", t.TempDir()}
+	for _, command := range [][]string{{"task", "open-session", "--cwd", t.TempDir()}, {"task", "open-note", "--in-session"}} {
+		if _, err := captureStdout(func() error { return run(append(command, taskArgs...)) }); err != nil {
+			t.Fatalf("%v failed: %v", command, err)
+		}
+	}
+
+	// The agent pane dies; the editor split beside it is still open.
+	if err := fake.KillPane(context.Background(), 6400); err != nil {
+		t.Fatalf("KillPane: %v", err)
+	}
+	if _, err := captureStdout(func() error {
+		return run([]string{"task", "sessions", "--db", dbPath, "--reconcile"})
+	}); err != nil {
+		t.Fatalf("task sessions --reconcile failed: %v", err)
+	}
+
+	store, err := tasks.NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	sessions, err := store.ListSessions(context.Background())
+	_ = store.Close()
+	if err != nil || len(sessions) != 1 || sessions[0].Status != tasks.SessionStatusClosed || len(sessions[0].AuxPaneIDs) != 0 {
+		t.Fatalf("expected a closed session without aux panes, got %#v err=%v", sessions, err)
+	}
+	if len(fake.panes) != 1 || fake.panes[0].PaneID != 6500 {
+		t.Fatalf("expected the editor pane left open, got %#v", fake.panes)
+	}
+}
+
+func TestRunNoteLinksListsLinksAndBacklinks(t *testing.T) {
+	useTestUserState(t)
+	dbPath := t.TempDir() + "/state.db"
+	notesDir := t.TempDir()
+
+	paths := make(map[string]string)
+	taskIDs := make(map[string]string)
+	for _, branch := range []string{"feature/api", "feature/ui"} {
+		out, err := captureStdout(func() error {
+			return run([]string{
+				"task", "ensure-note",
+				"--repo", "zew1me/term-workspaces",
+				"--branch", branch,
+				"--db", dbPath,
+				"--notes-dir", notesDir,
+			})
+		})
+		if err != nil {
+			t.Fatalf("ensure-note %s failed: %v", branch, err)
+		}
+		kv := parseKVLine(t, out)
+		paths[branch], taskIDs[branch] = kv["note_path"], kv["task_id"]
+	}
+	note := "## Blockers\n- waits on [[prepr:zew1me/term-workspaces:feature/api|the API]] and [[zew1me/term-workspaces#99]]\n"
+	if err := os.WriteFile(paths["feature/ui"], []byte(note), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	out, err := captureStdout(func() error {
+		return run([]string{"note", "links", "--repo", "zew1me/term-workspaces", "--branch", "feature/ui", "--db", dbPath, "--notes-dir", notesDir})
+	})
+	if err != nil {
+		t.Fatalf("note links failed: %v", err)
+	}
+	expected := "task_id=" + taskIDs["feature/ui"] + " link=pr:zew1me/term-workspaces#99 linked_task_id=\n" +
+		"task_id=" + taskIDs["feature/ui"] + " link=prepr:zew1me/term-workspaces:feature/api linked_task_id=" + taskIDs["feature/api"]
+	if out != expected {
+		t.Fatalf("unexpected links output:\n%s", out)
+	}
+
+	out, err = captureStdout(func() error {
+		return run([]string{"note", "links", "--repo", "zew1me/term-workspaces", "--branch", "feature/api", "--db", dbPath, "--notes-dir", notesDir, "--json"})
+	})
+	if err != nil {
+		t.Fatalf("note links --json failed: %v", err)
+	}
+	var result noteLinksResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v (%q)", err, out)
+	}
+	if len(result.Links) != 0 || len(result.Backlinks) != 1 || result.Backlinks[0].FromTaskID != taskIDs["feature/ui"] {
+		t.Fatalf("unexpected backlinks: %#v", result)
+	}
+
+	out, err = captureStdout(func() error {
+		return run([]string{"task", "dashboard", "--db", dbPath, "--notes-dir", notesDir, "--json"})
+	})
+	if err != nil {
+		t.Fatalf("dashboard failed: %v", err)
+	}
+	var payload dashboardPayload
+	if err := json.Unmarshal([]byte(out), &payload); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	for _, entry := range payload.Tasks {
+		other := taskIDs["feature/api"]
+		if entry.Task.ID == other {
+			other = taskIDs["feature/ui"]
+		}
+		if len(entry.Related) != 1 || entry.Related[0].TaskID != other {
+			t.Fatalf("unexpected related tasks for %s: %#v", entry.Task.ID, entry.Related)
+		}
+	}
+}
+
+func TestSessionLifecycleIsLoggedInNote(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	notesDir := t.TempDir()
+	cwd := t.TempDir()
+	fake := &fakeTerminalClient{nextPaneID: 6100}
+	useFakeTerminal(t, fake)
+
+	out, err := captureStdout(func() error {
+		return run([]string{
+			"task", "ensure-note",
+			"--repo", "zew1me/term-workspaces",
+			"--branch", "feature/session-log",
+			"--db", dbPath,
+			"--notes-dir", notesDir,
+		})
+	})
+	if err != nil {
+		t.Fatalf("ensure-note failed: %v", err)
+	}
+	notePath := parseKVLine(t, out)["note_path"]
+	original, err := os.ReadFile(notePath)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	sessionArgs := []string{"--repo", "zew1me/term-workspaces", "--branch", "feature/session-log", "--db", dbPath, "--notes-dir", notesDir}
+	steps := [][]string{
+		append([]string{"task", "open-session", "--cwd", cwd, "--profile", "shell"}, sessionArgs...),
+		append([]string{"task", "open-session", "--cwd", cwd}, sessionArgs...),
+		append([]string{"task", "close-session"}, sessionArgs...),
+		append([]string{"task", "close-session"}, sessionArgs...),
+		append([]string{"task", "open-session", "--cwd", cwd}, sessionArgs...),
+	}
+	for _, args := range steps {
+		if _, err := captureStdout(func() error { return run(args) }); err != nil {
+			t.Fatalf("%v failed: %v", args, err)
+		}
+	}
+	// The second spawn's pane disappears behind ttt's back.
+	fake.panes = nil
+	if _, err := captureStdout(func() error {
+		return run([]string{"task", "sessions", "--db", dbPath, "--notes-dir", notesDir, "--reconcile"})
+	}); err != nil {
+		t.Fatalf("sessions --reconcile failed: %v", err)
+	}
+
+	content, err := os.ReadFile(notePath)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	prefix, log, ok := strings.Cut(string(content), "## Session Context\n")
+	if !ok || !strings.HasPrefix(string(original), prefix) {
+		t.Fatalf("expected the note above Session Context to be untouched:\n%s", content)
+	}
+	lines := strings.Split(strings.TrimSpace(log), "\n")
+	expected := []string{"spawned pane=6100", "activated pane=6100", "closed pane=6100", "spawned pane=6101", "dead pane=6101"}
+	if len(lines) != len(expected) {
+		t.Fatalf("unexpected session log:\n%s", log)
+	}
+	for i, want := range expected {
+		if !strings.Contains(lines[i], " "+want+" workspace=") || !strings.HasSuffix(lines[i], " cwd="+cwd+" profile=shell") {
+			t.Fatalf("log line %d = %q, want %q", i, lines[i], want)
+		}
+	}
+}
+
+func TestNotesGitModeCommitsAndSyncs(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dbPath := t.TempDir() + "/state.db"
+	notesDir := t.TempDir() + "/notes"
+	remote := t.TempDir() + "/notes.git"
+	if output, err := exec.Command("git", "init", "-q", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init --bare: %v (%s)", err, output)
+	}
+	configPath := t.TempDir() + "/config.json"
+	// Without notes git mode, sync refuses to make the notes dir a repository.
+	if err := os.WriteFile(configPath, []byte(`{"notes": {"remote": "`+remote+`"}}`), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	err := run([]string{"note", "sync", "--config", configPath, "--notes-dir", notesDir})
+	if err == nil || !strings.Contains(err.Error(), "notes git mode is off") {
+		t.Fatalf("expected notes git mode error, got %v", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(notesDir, ".git")); !errors.Is(statErr, os.ErrNotExist) {
+		t.Fatalf("expected no repository in the notes dir, got %v", statErr)
+	}
+	if err := os.WriteFile(configPath, []byte(`{"notes": {"git": true, "remote": "`+remote+`"}}`), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	editor := t.TempDir() + "/editor.sh"
+	if err := os.WriteFile(editor, []byte("#!/bin/sh\necho '- [ ] committed by ttt' >> \"$1\"\n"), 0o700); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	t.Setenv("EDITOR", editor)
+	fake := &fakeTerminalClient{nextPaneID: 7100}
+	useFakeTerminal(t, fake)
+
+	taskArgs := []string{"--repo", "zew1me/term-workspaces", "--branch", "feature/notes-git", "--db", dbPath, "--config", configPath, "--notes-dir", notesDir}
+	for _, command := range []string{"ensure-note", "open-note", "open-session", "close-session"} {
+		if _, err := captureStdout(func() error { return run(append([]string{"task", command}, taskArgs...)) }); err != nil {
+			t.Fatalf("task %s failed: %v", command, err)
+		}
+	}
+	if _, err := captureStdout(func() error {
+		return run(append([]string{"task", "set-meta", "--priority", "high"}, taskArgs...))
+	}); err != nil {
+		t.Fatalf("task set-meta failed: %v", err)
+	}
+
+	gitLog := func(dir string) string {
+		t.Helper()
+		output, err := exec.Command("git", "-C", dir, "log", "--format=%s").CombinedOutput()
+		if err != nil {
+			t.Fatalf("git log: %v (%s)", err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+	subject := "Update note: prepr:zew1me/term-workspaces:feature/notes-git"
+	commits := strings.TrimSuffix(strings.Repeat(subject+"\n", 3), "\n")
+	if got := gitLog(notesDir); got != commits {
+		t.Fatalf("expected open-note, close-session and set-meta commits, got:\n%s", got)
+	}
+
+	out, err := captureStdout(func() error {
+		return run([]string{"note", "sync", "--config", configPath, "--notes-dir", notesDir})
+	})
+	if err != nil {
+		t.Fatalf("note sync failed: %v", err)
+	}
+	if !strings.Contains(out, "status=synced") || !strings.Contains(out, "committed=false pulled=false pushed=true") {
+		t.Fatalf("unexpected sync output: %q", out)
+	}
+	if got := gitLog(remote); got != commits {
+		t.Fatalf("expected the commits on the remote, got:\n%s", got)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"term-workspaces/internal/config"
+	"term-workspaces/internal/tasks"
+	"text/template"
+)
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// promptData is what a profile's prompt_template can reference.
+type promptData struct {
+	TaskID      string
+	Repo        string
+	Branch      string
+	PRNumber    int
+	PRTitle     string
+	Objective   string
+	NextActions string
+	Blockers    string
+	NotePath    string
+	// Context is the default bootstrap prompt built from the fields above;
+	// empty when there is nothing to tell the agent.
+	Context string
+}
+
+// sessionLaunch is what a newly spawned task pane runs.
+type sessionLaunch struct {
+	Profile        string
+	Argv           []string
+	Env            map[string]string
+	AgentKind      string
+	AgentSessionID string
+	// PromptMode says how an initial prompt is delivered; empty when the
+	// agent starts without one.
+	PromptMode string
+	// SendText is the initial prompt to type into the pane after it spawns
+	// when PromptMode is send-text.
+	SendText string
+	// Ready matches the screen of an agent waiting for SendText; nil waits
+	// for the screen to settle.
+	Ready *regexp.Regexp
+}
+
+func (l sessionLaunch) resumed() bool {
+	return l.AgentSessionID != ""
+}
+
+// selectProfile picks the profile name for open-session: --profile, then the
+// deprecated --command, then the profile the session last ran, then config.
+func selectProfile(cfg config.Config, repo, profileFlag, commandFlag string, existing *tasks.TaskSession) string {
+	if name := strings.TrimSpace(profileFlag); name != "" {
+		return name
+	}
+	if name := strings.TrimSpace(commandFlag); name != "" {
+		fmt.Fprintln(os.Stderr, "ttt: --command is deprecated; use --profile")
+		return name
+	}
+	if existing != nil && strings.TrimSpace(existing.Profile) != "" {
+		return existing.Profile
+	}
+	return cfg.ProfileForRepo(repo)
+}
+
+// resumesSession reports whether a pane running profile should resume the
+// previous agent conversation recorded on existing. A conversation is only
+// resumed in the directory it ran in, by a profile running the same agent; an
+// agent-less profile such as shell always starts fresh.
+func resumesSession(profile config.Profile, existing *tasks.TaskSession, cwd string) bool {
+	return existing != nil && existing.Cwd == cwd && existing.AgentSessionID != "" &&
+		profile.Agent != "" && profile.Agent == existing.AgentKind
+}
+
+// promptsAgent reports whether a fresh pane running profile gets an initial
+// prompt, so task context is worth gathering.
+func promptsAgent(profile config.Profile) bool {
+	return len(profile.Argv) > 0 && (profile.Agent != "" || strings.TrimSpace(profile.PromptTemplate) != "")
+}
+
+// planSessionLaunch resolves profileName into the program for a new pane. A
+// previous agent conversation is resumed instead of starting a fresh one when
+// resumesSession allows it.
+func planSessionLaunch(cfg config.Config, profileName string, existing *tasks.TaskSession, cwd string, data promptData) (sessionLaunch, error) {
+	profile, ok := cfg.Profile(profileName)
+	if !ok {
+		return sessionLaunch{}, fmt.Errorf("unknown profile %q (available: %s)", profileName, strings.Join(profileNames(cfg), ", "))
+	}
+	for name := range profile.Env {
+		if !envNamePattern.MatchString(name) {
+			return sessionLaunch{}, fmt.Errorf("profile %q: invalid env var name %q", profileName, name)
+		}
+	}
+	switch profile.PromptMode {
+	case "", config.PromptModeArg, config.PromptModeSendText:
+	default:
+		return sessionLaunch{}, fmt.Errorf("profile %q: unsupported prompt_mode %q (supported: %s, %s)", profileName, profile.PromptMode, config.PromptModeArg, config.PromptModeSendText)
+	}
+	var ready *regexp.Regexp
+	if profile.ReadyPattern != "" {
+		var err error
+		if ready, err = regexp.Compile(profile.ReadyPattern); err != nil {
+			return sessionLaunch{}, fmt.Errorf("profile %q: invalid ready_pattern: %w", profileName, err)
+		}
+	}
+
+	launch := sessionLaunch{Profile: profileName, Env: profile.Env}
+	if resumesSession(profile, existing, cwd) {
+		argv, err := resumeArgv(*existing)
+		if err != nil {
+			return sessionLaunch{}, err
+		}
+		launch.Argv = argv
+		launch.AgentKind = existing.AgentKind
+		launch.AgentSessionID = existing.AgentSessionID
+		return launch, nil
+	}
+
+	launch.Argv = append([]string(nil), profile.Argv...)
+	if !promptsAgent(profile) {
+		return launch, nil
+	}
+	prompt := data.Context
+	if strings.TrimSpace(profile.PromptTemplate) != "" {
+		rendered, err := renderPrompt(profile.PromptTemplate, data)
+		if err != nil {
+			return sessionLaunch{}, fmt.Errorf("profile %q: %w", profileName, err)
+		}
+		prompt = rendered
+	}
+	switch {
+	case prompt == "":
+	case profile.PromptMode == config.PromptModeSendText:
+		launch.PromptMode = config.PromptModeSendText
+		launch.SendText = prompt
+		launch.Ready = ready
+	default:
+		launch.PromptMode = config.PromptModeArg
+		launch.Argv = append(launch.Argv, prompt)
+	}
+	return launch, nil
+}
+
+func renderPrompt(text string, data promptData) (string, error) {
+	tmpl, err := template.New("prompt").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parse prompt template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render prompt template: %w", err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func profileNames(cfg config.Config) []string {
+	seen := map[string]struct{}{
+		config.ProfileCodex:  {},
+		config.ProfileClaude: {},
+		config.ProfileShell:  {},
+	}
+	for name := range cfg.Profiles {
+		seen[strings.ToLower(strings.TrimSpace(name))] = struct{}{}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
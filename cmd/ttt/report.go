@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"term-workspaces/internal/github"
+	"term-workspaces/internal/report"
+	"term-workspaces/internal/tasks"
+	"time"
+)
+
+// reportNow is the report clock; tests pin it.
+var reportNow = time.Now
+
+type prStateFetcher interface {
+	PRState(ctx context.Context, repo string, number int) (github.PRState, error)
+}
+
+var newPRStateFetcher = func() prStateFetcher {
+	return github.NewCLIClient()
+}
+
+func runReport(args []string) error {
+	if len(args) == 0 {
+		return printReportUsage()
+	}
+
+	switch args[0] {
+	case "standup":
+		return runReportStandup(args[1:])
+	default:
+		return printReportUsage()
+	}
+}
+
+func runReportStandup(args []string) error {
+	fs := flag.NewFlagSet("report standup", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	dbPath := fs.String("db", defaultDBPath(), "Path to sqlite database")
+	notesDir := fs.String("notes-dir", defaultNotesDir(), "Directory for task note markdown files")
+	sinceFlag := fs.String("since", "24h", "Report window: a duration (24h, 3d) or a time (RFC3339 or YYYY-MM-DD)")
+	offline := fs.Bool("offline", false, "Skip asking GitHub (gh) which PRs were opened, merged or closed")
+	jsonOutput := fs.Bool("json", false, "Emit machine-readable JSON instead of Markdown")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	until := reportNow()
+	since, err := parseReportSince(*sinceFlag, until)
+	if err != nil {
+		return err
+	}
+
+	store, err := tasks.NewSQLiteStore(*dbPath)
+	if err != nil {
+		return fmt.Errorf("open sqlite task store: %w", err)
+	}
+	defer func() {
+		_ = store.Close()
+	}()
+
+	ctx := context.Background()
+	// Refreshing the note index also syncs note front matter into the tasks.
+	// The standup still works from what is stored when that fails.
+	if _, err := store.RefreshNoteIndex(ctx, *notesDir); err != nil {
+		fmt.Fprintf(os.Stderr, "ttt: note index not refreshed: %v\n", err)
+	}
+	input := report.StandupInput{Notes: make(map[string]tasks.ParsedNote)}
+	if input.Tasks, err = store.ListTasks(ctx); err != nil {
+		return fmt.Errorf("standup tasks: %w", err)
+	}
+	if input.Aliases, err = store.ListTaskAliasRows(ctx); err != nil {
+		return fmt.Errorf("standup aliases: %w", err)
+	}
+	if input.Sessions, err = store.ListSessions(ctx); err != nil {
+		return fmt.Errorf("standup sessions: %w", err)
+	}
+	if input.Events, err = store.ListEvents(ctx, since); err != nil {
+		return fmt.Errorf("standup events: %w", err)
+	}
+	if !*offline {
+		input.PRStates = lookupPRStates(ctx, input.Aliases)
+	}
+	for _, task := range input.Tasks {
+		// #nosec G304 -- the note path is derived from the notes dir and task ID.
+		content, err := os.ReadFile(tasks.NotePath(*notesDir, task.ID))
+		if err == nil {
+			input.Notes[task.ID] = tasks.ParseNote(string(content))
+		}
+	}
+
+	standup := report.BuildStandup(input, since, until)
+	if *jsonOutput {
+		return writeJSON(standup)
+	}
+	fmt.Print(standup.Markdown())
+	return nil
+}
+
+// lookupPRStates asks `gh` for the state of every PR linked to a task.
+// Failures are reported and leave the PR out, so it only shows up in the
+// standup when it was linked in the window.
+func lookupPRStates(ctx context.Context, aliases []tasks.TaskAliasRow) map[report.PRKey]github.PRState {
+	fetcher := newPRStateFetcher()
+	states := make(map[report.PRKey]github.PRState)
+	for _, alias := range aliases {
+		key := report.PRKey{Repo: alias.Repo, Number: alias.PRNumber}
+		if _, done := states[key]; done || alias.AliasType != tasks.AliasTypePR {
+			continue
+		}
+		stateCtx, cancel := context.WithTimeout(ctx, prTitleTimeout)
+		state, err := fetcher.PRState(stateCtx, alias.Repo, alias.PRNumber)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ttt: PR state unavailable: %v\n", err)
+			continue
+		}
+		states[key] = state
+	}
+	return states
+}
+
+// parseReportSince accepts a Go duration, a whole number of days ("3d"),
+// an RFC3339 time or a local YYYY-MM-DD date.
+func parseReportSince(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if duration, err := time.ParseDuration(value); err == nil && duration > 0 {
+		return now.Add(-duration), nil
+	}
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, nil
+	}
+	if at, err := time.ParseInLocation(time.DateOnly, value, now.Location()); err == nil {
+		return at, nil
+	}
+	return time.Time{}, fmt.Errorf("--since must be a duration (24h, 3d), RFC3339 time or YYYY-MM-DD date: %q", value)
+}
+
+func printReportUsage() error {
+	fmt.Println("ttt report usage:")
+	fmt.Println("  ttt report standup [--since 24h|3d|YYYY-MM-DD] [--db path] [--notes-dir path] [--offline] [--json]")
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"term-workspaces/internal/report"
+	"testing"
+	"time"
+)
+
+func useReportClock(t *testing.T, now time.Time) {
+	t.Helper()
+
+	previous := reportNow
+	reportNow = func() time.Time { return now }
+	t.Cleanup(func() {
+		reportNow = previous
+	})
+}
+
+func TestParseReportSince(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"24h":                  now.Add(-24 * time.Hour),
+		"3d":                   time.Date(2026, 2, 27, 9, 30, 0, 0, time.UTC),
+		"2026-03-01":           time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		"2026-03-01T12:00:00Z": time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+	for value, expected := range cases {
+		got, err := parseReportSince(value, now)
+		if err != nil || !got.Equal(expected) {
+			t.Fatalf("parseReportSince(%q) = %s, %v; want %s", value, got, err, expected)
+		}
+	}
+	for _, value := range []string{"", "yesterday", "-2h", "0d"} {
+		if _, err := parseReportSince(value, now); err == nil {
+			t.Fatalf("expected error for %q", value)
+		}
+	}
+}
+
+func TestRunReportStandupCombinesTasksNotesAndSessions(t *testing.T) {
+	dbPath := t.TempDir() + "/state.db"
+	notesDir := t.TempDir()
+	fake := &fakeTerminalClient{nextPaneID: 5100}
+	useFakeTerminal(t, fake)
+
+	out, err := captureStdout(func() error {
+		return run([]string{
+			"task", "ensure-note",
+			"--repo", "zew1me/term-workspaces",
+			"--branch", "feature/standup",
+			"--db", dbPath,
+			"--notes-dir", notesDir,
+		})
+	})
+	if err != nil {
+		t.Fatalf("ensure-note failed: %v", err)
+	}
+	notePath := parseKVLine(t, out)["note_path"]
+	if err := os.WriteFile(notePath, []byte("## Status\nReport command drafted.\n\n## Blockers\n- need a review\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := captureStdout(func() error {
+		return run([]string{
+			"task", "open-session",
+			"--repo", "zew1me/term-workspaces",
+			"--branch", "feature/standup",
+			"--db", dbPath,
+			"--notes-dir", notesDir,
+		})
+	}); err != nil {
+		t.Fatalf("open-session failed: %v", err)
+	}
+
+	// Pin the clock just after the activity above so the window is stable.
+	now := time.Now().Add(time.Minute).Truncate(time.Second)
+	useReportClock(t, now)
+
+	out, err = captureStdout(func() error {
+		return run([]string{"report", "standup", "--db", dbPath, "--notes-dir", notesDir, "--json"})
+	})
+	if err != nil {
+		t.Fatalf("report standup --json failed: %v", err)
+	}
+	var standup report.Standup
+	if err := json.Unmarshal([]byte(out), &standup); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v (%q)", err, out)
+	}
+	if !standup.Until.Equal(now) || !standup.Since.Equal(now.Add(-24*time.Hour)) {
+		t.Fatalf("unexpected window: %s..%s", standup.Since, standup.Until)
+	}
+	if len(standup.Repos) != 1 || standup.Repos[0].Repo != "zew1me/term-workspaces" || len(standup.Repos[0].Tasks) != 1 {
+		t.Fatalf("unexpected repos: %#v", standup.Repos)
+	}
+	entry := standup.Repos[0].Tasks[0]
+	if entry.Branch != "feature/standup" || entry.Status != "Report command drafted." || len(entry.Blockers) != 1 || len(entry.Sessions) != 1 {
+		t.Fatalf("unexpected standup entry: %#v", entry)
+	}
+
+	out, err = captureStdout(func() error {
+		return run([]string{"report", "standup", "--db", dbPath, "--notes-dir", notesDir})
+	})
+	if err != nil {
+		t.Fatalf("report standup failed: %v", err)
+	}
+	for _, want := range []string{"## zew1me/term-workspaces", "- **feature/standup**", "  - Status: Report command drafted.", "  - Blocked: need a review", "  - Session opened"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in standup:\n%s", want, out)
+		}
+	}
+
+	// A clock a week later leaves the window empty.
+	useReportClock(t, now.AddDate(0, 0, 7))
+	out, err = captureStdout(func() error {
+		return run([]string{"report", "standup", "--db", dbPath, "--notes-dir", notesDir, "--since", "2d"})
+	})
+	if err != nil {
+		t.Fatalf("report standup failed: %v", err)
+	}
+	if !strings.HasSuffix(out, "No task activity.") {
+		t.Fatalf("expected no activity a week later:\n%s", out)
+	}
+}
+
+func TestRunReportStandupReportsPRStateChanges(t *testing.T) {
+	useTestUserState(t)
+	dbPath := t.TempDir() + "/state.db"
+
+	if _, err := captureStdout(func() error {
+		return run([]string{"task", "link-pr", "--repo", "zew1me/term-workspaces", "--branch", "feature/merged", "--pr", "12", "--db", dbPath})
+	}); err != nil {
+		t.Fatalf("link-pr failed: %v", err)
+	}
+	now := time.Now().AddDate(0, 0, 7).Truncate(time.Minute)
+	useReportClock(t, now)
+
+	// The PR was linked before the window; only the merge is news.
+	usePRStates(t, fakePRStates{12: {State: "MERGED", CreatedAt: now.AddDate(0, 0, -5), MergedAt: now.Add(-2 * time.Hour)}})
+	out, err := captureStdout(func() error {
+		return run([]string{"report", "standup", "--db", dbPath, "--notes-dir", t.TempDir()})
+	})
+	if err != nil {
+		t.Fatalf("report standup failed: %v", err)
+	}
+	merged := "  - PR #12 merged at " + now.Add(-2*time.Hour).Format("2006-01-02 15:04")
+	if !strings.Contains(out, "- **#12**") || !strings.Contains(out, merged) || strings.Contains(out, "Linked PR") {
+		t.Fatalf("expected the merge in the standup:\n%s", out)
+	}
+
+	out, err = captureStdout(func() error {
+		return run([]string{"report", "standup", "--db", dbPath, "--notes-dir", t.TempDir(), "--offline"})
+	})
+	if err != nil || !strings.HasSuffix(out, "No task activity.") {
+		t.Fatalf("expected --offline to skip PR states, got %q err=%v", out, err)
+	}
+}
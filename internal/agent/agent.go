@@ -0,0 +1,74 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Kind identifies an agent CLI whose conversations ttt can discover and
+// resume.
+type Kind string
+
+const (
+	KindCodex  Kind = "codex"
+	KindClaude Kind = "claude"
+)
+
+// Session is an agent conversation discovered from the agent's local logs.
+type Session struct {
+	Kind      Kind
+	ID        string
+	Cwd       string
+	StartedAt time.Time
+	Path      string
+}
+
+// Dirs are the local log locations scanned for each agent.
+type Dirs struct {
+	CodexSessions  string
+	ClaudeProjects string
+}
+
+func DefaultDirs() Dirs {
+	return Dirs{
+		CodexSessions:  CodexSessionsDir(),
+		ClaudeProjects: ClaudeProjectsDir(),
+	}
+}
+
+// FindSession returns the most recently started conversation of any known
+// agent in cwd that started at or after since. One agent's logs failing to
+// scan doesn't hide the other's conversation: the best one found is returned
+// along with the joined errors.
+func FindSession(dirs Dirs, cwd string, since time.Time) (Session, bool, error) {
+	var best Session
+	found := false
+	var errs []error
+	consider := func(session Session, ok bool, err error) {
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		if ok && (!found || session.StartedAt.After(best.StartedAt)) {
+			best = session
+			found = true
+		}
+	}
+
+	consider(FindCodexSession(dirs.CodexSessions, cwd, since))
+	consider(FindClaudeSession(dirs.ClaudeProjects, cwd, since))
+	return best, found, errors.Join(errs...)
+}
+
+// ResumeArgv is the command line that continues conversation id of kind.
+func ResumeArgv(kind Kind, id string) ([]string, error) {
+	switch kind {
+	case KindCodex:
+		return []string{"codex", "resume", id}, nil
+	case KindClaude:
+		return []string{"claude", "--resume", id}, nil
+	default:
+		return nil, fmt.Errorf("unsupported agent kind %q", kind)
+	}
+}
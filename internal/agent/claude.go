@@ -0,0 +1,167 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// claudeHeaderLines bounds how far into a transcript the first message with
+// session metadata is searched for; leading lines may be summaries.
+// claudeMaxLine is the longest of those lines read; longer ones, such as a
+// pasted image, are skipped.
+const (
+	claudeHeaderLines = 50
+	claudeMaxLine     = 1024 * 1024
+)
+
+// ClaudeProjectsDir is where Claude Code keeps per-project transcripts:
+// $CLAUDE_CONFIG_DIR/projects, defaulting to ~/.claude/projects.
+func ClaudeProjectsDir() string {
+	if configDir := strings.TrimSpace(os.Getenv("CLAUDE_CONFIG_DIR")); configDir != "" {
+		return filepath.Join(configDir, "projects")
+	}
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".claude", "projects")
+	}
+	return filepath.Join(userHome, ".claude", "projects")
+}
+
+// ClaudeProjectDirName is the transcript directory name Claude Code uses for
+// cwd: every character other than an ASCII letter or digit becomes "-".
+func ClaudeProjectDirName(cwd string) string {
+	var b strings.Builder
+	for _, r := range filepath.Clean(cwd) {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
+
+type claudeLogLine struct {
+	SessionID string `json:"sessionId"`
+	Cwd       string `json:"cwd"`
+	Timestamp string `json:"timestamp"`
+}
+
+// FindClaudeSession returns the most recently started Claude Code
+// conversation in cwd that started at or after since. Transcripts live in one
+// directory per project, so only that directory is read. A transcript that
+// can't be read is skipped.
+func FindClaudeSession(root, cwd string, since time.Time) (Session, bool, error) {
+	cwd = filepath.Clean(cwd)
+	projectDir := filepath.Join(root, ClaudeProjectDirName(cwd))
+	entries, err := os.ReadDir(projectDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Session{}, false, nil
+		}
+		return Session{}, false, fmt.Errorf("read claude project dir %s: %w", projectDir, err)
+	}
+
+	var best Session
+	found := false
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".jsonl" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().Before(since) {
+			continue
+		}
+
+		path := filepath.Join(projectDir, entry.Name())
+		session, ok, err := readClaudeSessionMeta(path)
+		if err != nil {
+			continue
+		}
+		// Different cwds can share an encoded directory name ("a.b" and
+		// "a-b"), so the recorded cwd is checked as well.
+		if !ok || filepath.Clean(session.Cwd) != cwd || session.StartedAt.Before(since) {
+			continue
+		}
+		if !found || session.StartedAt.After(best.StartedAt) {
+			best = session
+			found = true
+		}
+	}
+	return best, found, nil
+}
+
+func readClaudeSessionMeta(path string) (Session, bool, error) {
+	// #nosec G304 -- paths come from listing the Claude project directory.
+	file, err := os.Open(path)
+	if err != nil {
+		return Session{}, false, fmt.Errorf("open claude transcript: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	reader := bufio.NewReader(file)
+	for i := 0; i < claudeHeaderLines; i++ {
+		raw, err := readLimitedLine(reader, claudeMaxLine)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Session{}, false, fmt.Errorf("read claude transcript %s: %w", path, err)
+		}
+		var line claudeLogLine
+		if err := json.Unmarshal(raw, &line); err != nil {
+			continue
+		}
+		if line.Cwd == "" || line.Timestamp == "" {
+			continue
+		}
+		startedAt, err := time.Parse(time.RFC3339Nano, line.Timestamp)
+		if err != nil {
+			continue
+		}
+		id := line.SessionID
+		if id == "" {
+			id = strings.TrimSuffix(filepath.Base(path), ".jsonl")
+		}
+		return Session{
+			Kind:      KindClaude,
+			ID:        id,
+			Cwd:       line.Cwd,
+			StartedAt: startedAt,
+			Path:      path,
+		}, true, nil
+	}
+	return Session{}, false, nil
+}
+
+// readLimitedLine reads the next line of reader without its line ending. A
+// line longer than limit is consumed and returned as nil.
+func readLimitedLine(reader *bufio.Reader, limit int) ([]byte, error) {
+	var line []byte
+	tooLong := false
+	for {
+		fragment, more, err := reader.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+		if !tooLong && len(line)+len(fragment) > limit {
+			tooLong, line = true, nil
+		}
+		if !tooLong {
+			line = append(line, fragment...)
+		}
+		if !more {
+			return line, nil
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const claudeFixtureRoot = "testdata/claude/projects"
+
+func TestClaudeProjectDirName(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"/work/alpha":               "-work-alpha",
+		"/Users/me/code/my.app":     "-Users-me-code-my-app",
+		"/Users/me/code/my_repo/":   "-Users-me-code-my-repo",
+		"/srv/term-workspaces/main": "-srv-term-workspaces-main",
+	}
+	for cwd, expected := range cases {
+		if got := ClaudeProjectDirName(cwd); got != expected {
+			t.Fatalf("ClaudeProjectDirName(%q) = %q, want %q", cwd, got, expected)
+		}
+	}
+}
+
+func TestFindClaudeSessionPicksLatestForCwd(t *testing.T) {
+	t.Parallel()
+
+	since := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	session, found, err := FindClaudeSession(claudeFixtureRoot, "/work/alpha", since)
+	if err != nil || !found {
+		t.Fatalf("FindClaudeSession found=%v err=%v", found, err)
+	}
+	if session.Kind != KindClaude || session.ID != "5b1c0d9e-0000-4000-8000-00000000c002" {
+		t.Fatalf("unexpected session: %#v", session)
+	}
+}
+
+func TestFindClaudeSessionChecksRecordedCwd(t *testing.T) {
+	t.Parallel()
+
+	// "/work/my.app" encodes to the same directory as "/work/my-app".
+	_, found, err := FindClaudeSession(claudeFixtureRoot, "/work/my.app", time.Time{})
+	if err != nil || found {
+		t.Fatalf("expected no session for a colliding cwd, found=%v err=%v", found, err)
+	}
+	if _, found, err := FindClaudeSession(claudeFixtureRoot, "/work/unknown", time.Time{}); err != nil || found {
+		t.Fatalf("expected no session for an unknown project, found=%v err=%v", found, err)
+	}
+}
+
+func TestFindClaudeSessionSkipsUnreadableTranscripts(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	projectDir := filepath.Join(root, ClaudeProjectDirName("/work/alpha"))
+	if err := os.MkdirAll(projectDir, 0o750); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	// A transcript whose first line, a pasted image, is longer than any
+	// header line is read past that line.
+	long := `{"type":"user","message":{"content":"` + strings.Repeat("A", 5*1024*1024) + `"}}` + "\n" +
+		`{"cwd":"/work/alpha","sessionId":"after-image","type":"user","timestamp":"2026-03-01T11:00:00.000Z"}` + "\n"
+	if err := os.WriteFile(filepath.Join(projectDir, "after-image.jsonl"), []byte(long), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	// A transcript that can't be opened is skipped.
+	if err := os.Symlink(filepath.Join(root, "missing"), filepath.Join(projectDir, "dangling.jsonl")); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+
+	session, found, err := FindClaudeSession(root, "/work/alpha", time.Time{})
+	if err != nil || !found || session.ID != "after-image" {
+		t.Fatalf("expected the transcript after the long line, got %#v found=%v err=%v", session, found, err)
+	}
+}
+
+func TestFindSessionKeepsOneAgentWhenTheOtherFails(t *testing.T) {
+	t.Parallel()
+
+	// A Claude projects root that is a file can't be listed.
+	claudeRoot := filepath.Join(t.TempDir(), "projects")
+	if err := os.WriteFile(claudeRoot, nil, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	dirs := Dirs{CodexSessions: codexFixtureRoot, ClaudeProjects: claudeRoot}
+	since := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	session, found, err := FindSession(dirs, "/work/beta", since)
+	if err == nil {
+		t.Fatalf("expected the claude error to be reported")
+	}
+	if !found || session.Kind != KindCodex {
+		t.Fatalf("expected codex session despite the claude error, got %#v found=%v", session, found)
+	}
+}
+
+func TestFindSessionPrefersMostRecentAgent(t *testing.T) {
+	t.Parallel()
+
+	dirs := Dirs{CodexSessions: codexFixtureRoot, ClaudeProjects: claudeFixtureRoot}
+	since := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	// Claude's 11:00 conversation is newer than Codex's 10:30 session.
+	session, found, err := FindSession(dirs, "/work/alpha", since)
+	if err != nil || !found || session.Kind != KindClaude {
+		t.Fatalf("expected latest claude session, got %#v found=%v err=%v", session, found, err)
+	}
+
+	// Only Codex ran in /work/beta.
+	session, found, err = FindSession(dirs, "/work/beta", since)
+	if err != nil || !found || session.Kind != KindCodex {
+		t.Fatalf("expected codex session, got %#v found=%v err=%v", session, found, err)
+	}
+}
+
+func TestResumeArgv(t *testing.T) {
+	t.Parallel()
+
+	codex, err := ResumeArgv(KindCodex, "abc")
+	if err != nil || len(codex) != 3 || codex[0] != "codex" || codex[1] != "resume" || codex[2] != "abc" {
+		t.Fatalf("unexpected codex argv %#v err=%v", codex, err)
+	}
+	claude, err := ResumeArgv(KindClaude, "def")
+	if err != nil || len(claude) != 3 || claude[0] != "claude" || claude[1] != "--resume" || claude[2] != "def" {
+		t.Fatalf("unexpected claude argv %#v err=%v", claude, err)
+	}
+	if _, err := ResumeArgv("aider", "x"); err == nil {
+		t.Fatalf("expected error for unsupported agent kind")
+	}
+}
@@ -0,0 +1,160 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CodexSessionsDir is where Codex writes its rollout logs:
+// $CODEX_HOME/sessions, defaulting to ~/.codex/sessions.
+func CodexSessionsDir() string {
+	if home := strings.TrimSpace(os.Getenv("CODEX_HOME")); home != "" {
+		return filepath.Join(home, "sessions")
+	}
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".codex", "sessions")
+	}
+	return filepath.Join(userHome, ".codex", "sessions")
+}
+
+// codexLogLine is the first line of a rollout log, which carries the
+// session metadata.
+type codexLogLine struct {
+	Type    string `json:"type"`
+	Payload struct {
+		ID        string `json:"id"`
+		Cwd       string `json:"cwd"`
+		Timestamp string `json:"timestamp"`
+	} `json:"payload"`
+}
+
+// FindCodexSession returns the most recently started Codex session under
+// root whose working directory is cwd and which started at or after since.
+// A missing root means Codex has never run and is not an error; a log or
+// directory below it that can't be read is skipped.
+func FindCodexSession(root, cwd string, since time.Time) (Session, bool, error) {
+	cwd = filepath.Clean(cwd)
+	var best Session
+	found := false
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			switch {
+			case path == root && errors.Is(err, fs.ErrNotExist):
+				return fs.SkipAll
+			case path == root:
+				return err
+			case entry != nil && entry.IsDir():
+				return fs.SkipDir
+			default:
+				return nil
+			}
+		}
+		if entry.IsDir() {
+			if rel, err := filepath.Rel(root, path); err == nil && codexDirBefore(rel, since) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasPrefix(entry.Name(), "rollout-") || filepath.Ext(path) != ".jsonl" {
+			return nil
+		}
+		// A log last written before since cannot belong to a session that
+		// started after it; skip it without reading.
+		info, err := entry.Info()
+		if err != nil || info.ModTime().Before(since) {
+			return nil
+		}
+
+		session, ok, err := readCodexSessionMeta(path)
+		if err != nil {
+			return nil
+		}
+		if !ok || filepath.Clean(session.Cwd) != cwd || session.StartedAt.Before(since) {
+			return nil
+		}
+		if !found || session.StartedAt.After(best.StartedAt) {
+			best = session
+			found = true
+		}
+		return nil
+	})
+	if err != nil {
+		return Session{}, false, fmt.Errorf("scan codex sessions in %s: %w", root, err)
+	}
+	return best, found, nil
+}
+
+// codexDirBefore reports whether rel, a YYYY, YYYY/MM or YYYY/MM/DD
+// directory under the sessions root, only holds logs of sessions started
+// before since. Codex dates the directories by local time, so a day of slack
+// covers any time zone.
+func codexDirBefore(rel string, since time.Time) bool {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	date := make([]int, 0, len(parts))
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return false
+		}
+		date = append(date, n)
+	}
+
+	var end time.Time
+	switch len(date) {
+	case 1:
+		end = time.Date(date[0]+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	case 2:
+		end = time.Date(date[0], time.Month(date[1])+1, 1, 0, 0, 0, 0, time.UTC)
+	case 3:
+		end = time.Date(date[0], time.Month(date[1]), date[2]+1, 0, 0, 0, 0, time.UTC)
+	default:
+		return false
+	}
+	return end.Add(24 * time.Hour).Before(since)
+}
+
+func readCodexSessionMeta(path string) (Session, bool, error) {
+	// #nosec G304 -- paths come from walking the Codex sessions directory.
+	file, err := os.Open(path)
+	if err != nil {
+		return Session{}, false, fmt.Errorf("open codex log: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	reader := bufio.NewReader(file)
+	line, err := reader.ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		// An empty log is a session that has not written anything yet.
+		return Session{}, false, nil
+	}
+
+	var meta codexLogLine
+	if err := json.Unmarshal(line, &meta); err != nil || meta.Type != "session_meta" || meta.Payload.ID == "" {
+		// Logs from older Codex releases have no session_meta header and
+		// no cwd to match on.
+		return Session{}, false, nil
+	}
+	startedAt, err := time.Parse(time.RFC3339Nano, meta.Payload.Timestamp)
+	if err != nil {
+		return Session{}, false, nil
+	}
+	return Session{
+		Kind:      KindCodex,
+		ID:        meta.Payload.ID,
+		Cwd:       meta.Payload.Cwd,
+		StartedAt: startedAt,
+		Path:      path,
+	}, true, nil
+}
@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const codexFixtureRoot = "testdata/codex/sessions"
+
+func TestFindCodexSessionPicksLatestForCwd(t *testing.T) {
+	t.Parallel()
+
+	since := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	session, found, err := FindCodexSession(codexFixtureRoot, "/work/alpha/", since)
+	if err != nil {
+		t.Fatalf("FindCodexSession: %v", err)
+	}
+	if !found {
+		t.Fatalf("expected a session for /work/alpha")
+	}
+	if session.ID != "0195a1b2-0000-7000-8000-00000000a002" {
+		t.Fatalf("expected latest alpha session, got %#v", session)
+	}
+	if session.Cwd != "/work/alpha" || session.StartedAt.IsZero() {
+		t.Fatalf("unexpected session metadata: %#v", session)
+	}
+}
+
+func TestFindCodexSessionIgnoresSessionsBeforeSince(t *testing.T) {
+	t.Parallel()
+
+	since := time.Date(2026, 3, 1, 11, 0, 0, 0, time.UTC)
+	if _, found, err := FindCodexSession(codexFixtureRoot, "/work/alpha", since); err != nil || found {
+		t.Fatalf("expected no session started after %s, found=%v err=%v", since, found, err)
+	}
+
+	since = time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	session, found, err := FindCodexSession(codexFixtureRoot, "/work/beta", since)
+	if err != nil || !found || session.ID != "0195a1b2-0000-7000-8000-00000000b001" {
+		t.Fatalf("expected beta session, got %#v found=%v err=%v", session, found, err)
+	}
+}
+
+func TestFindCodexSessionSkipsDirectoriesBeforeSince(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	// A log filed under a day long before since is not read, whatever it
+	// says.
+	dir := filepath.Join(root, "2026", "01", "15")
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	meta := `{"type":"session_meta","payload":{"id":"misfiled","timestamp":"2026-03-01T10:00:00Z","cwd":"/work/alpha"}}` + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "rollout-2026-01-15T09-00-00-misfiled.jsonl"), []byte(meta), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	since := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	if _, found, err := FindCodexSession(root, "/work/alpha", since); err != nil || found {
+		t.Fatalf("expected the old directory to be skipped, found=%v err=%v", found, err)
+	}
+	if _, found, err := FindCodexSession(root, "/work/alpha", time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)); err != nil || !found {
+		t.Fatalf("expected the directory to be read from its own day, found=%v err=%v", found, err)
+	}
+}
+
+func TestFindCodexSessionWithoutSessionsDir(t *testing.T) {
+	t.Parallel()
+
+	_, found, err := FindCodexSession(t.TempDir()+"/missing", "/work/alpha", time.Time{})
+	if err != nil || found {
+		t.Fatalf("expected no session and no error, found=%v err=%v", found, err)
+	}
+}
+
+func TestCodexSessionsDirHonorsCodexHome(t *testing.T) {
+	t.Setenv("CODEX_HOME", "/tmp/codex-home")
+
+	if got := CodexSessionsDir(); got != "/tmp/codex-home/sessions" {
+		t.Fatalf("unexpected sessions dir: %q", got)
+	}
+}
@@ -0,0 +1,228 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	BackendWezTerm = "wezterm"
+	BackendTmux    = "tmux"
+	BackendKitty   = "kitty"
+)
+
+// Prompt modes say how a profile's initial prompt reaches the agent.
+const (
+	PromptModeArg      = "arg"
+	PromptModeSendText = "send-text"
+)
+
+const (
+	ProfileCodex  = "codex"
+	ProfileClaude = "claude"
+	ProfileShell  = "shell"
+)
+
+type Config struct {
+	Backend string                `json:"backend"`
+	Kitty   KittyConfig           `json:"kitty"`
+	WezTerm WezTermConfig         `json:"wezterm"`
+	Repos   map[string]RepoConfig `json:"repos"`
+	// Profiles add to or override the built-in codex, claude and shell
+	// profiles.
+	Profiles       map[string]Profile `json:"profiles"`
+	DefaultProfile string             `json:"default_profile"`
+	Attention      AttentionConfig    `json:"attention"`
+	Context        ContextConfig      `json:"context"`
+	// Editor opens task notes when $VISUAL and $EDITOR are unset, e.g.
+	// "code --wait". It is split like a shell command line.
+	Editor string      `json:"editor"`
+	Notes  NotesConfig `json:"notes"`
+}
+
+type KittyConfig struct {
+	// Socket is passed to `kitty @ --to`, e.g. "unix:/tmp/kitty".
+	Socket string `json:"socket"`
+}
+
+type WezTermConfig struct {
+	// SSHHosts maps an SSHMUX domain's name to the ssh destination of its
+	// host, e.g. {"devbox": "me@devbox.example.com"}, for domains whose
+	// name is not itself an ssh host. It mirrors the domain's
+	// remote_address in wezterm.lua.
+	SSHHosts map[string]string `json:"ssh_hosts"`
+}
+
+type RepoConfig struct {
+	Domain  string `json:"domain"`
+	Profile string `json:"profile"`
+}
+
+// AttentionConfig controls how reconcile decides that an agent pane is
+// waiting for the user.
+type AttentionConfig struct {
+	// Patterns are regular expressions matched against each of the last
+	// Lines non-empty screen lines of an open pane. Empty uses
+	// DefaultAttentionPatterns.
+	Patterns []string `json:"patterns"`
+	Lines    int      `json:"lines"`
+}
+
+// DefaultAttentionPatterns match approval prompts and questions left on the
+// last lines of Codex and Claude Code panes.
+var DefaultAttentionPatterns = []string{
+	`(?i)\b(allow|approve|proceed)\b.*\?`,
+	`(?i)do you want to`,
+	`(?i)would you like to`,
+	`(?i)[\[(](y/n|yes/no)[\])]`,
+	`(?i)press enter to`,
+	`\?\s*$`,
+}
+
+const defaultAttentionLines = 5
+
+// ContextConfig controls the task context file written into session
+// working directories.
+type ContextConfig struct {
+	// File is the file name agents read from their cwd, such as AGENTS.md.
+	// Context files are off while it is empty.
+	File string `json:"file"`
+}
+
+// NotesConfig controls version control of the notes directory.
+type NotesConfig struct {
+	// Git makes the notes directory a git repository; notes are committed
+	// after open-note and close-session.
+	Git bool `json:"git"`
+	// Remote is the URL `ttt note sync` pushes to and pulls from.
+	Remote string `json:"remote"`
+}
+
+// Profile is a program a task session pane runs, such as an agent CLI.
+type Profile struct {
+	// Argv is run in the pane; empty means just the user's shell.
+	Argv []string          `json:"argv"`
+	Env  map[string]string `json:"env"`
+	// PromptTemplate is a text/template rendered with the task into the
+	// initial prompt of a freshly started agent. Agent profiles without one
+	// are prompted with the task note context.
+	PromptTemplate string `json:"prompt_template"`
+	// PromptMode is "arg" (default) to pass the initial prompt as the last
+	// argument, or "send-text" to type it into the pane once it has started.
+	PromptMode string `json:"prompt_mode"`
+	// ReadyPattern is a regexp matched against the pane's screen; a
+	// send-text prompt is typed once it matches. Without one the prompt waits
+	// for the screen to stop changing.
+	ReadyPattern string `json:"ready_pattern"`
+	// Agent names the agent CLI the profile runs ("codex", "claude") so a
+	// previous conversation of that agent can be resumed.
+	Agent string `json:"agent"`
+}
+
+var builtinProfiles = map[string]Profile{
+	ProfileCodex:  {Argv: []string{"codex"}, Agent: "codex"},
+	ProfileClaude: {Argv: []string{"claude"}, Agent: "claude"},
+	ProfileShell:  {},
+}
+
+// Load reads a JSON config file. A missing file yields an empty config so
+// commands keep working without any setup.
+func Load(path string) (Config, error) {
+	if strings.TrimSpace(path) == "" {
+		return Config{}, nil
+	}
+
+	// #nosec G304 -- config path is intentionally user-configurable.
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Config{}, nil
+		}
+		return Config{}, fmt.Errorf("read config file: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return Config{}, fmt.Errorf("decode config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// TerminalBackend returns the configured terminal backend, defaulting to
+// WezTerm.
+func (c Config) TerminalBackend() string {
+	backend := strings.ToLower(strings.TrimSpace(c.Backend))
+	if backend == "" {
+		return BackendWezTerm
+	}
+	return backend
+}
+
+func (c Config) Repo(repo string) RepoConfig {
+	if c.Repos == nil {
+		return RepoConfig{}
+	}
+	key := strings.ToLower(strings.TrimSpace(repo))
+	if entry, ok := c.Repos[key]; ok {
+		return entry
+	}
+	for name, entry := range c.Repos {
+		if strings.ToLower(strings.TrimSpace(name)) == key {
+			return entry
+		}
+	}
+	return RepoConfig{}
+}
+
+// Profile looks up a profile by name, preferring configured profiles over
+// the built-in ones.
+func (c Config) Profile(name string) (Profile, bool) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	for profileName, profile := range c.Profiles {
+		if strings.ToLower(strings.TrimSpace(profileName)) == key {
+			return profile, true
+		}
+	}
+	profile, ok := builtinProfiles[key]
+	return profile, ok
+}
+
+// ProfileForRepo returns the profile name a new session in repo should use
+// when none is requested: the repo's profile, then default_profile, then a
+// plain shell.
+func (c Config) ProfileForRepo(repo string) string {
+	if name := strings.TrimSpace(c.Repo(repo).Profile); name != "" {
+		return name
+	}
+	if name := strings.TrimSpace(c.DefaultProfile); name != "" {
+		return name
+	}
+	return ProfileShell
+}
+
+// AttentionPatterns returns the configured attention patterns, or the
+// defaults when none are set.
+func (c Config) AttentionPatterns() []string {
+	if len(c.Attention.Patterns) == 0 {
+		return DefaultAttentionPatterns
+	}
+	return c.Attention.Patterns
+}
+
+// AttentionLines returns how many trailing screen lines are matched against
+// the attention patterns.
+func (c Config) AttentionLines() int {
+	if c.Attention.Lines <= 0 {
+		return defaultAttentionLines
+	}
+	return c.Attention.Lines
+}
+
+// ContextFile returns the task context file name, or "" when context files
+// are off, which is the default.
+func (c Config) ContextFile() string {
+	return strings.TrimSpace(c.Context.File)
+}
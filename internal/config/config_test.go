@@ -0,0 +1,148 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadMissingFileReturnsEmptyConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(cfg.Repos) != 0 {
+		t.Fatalf("expected empty repos, got %#v", cfg.Repos)
+	}
+}
+
+func TestLoadRepoDomain(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	raw := `{"repos": {"Owner/Repo": {"domain": "devbox"}}}`
+	if err := os.WriteFile(path, []byte(raw), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if got := cfg.Repo("owner/repo").Domain; got != "devbox" {
+		t.Fatalf("expected domain=devbox, got %q", got)
+	}
+	if got := cfg.Repo("other/repo").Domain; got != "" {
+		t.Fatalf("expected empty domain for unknown repo, got %q", got)
+	}
+}
+
+func TestLoadRejectsBadJSON(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte("{nope"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatalf("expected decode error")
+	}
+}
+
+func TestTerminalBackendDefaultsToWezTerm(t *testing.T) {
+	t.Parallel()
+
+	if got := (Config{}).TerminalBackend(); got != BackendWezTerm {
+		t.Fatalf("expected default backend %q, got %q", BackendWezTerm, got)
+	}
+	if got := (Config{Backend: " TMUX "}).TerminalBackend(); got != BackendTmux {
+		t.Fatalf("expected normalized backend %q, got %q", BackendTmux, got)
+	}
+}
+
+func TestProfilesOverrideBuiltins(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	raw := `{
+	  "default_profile": "codex",
+	  "profiles": {
+	    "Codex": {"argv": ["codex", "--full-auto"], "env": {"CODEX_PROFILE": "work"}, "agent": "codex"},
+	    "review": {"argv": ["claude"], "prompt_template": "Review {{.Alias}}", "agent": "claude"}
+	  },
+	  "repos": {"owner/repo": {"profile": "review"}}
+	}`
+	if err := os.WriteFile(path, []byte(raw), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	codex, ok := cfg.Profile("codex")
+	if !ok || len(codex.Argv) != 2 || codex.Env["CODEX_PROFILE"] != "work" {
+		t.Fatalf("expected configured codex profile, got %#v ok=%v", codex, ok)
+	}
+	claude, ok := cfg.Profile("claude")
+	if !ok || len(claude.Argv) != 1 || claude.Agent != "claude" {
+		t.Fatalf("expected built-in claude profile, got %#v ok=%v", claude, ok)
+	}
+	if shell, ok := cfg.Profile("shell"); !ok || len(shell.Argv) != 0 {
+		t.Fatalf("expected built-in shell profile, got %#v ok=%v", shell, ok)
+	}
+	if _, ok := cfg.Profile("missing"); ok {
+		t.Fatalf("expected unknown profile lookup to fail")
+	}
+
+	if got := cfg.ProfileForRepo("owner/repo"); got != "review" {
+		t.Fatalf("expected repo profile, got %q", got)
+	}
+	if got := cfg.ProfileForRepo("other/repo"); got != "codex" {
+		t.Fatalf("expected default_profile, got %q", got)
+	}
+	if got := (Config{}).ProfileForRepo("other/repo"); got != ProfileShell {
+		t.Fatalf("expected shell fallback, got %q", got)
+	}
+}
+
+func TestAttentionDefaultsAndOverrides(t *testing.T) {
+	t.Parallel()
+
+	var empty Config
+	if got := empty.AttentionPatterns(); len(got) != len(DefaultAttentionPatterns) {
+		t.Fatalf("expected default patterns, got %#v", got)
+	}
+	if got := empty.AttentionLines(); got != defaultAttentionLines {
+		t.Fatalf("expected default lines, got %d", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	raw := `{"attention": {"patterns": ["needs review"], "lines": 2}}`
+	if err := os.WriteFile(path, []byte(raw), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if got := cfg.AttentionPatterns(); len(got) != 1 || got[0] != "needs review" {
+		t.Fatalf("expected configured patterns, got %#v", got)
+	}
+	if got := cfg.AttentionLines(); got != 2 {
+		t.Fatalf("expected configured lines, got %d", got)
+	}
+}
+
+func TestContextFileIsOptIn(t *testing.T) {
+	t.Parallel()
+
+	if got := (Config{}).ContextFile(); got != "" {
+		t.Fatalf("expected context files to be off by default, got %q", got)
+	}
+	if got := (Config{Context: ContextConfig{File: " CLAUDE.local.md "}}).ContextFile(); got != "CLAUDE.local.md" {
+		t.Fatalf("expected configured context file, got %q", got)
+	}
+}
@@ -0,0 +1,101 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotRepository is returned for directories outside any git work tree.
+var ErrNotRepository = errors.New("not a git repository")
+
+// CLIClient runs git commands against a work tree directory.
+type CLIClient struct {
+	exec ExecFunc
+}
+
+func NewCLIClient() *CLIClient {
+	return &CLIClient{exec: DefaultExec}
+}
+
+func NewCLIClientWithExec(execFn ExecFunc) *CLIClient {
+	return &CLIClient{exec: execFn}
+}
+
+func (c *CLIClient) run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	output, err := c.exec(ctx, "git", append([]string{"-C", dir}, args...)...)
+	if err != nil {
+		var execErr *ExecError
+		if errors.As(err, &execErr) && strings.Contains(strings.ToLower(execErr.Stderr), "not a git repository") {
+			return nil, fmt.Errorf("%w: %s", ErrNotRepository, dir)
+		}
+		return nil, fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return output, nil
+}
+
+// IsTracked reports whether name, relative to dir, is tracked by git.
+func (c *CLIClient) IsTracked(ctx context.Context, dir, name string) (bool, error) {
+	output, err := c.run(ctx, dir, "ls-files", "--", name)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(output)) != "", nil
+}
+
+// Exclude adds name, relative to dir, to the repository's info/exclude so git
+// ignores it without touching any committed .gitignore. Linked worktrees
+// share the main repository's exclude file. It reports whether the entry
+// was added rather than already present.
+func (c *CLIClient) Exclude(ctx context.Context, dir, name string) (bool, error) {
+	output, err := c.run(ctx, dir, "rev-parse", "--git-common-dir", "--show-prefix")
+	if err != nil {
+		return false, err
+	}
+	lines := strings.Split(strings.TrimRight(string(output), "\n"), "\n")
+	commonDir := strings.TrimSpace(lines[0])
+	prefix := ""
+	if len(lines) > 1 {
+		prefix = strings.TrimSpace(lines[1])
+	}
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(dir, commonDir)
+	}
+
+	pattern := "/" + filepath.ToSlash(filepath.Join(prefix, name))
+	excludePath := filepath.Join(commonDir, "info", "exclude")
+	// #nosec G304 -- the exclude path comes from git rev-parse.
+	existing, err := os.ReadFile(excludePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("read %s: %w", excludePath, err)
+	}
+	for _, line := range strings.Split(string(existing), "\n") {
+		if strings.TrimSpace(line) == pattern {
+			return false, nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(excludePath), 0o750); err != nil {
+		return false, fmt.Errorf("create %s: %w", filepath.Dir(excludePath), err)
+	}
+	entry := pattern + "\n"
+	if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+		entry = "\n" + entry
+	}
+	// #nosec G304 -- the exclude path comes from git rev-parse.
+	file, err := os.OpenFile(excludePath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return false, fmt.Errorf("open %s: %w", excludePath, err)
+	}
+	if _, err := file.WriteString(entry); err != nil {
+		_ = file.Close()
+		return false, fmt.Errorf("append to %s: %w", excludePath, err)
+	}
+	if err := file.Close(); err != nil {
+		return false, fmt.Errorf("close %s: %w", excludePath, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,76 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func initRepo(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	if output, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v (%s)", err, output)
+	}
+	return dir
+}
+
+func TestExcludeAddsPatternOnce(t *testing.T) {
+	t.Parallel()
+
+	dir := initRepo(t)
+	sub := filepath.Join(dir, "pkg")
+	if err := os.MkdirAll(sub, 0o750); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	client := NewCLIClient()
+
+	for _, want := range []bool{true, false} {
+		added, err := client.Exclude(context.Background(), sub, "AGENTS.md")
+		if err != nil {
+			t.Fatalf("Exclude: %v", err)
+		}
+		if added != want {
+			t.Fatalf("Exclude added=%v, want %v", added, want)
+		}
+	}
+	// #nosec G304 -- path is inside t.TempDir.
+	content, err := os.ReadFile(filepath.Join(dir, ".git", "info", "exclude"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if strings.Count(string(content), "/pkg/AGENTS.md\n") != 1 {
+		t.Fatalf("expected one exclude entry, got %q", content)
+	}
+}
+
+func TestIsTrackedAndNotRepository(t *testing.T) {
+	t.Parallel()
+
+	dir := initRepo(t)
+	if err := os.WriteFile(filepath.Join(dir, "AGENTS.md"), []byte("rules\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	client := NewCLIClient()
+	if tracked, err := client.IsTracked(context.Background(), dir, "AGENTS.md"); err != nil || tracked {
+		t.Fatalf("expected untracked file, got tracked=%v err=%v", tracked, err)
+	}
+	if output, err := exec.Command("git", "-C", dir, "add", "AGENTS.md").CombinedOutput(); err != nil {
+		t.Fatalf("git add: %v (%s)", err, output)
+	}
+	if tracked, err := client.IsTracked(context.Background(), dir, "AGENTS.md"); err != nil || !tracked {
+		t.Fatalf("expected tracked file, got tracked=%v err=%v", tracked, err)
+	}
+
+	if _, err := client.Exclude(context.Background(), t.TempDir(), "AGENTS.md"); !errors.Is(err, ErrNotRepository) {
+		t.Fatalf("expected ErrNotRepository, got %v", err)
+	}
+}
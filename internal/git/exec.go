@@ -0,0 +1,50 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ExecFunc runs a command and returns its stdout. Tests swap it for a fake.
+type ExecFunc func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// ExecError describes a failed git invocation. Stderr is kept separately so
+// failures can be classified without parsing the formatted message.
+type ExecError struct {
+	Name     string
+	Args     []string
+	ExitCode int
+	Stderr   string
+	Err      error
+}
+
+func (e *ExecError) Error() string {
+	return fmt.Sprintf("%s %v failed: %v (%s)", e.Name, e.Args, e.Err, strings.TrimSpace(e.Stderr))
+}
+
+func (e *ExecError) Unwrap() error { return e.Err }
+
+// DefaultExec runs a command and returns its stdout. Failures are reported
+// as *ExecError.
+func DefaultExec(ctx context.Context, name string, args ...string) ([]byte, error) {
+	command := exec.CommandContext(ctx, name, args...)
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+	output, err := command.Output()
+	if err != nil {
+		execErr := &ExecError{Name: name, Args: args, ExitCode: -1, Stderr: stderr.String(), Err: err}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			execErr.ExitCode = exitErr.ExitCode()
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			execErr.Err = fmt.Errorf("%w: %w", ctxErr, err)
+		}
+		return nil, execErr
+	}
+	return output, nil
+}
@@ -0,0 +1,188 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoRemote is returned by Sync when no remote URL is given and the
+// repository has no origin.
+var ErrNoRemote = errors.New("no git remote configured")
+
+// Identity used for commits when git has no user configured, so notes can
+// be committed on a fresh machine.
+const (
+	fallbackUserName  = "ttt"
+	fallbackUserEmail = "ttt@localhost"
+)
+
+// SyncResult reports what Sync did.
+type SyncResult struct {
+	Branch    string `json:"branch"`
+	Committed bool   `json:"committed"`
+	Pulled    bool   `json:"pulled"`
+	Pushed    bool   `json:"pushed"`
+}
+
+// Init makes dir a repository of its own unless it already is the top of
+// one; a dir nested inside another work tree gets its own repository. It
+// reports whether a repository was created.
+func (c *CLIClient) Init(ctx context.Context, dir string) (bool, error) {
+	output, err := c.run(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil && !errors.Is(err, ErrNotRepository) {
+		return false, err
+	}
+	if err == nil && samePath(strings.TrimSpace(string(output)), dir) {
+		return false, nil
+	}
+	if _, err := c.run(ctx, dir, "init", "-q"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Commit stages paths (relative to dir; all of dir when none are given)
+// and commits them alone with message. It reports whether anything was
+// committed.
+func (c *CLIClient) Commit(ctx context.Context, dir, message string, paths ...string) (bool, error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	if _, err := c.run(ctx, dir, append([]string{"add", "-A", "--"}, paths...)...); err != nil {
+		return false, err
+	}
+	status, err := c.run(ctx, dir, append([]string{"status", "--porcelain", "--"}, paths...)...)
+	if err != nil {
+		return false, err
+	}
+	if strings.TrimSpace(string(status)) == "" {
+		return false, nil
+	}
+
+	args := []string{"commit", "-q", "-m", message, "--"}
+	if !c.hasIdentity(ctx, dir) {
+		args = append([]string{"-c", "user.name=" + fallbackUserName, "-c", "user.email=" + fallbackUserEmail}, args...)
+	}
+	if _, err := c.run(ctx, dir, append(args, paths...)...); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Sync commits everything pending in dir with message, rebases onto the
+// current branch of origin when it exists there, and pushes. A non-empty
+// remoteURL is set as origin first. A repository without commits takes
+// origin's default branch, whatever its own initial branch is called.
+func (c *CLIClient) Sync(ctx context.Context, dir, remoteURL, message string) (SyncResult, error) {
+	var result SyncResult
+	if err := c.ensureOrigin(ctx, dir, remoteURL); err != nil {
+		return result, err
+	}
+	if !c.hasCommits(ctx, dir) {
+		if err := c.useRemoteHead(ctx, dir); err != nil {
+			return result, err
+		}
+	}
+
+	committed, err := c.Commit(ctx, dir, message)
+	if err != nil {
+		return result, err
+	}
+	result.Committed = committed
+
+	output, err := c.run(ctx, dir, "symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return result, err
+	}
+	result.Branch = strings.TrimSpace(string(output))
+
+	heads, err := c.run(ctx, dir, "ls-remote", "--heads", "origin", result.Branch)
+	if err != nil {
+		return result, err
+	}
+	if strings.TrimSpace(string(heads)) != "" {
+		args := []string{"pull", "-q", "--rebase", "origin", result.Branch}
+		if !c.hasIdentity(ctx, dir) {
+			args = append([]string{"-c", "user.name=" + fallbackUserName, "-c", "user.email=" + fallbackUserEmail}, args...)
+		}
+		if _, err := c.run(ctx, dir, args...); err != nil {
+			return result, err
+		}
+		result.Pulled = true
+	}
+
+	// A repository without commits has nothing to push.
+	if !c.hasCommits(ctx, dir) {
+		return result, nil
+	}
+	if _, err := c.run(ctx, dir, "push", "-q", "-u", "origin", result.Branch); err != nil {
+		return result, err
+	}
+	result.Pushed = true
+	return result, nil
+}
+
+func (c *CLIClient) ensureOrigin(ctx context.Context, dir, remoteURL string) error {
+	current, err := c.run(ctx, dir, "remote")
+	if err != nil {
+		return err
+	}
+	hasOrigin := false
+	for _, name := range strings.Fields(string(current)) {
+		hasOrigin = hasOrigin || name == "origin"
+	}
+
+	switch {
+	case remoteURL == "" && !hasOrigin:
+		return fmt.Errorf("%w for %s", ErrNoRemote, dir)
+	case remoteURL == "":
+		return nil
+	case !hasOrigin:
+		_, err = c.run(ctx, dir, "remote", "add", "origin", remoteURL)
+	default:
+		_, err = c.run(ctx, dir, "remote", "set-url", "origin", remoteURL)
+	}
+	return err
+}
+
+// hasCommits reports whether HEAD points at a commit rather than an unborn
+// branch.
+func (c *CLIClient) hasCommits(ctx context.Context, dir string) bool {
+	_, err := c.run(ctx, dir, "rev-parse", "-q", "--verify", "HEAD")
+	return err == nil
+}
+
+// useRemoteHead points an unborn HEAD at the branch origin's HEAD names. An
+// empty remote has no HEAD and leaves the local branch as it is.
+func (c *CLIClient) useRemoteHead(ctx context.Context, dir string) error {
+	output, err := c.run(ctx, dir, "ls-remote", "--symref", "origin", "HEAD")
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[0] == "ref:" && fields[2] == "HEAD" && strings.HasPrefix(fields[1], "refs/heads/") {
+			_, err := c.run(ctx, dir, "symbolic-ref", "HEAD", fields[1])
+			return err
+		}
+	}
+	return nil
+}
+
+// hasIdentity reports whether git can determine a committer on its own.
+func (c *CLIClient) hasIdentity(ctx context.Context, dir string) bool {
+	_, err := c.run(ctx, dir, "var", "GIT_COMMITTER_IDENT")
+	return err == nil
+}
+
+func samePath(a, b string) bool {
+	resolvedA, errA := filepath.EvalSymlinks(a)
+	resolvedB, errB := filepath.EvalSymlinks(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return resolvedA == resolvedB
+}
@@ -0,0 +1,114 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	output, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v (%s)", args, err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func TestInitAndCommitPaths(t *testing.T) {
+	t.Parallel()
+
+	parent := initRepo(t)
+	dir := filepath.Join(parent, "notes")
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	client := NewCLIClient()
+	ctx := context.Background()
+
+	// A dir inside another work tree still gets a repository of its own.
+	for _, want := range []bool{true, false} {
+		created, err := client.Init(ctx, dir)
+		if err != nil || created != want {
+			t.Fatalf("Init created=%v err=%v, want %v", created, err, want)
+		}
+	}
+
+	for name, content := range map[string]string{"a.md": "a\n", "b.md": "b\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	committed, err := client.Commit(ctx, dir, "Update note: a", "a.md")
+	if err != nil || !committed {
+		t.Fatalf("Commit committed=%v err=%v", committed, err)
+	}
+	if got := gitOutput(t, dir, "log", "--format=%s", "--name-only"); got != "Update note: a\n\na.md" {
+		t.Fatalf("unexpected log: %q", got)
+	}
+	if got := gitOutput(t, dir, "status", "--porcelain"); got != "?? b.md" {
+		t.Fatalf("expected b.md left alone, got %q", got)
+	}
+	if committed, err := client.Commit(ctx, dir, "again", "a.md"); err != nil || committed {
+		t.Fatalf("expected nothing to commit, committed=%v err=%v", committed, err)
+	}
+}
+
+func TestSyncPushesAndPulls(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	remote := filepath.Join(t.TempDir(), "notes.git")
+	if output, err := exec.Command("git", "init", "-q", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init --bare: %v (%s)", err, output)
+	}
+	client := NewCLIClient()
+	ctx := context.Background()
+
+	first := t.TempDir()
+	if _, err := client.Init(ctx, first); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if _, err := client.Sync(ctx, first, "", "Sync notes"); !errors.Is(err, ErrNoRemote) {
+		t.Fatalf("expected ErrNoRemote, got %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(first, "task_a.md"), []byte("from first\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	result, err := client.Sync(ctx, first, remote, "Sync notes")
+	if err != nil {
+		t.Fatalf("Sync first: %v", err)
+	}
+	if !result.Committed || result.Pulled || !result.Pushed {
+		t.Fatalf("unexpected first sync: %#v", result)
+	}
+
+	// A second machine starts empty, pulls, adds a note and pushes.
+	second := t.TempDir()
+	if _, err := client.Init(ctx, second); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	// Its initial branch need not match the remote's.
+	gitOutput(t, second, "symbolic-ref", "HEAD", "refs/heads/not-"+result.Branch)
+	if err := os.WriteFile(filepath.Join(second, "task_b.md"), []byte("from second\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	// The first push made the remote's branch; rebasing onto it keeps both notes.
+	if synced, err := client.Sync(ctx, second, remote, "Sync notes"); err != nil || synced.Branch != result.Branch || !synced.Pulled || !synced.Pushed {
+		t.Fatalf("Sync second = %#v, %v", synced, err)
+	}
+	if result, err := client.Sync(ctx, first, "", "Sync notes"); err != nil || result.Committed || !result.Pulled {
+		t.Fatalf("Sync first again = %#v, %v", result, err)
+	}
+	// #nosec G304 -- path is inside t.TempDir.
+	if content, err := os.ReadFile(filepath.Join(first, "task_b.md")); err != nil || string(content) != "from second\n" {
+		t.Fatalf("expected the second machine's note, got %q err=%v", content, err)
+	}
+}
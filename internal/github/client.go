@@ -0,0 +1,56 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"term-workspaces/internal/terminal"
+	"time"
+)
+
+// CLIClient reads pull request metadata through the GitHub CLI (`gh`), so it
+// uses whatever account `gh auth login` set up.
+type CLIClient struct {
+	exec terminal.ExecFunc
+}
+
+func NewCLIClient() *CLIClient {
+	return &CLIClient{exec: terminal.DefaultExec}
+}
+
+func NewCLIClientWithExec(execFn terminal.ExecFunc) *CLIClient {
+	return &CLIClient{exec: execFn}
+}
+
+// PRTitle returns the title of pull request number in repo (owner/repo).
+func (c *CLIClient) PRTitle(ctx context.Context, repo string, number int) (string, error) {
+	output, err := c.exec(ctx, "gh", "pr", "view", strconv.Itoa(number), "--repo", repo, "--json", "title", "--jq", ".title")
+	if err != nil {
+		return "", fmt.Errorf("gh pr view %s#%d: %w", repo, number, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// PRState is a pull request's state ("OPEN", "CLOSED" or "MERGED") and when
+// it was opened, closed and merged. Times that have not happened are zero.
+type PRState struct {
+	State     string    `json:"state"`
+	CreatedAt time.Time `json:"createdAt"`
+	ClosedAt  time.Time `json:"closedAt"`
+	MergedAt  time.Time `json:"mergedAt"`
+}
+
+// PRState returns the state of pull request number in repo (owner/repo).
+func (c *CLIClient) PRState(ctx context.Context, repo string, number int) (PRState, error) {
+	output, err := c.exec(ctx, "gh", "pr", "view", strconv.Itoa(number), "--repo", repo, "--json", "state,createdAt,closedAt,mergedAt")
+	if err != nil {
+		return PRState{}, fmt.Errorf("gh pr view %s#%d: %w", repo, number, err)
+	}
+	var state PRState
+	if err := json.Unmarshal(output, &state); err != nil {
+		return PRState{}, fmt.Errorf("decode gh pr view %s#%d: %w", repo, number, err)
+	}
+	return state, nil
+}
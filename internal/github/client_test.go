@@ -0,0 +1,62 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPRTitleQueriesGH(t *testing.T) {
+	t.Parallel()
+
+	client := NewCLIClientWithExec(func(_ context.Context, name string, args ...string) ([]byte, error) {
+		expected := []string{"pr", "view", "42", "--repo", "owner/repo", "--json", "title", "--jq", ".title"}
+		if name != "gh" || !reflect.DeepEqual(args, expected) {
+			t.Fatalf("unexpected command: %s %#v", name, args)
+		}
+		return []byte("Add attention view\n"), nil
+	})
+
+	title, err := client.PRTitle(context.Background(), "owner/repo", 42)
+	if err != nil {
+		t.Fatalf("PRTitle returned error: %v", err)
+	}
+	if title != "Add attention view" {
+		t.Fatalf("unexpected title: %q", title)
+	}
+}
+
+func TestPRTitleWrapsExecError(t *testing.T) {
+	t.Parallel()
+
+	boom := errors.New("boom")
+	client := NewCLIClientWithExec(func(context.Context, string, ...string) ([]byte, error) {
+		return nil, boom
+	})
+	if _, err := client.PRTitle(context.Background(), "owner/repo", 42); !errors.Is(err, boom) {
+		t.Fatalf("expected wrapped exec error, got %v", err)
+	}
+}
+
+func TestPRStateQueriesGH(t *testing.T) {
+	t.Parallel()
+
+	client := NewCLIClientWithExec(func(_ context.Context, name string, args ...string) ([]byte, error) {
+		expected := []string{"pr", "view", "42", "--repo", "owner/repo", "--json", "state,createdAt,closedAt,mergedAt"}
+		if name != "gh" || !reflect.DeepEqual(args, expected) {
+			t.Fatalf("unexpected command: %s %#v", name, args)
+		}
+		return []byte(`{"closedAt":null,"createdAt":"2026-03-01T10:00:00Z","mergedAt":null,"state":"OPEN"}`), nil
+	})
+
+	state, err := client.PRState(context.Background(), "owner/repo", 42)
+	if err != nil {
+		t.Fatalf("PRState returned error: %v", err)
+	}
+	expected := PRState{State: "OPEN", CreatedAt: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)}
+	if !reflect.DeepEqual(state, expected) {
+		t.Fatalf("unexpected state: %#v", state)
+	}
+}
@@ -0,0 +1,228 @@
+package kitty
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"term-workspaces/internal/terminal"
+)
+
+var errorPatterns = terminal.ErrorPatterns{
+	PaneNotFound:   []string{"no matching windows"},
+	MuxUnavailable: []string{"failed to connect", "could not connect", "connection refused"},
+}
+
+// CLIClient drives kitty through `kitty @` remote control. Each task workspace
+// is a kitty tab titled with the workspace name, opened in its own OS window;
+// kitty window IDs are used as pane IDs. kitty has no multiplexer domains, so
+// SpawnOptions.Domain is ignored.
+type CLIClient struct {
+	exec   terminal.ExecFunc
+	socket string
+}
+
+var _ terminal.Client = (*CLIClient)(nil)
+
+// NewCLIClient targets the kitty instance listening on socket (for example
+// "unix:/tmp/kitty"). An empty socket relies on KITTY_LISTEN_ON, which is
+// only set when ttt itself runs inside kitty.
+func NewCLIClient(socket string) *CLIClient {
+	return &CLIClient{exec: terminal.DefaultExec, socket: socket}
+}
+
+func NewCLIClientWithExec(socket string, execFn terminal.ExecFunc) *CLIClient {
+	return &CLIClient{exec: execFn, socket: socket}
+}
+
+type lsOSWindow struct {
+	ID   int64   `json:"id"`
+	Tabs []lsTab `json:"tabs"`
+}
+
+type lsTab struct {
+	ID      int64      `json:"id"`
+	Title   string     `json:"title"`
+	Windows []lsWindow `json:"windows"`
+}
+
+type lsWindow struct {
+	ID                  int64               `json:"id"`
+	UserVars            map[string]string   `json:"user_vars"`
+	ForegroundProcesses []lsForegroundEntry `json:"foreground_processes"`
+}
+
+type lsForegroundEntry struct {
+	PID     int64    `json:"pid"`
+	Cmdline []string `json:"cmdline"`
+}
+
+func (c *CLIClient) Spawn(ctx context.Context, opts terminal.SpawnOptions) (int64, error) {
+	osWindows, err := c.ls(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	args := []string{"launch"}
+	if tabID, ok := findWorkspaceTab(osWindows, opts.Workspace); ok {
+		args = append(args, "--type=window", "--match", "id:"+strconv.FormatInt(tabID, 10))
+	} else {
+		args = append(args, "--type=os-window", "--os-window-title", opts.Workspace, "--tab-title", opts.Workspace)
+	}
+	if strings.TrimSpace(opts.Cwd) != "" {
+		args = append(args, "--cwd", opts.Cwd)
+	}
+	for _, name := range terminal.SortedKeys(opts.UserVars) {
+		args = append(args, "--var", name+"="+opts.UserVars[name])
+	}
+	for _, name := range terminal.SortedKeys(opts.Env) {
+		args = append(args, "--env", name+"="+opts.Env[name])
+	}
+	if len(opts.Argv) > 0 {
+		args = append(args, terminal.ShellCommand("", opts.Argv)...)
+	}
+
+	output, err := c.remote(ctx, args...)
+	if err != nil {
+		return 0, fmt.Errorf("kitty launch: %w", err)
+	}
+
+	windowIDRaw := strings.TrimSpace(string(output))
+	windowID, err := strconv.ParseInt(windowIDRaw, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse launch window id %q: %w", windowIDRaw, err)
+	}
+	return windowID, nil
+}
+
+// SplitPane opens a window beside paneID in the same tab. Kitty only lays
+// it out side by side when the tab uses the splits layout.
+func (c *CLIClient) SplitPane(ctx context.Context, paneID int64, opts terminal.SplitOptions) (int64, error) {
+	args := []string{"launch", "--type=window", "--location=vsplit", "--match", windowMatch(paneID)}
+	if strings.TrimSpace(opts.Cwd) != "" {
+		args = append(args, "--cwd", opts.Cwd)
+	}
+	for _, name := range terminal.SortedKeys(opts.UserVars) {
+		args = append(args, "--var", name+"="+opts.UserVars[name])
+	}
+	args = append(args, terminal.ExecCommand("", opts.Argv)...)
+
+	output, err := c.remote(ctx, args...)
+	if err != nil {
+		return 0, fmt.Errorf("kitty launch split of %d: %w", paneID, err)
+	}
+
+	windowIDRaw := strings.TrimSpace(string(output))
+	windowID, err := strconv.ParseInt(windowIDRaw, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse launch window id %q: %w", windowIDRaw, err)
+	}
+	return windowID, nil
+}
+
+func (c *CLIClient) ActivatePane(ctx context.Context, paneID int64) error {
+	if _, err := c.remote(ctx, "focus-window", "--match", windowMatch(paneID)); err != nil {
+		return fmt.Errorf("kitty focus-window %d: %w", paneID, err)
+	}
+	return nil
+}
+
+func (c *CLIClient) KillPane(ctx context.Context, paneID int64) error {
+	if _, err := c.remote(ctx, "close-window", "--match", windowMatch(paneID)); err != nil {
+		return fmt.Errorf("kitty close-window %d: %w", paneID, err)
+	}
+	return nil
+}
+
+func (c *CLIClient) GetText(ctx context.Context, paneID int64) (string, error) {
+	output, err := c.remote(ctx, "get-text", "--match", windowMatch(paneID))
+	if err != nil {
+		return "", fmt.Errorf("kitty get-text %d: %w", paneID, err)
+	}
+	return string(output), nil
+}
+
+// SendText escapes backslashes because kitty applies Python escape rules to
+// send-text arguments; the trailing Enter is sent as an escaped "\r".
+func (c *CLIClient) SendText(ctx context.Context, paneID int64, text string) error {
+	escaped := strings.ReplaceAll(text, `\`, `\\`)
+	if _, err := c.remote(ctx, "send-text", "--match", windowMatch(paneID), "--bracketed-paste", "enable", "--", escaped); err != nil {
+		return fmt.Errorf("kitty send-text %d: %w", paneID, err)
+	}
+	if _, err := c.remote(ctx, "send-text", "--match", windowMatch(paneID), "--", `\r`); err != nil {
+		return fmt.Errorf("kitty send-text %d: %w", paneID, err)
+	}
+	return nil
+}
+
+func (c *CLIClient) ListPanes(ctx context.Context) ([]terminal.Pane, error) {
+	osWindows, err := c.ls(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return panesFromLS(osWindows), nil
+}
+
+func (c *CLIClient) ls(ctx context.Context) ([]lsOSWindow, error) {
+	output, err := c.remote(ctx, "ls")
+	if err != nil {
+		return nil, fmt.Errorf("kitty ls: %w", err)
+	}
+	return parseLSJSON(output)
+}
+
+func (c *CLIClient) remote(ctx context.Context, args ...string) ([]byte, error) {
+	full := []string{"@"}
+	if strings.TrimSpace(c.socket) != "" {
+		full = append(full, "--to", c.socket)
+	}
+	full = append(full, args...)
+	output, err := c.exec(ctx, "kitty", full...)
+	if err != nil {
+		return nil, terminal.ClassifyExecError(err, errorPatterns)
+	}
+	return output, nil
+}
+
+func parseLSJSON(raw []byte) ([]lsOSWindow, error) {
+	var osWindows []lsOSWindow
+	if err := json.Unmarshal(raw, &osWindows); err != nil {
+		return nil, fmt.Errorf("decode kitty ls json: %w", err)
+	}
+	return osWindows, nil
+}
+
+func panesFromLS(osWindows []lsOSWindow) []terminal.Pane {
+	panes := make([]terminal.Pane, 0)
+	for _, osWindow := range osWindows {
+		for _, tab := range osWindow.Tabs {
+			for _, window := range tab.Windows {
+				pane := terminal.Pane{PaneID: window.ID, Workspace: tab.Title}
+				if len(window.UserVars) > 0 {
+					pane.UserVars = window.UserVars
+				}
+				if len(window.ForegroundProcesses) > 0 && len(window.ForegroundProcesses[0].Cmdline) > 0 {
+					pane.ForegroundProcess = window.ForegroundProcesses[0].Cmdline[0]
+				}
+				panes = append(panes, pane)
+			}
+		}
+	}
+	return panes
+}
+
+func findWorkspaceTab(osWindows []lsOSWindow, workspace string) (int64, bool) {
+	for _, osWindow := range osWindows {
+		for _, tab := range osWindow.Tabs {
+			if tab.Title == workspace {
+				return tab.ID, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func windowMatch(paneID int64) string {
+	return "id:" + strconv.FormatInt(paneID, 10)
+}
@@ -0,0 +1,210 @@
+package kitty
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"sort"
+	"term-workspaces/internal/terminal"
+	"testing"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	// #nosec G304 -- fixture names are fixed test inputs under testdata.
+	raw, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("ReadFile(%s): %v", name, err)
+	}
+	return raw
+}
+
+func TestListPanesParsesOSWindowsAndTabs(t *testing.T) {
+	t.Parallel()
+
+	fixture := readFixture(t, "ls.json")
+	client := NewCLIClientWithExec("", func(_ context.Context, name string, args ...string) ([]byte, error) {
+		if name != "kitty" || !reflect.DeepEqual(args, []string{"@", "ls"}) {
+			t.Fatalf("unexpected call %q %#v", name, args)
+		}
+		return fixture, nil
+	})
+
+	panes, err := client.ListPanes(context.Background())
+	if err != nil {
+		t.Fatalf("ListPanes returned error: %v", err)
+	}
+	sort.Slice(panes, func(i, j int) bool { return panes[i].PaneID < panes[j].PaneID })
+	expected := []terminal.Pane{
+		{PaneID: 11, Workspace: "task-alpha", UserVars: map[string]string{terminal.TaskIDUserVar: "task-alpha-id"}, ForegroundProcess: "codex"},
+		{PaneID: 12, Workspace: "task-alpha", ForegroundProcess: "/bin/zsh"},
+		{PaneID: 21, Workspace: "task-beta", ForegroundProcess: "claude"},
+	}
+	if !reflect.DeepEqual(panes, expected) {
+		t.Fatalf("unexpected panes: %#v", panes)
+	}
+}
+
+func TestListPanesReturnsErrorOnBadJSON(t *testing.T) {
+	t.Parallel()
+
+	client := NewCLIClientWithExec("", func(_ context.Context, _ string, _ ...string) ([]byte, error) {
+		return []byte("{not-json"), nil
+	})
+	if _, err := client.ListPanes(context.Background()); err == nil {
+		t.Fatalf("expected JSON parse error")
+	}
+}
+
+func TestSpawnOpensOSWindowForNewWorkspace(t *testing.T) {
+	t.Parallel()
+
+	fixture := readFixture(t, "ls.json")
+	var launchArgs []string
+	client := NewCLIClientWithExec("unix:/tmp/kitty", func(_ context.Context, _ string, args ...string) ([]byte, error) {
+		if args[len(args)-1] == "ls" {
+			return fixture, nil
+		}
+		launchArgs = args
+		return []byte("31\n"), nil
+	})
+
+	paneID, err := client.Spawn(context.Background(), terminal.SpawnOptions{
+		Workspace: "task-gamma",
+		Cwd:       "/tmp/work",
+		UserVars:  map[string]string{terminal.TaskIDUserVar: "task-gamma-id"},
+	})
+	if err != nil {
+		t.Fatalf("Spawn returned error: %v", err)
+	}
+	if paneID != 31 {
+		t.Fatalf("expected paneID=31, got %d", paneID)
+	}
+	expected := []string{
+		"@", "--to", "unix:/tmp/kitty", "launch",
+		"--type=os-window", "--os-window-title", "task-gamma", "--tab-title", "task-gamma",
+		"--cwd", "/tmp/work",
+		"--var", "TTT_TASK_ID=task-gamma-id",
+	}
+	if !reflect.DeepEqual(launchArgs, expected) {
+		t.Fatalf("unexpected launch args: %#v", launchArgs)
+	}
+}
+
+func TestSpawnAddsWindowToExistingWorkspaceTab(t *testing.T) {
+	t.Parallel()
+
+	fixture := readFixture(t, "ls.json")
+	var launchArgs []string
+	client := NewCLIClientWithExec("", func(_ context.Context, _ string, args ...string) ([]byte, error) {
+		if args[len(args)-1] == "ls" {
+			return fixture, nil
+		}
+		launchArgs = args
+		return []byte("13\n"), nil
+	})
+
+	paneID, err := client.Spawn(context.Background(), terminal.SpawnOptions{
+		Workspace: "task-alpha",
+		Argv:      []string{"codex"},
+		Env:       map[string]string{"CODEX_PROFILE": "work"},
+	})
+	if err != nil {
+		t.Fatalf("Spawn returned error: %v", err)
+	}
+	if paneID != 13 {
+		t.Fatalf("expected paneID=13, got %d", paneID)
+	}
+	expected := []string{
+		"@", "launch", "--type=window", "--match", "id:3", "--env", "CODEX_PROFILE=work",
+		"sh", "-c", `set -m; "$@"; exec "${SHELL:-/bin/sh}" -l`, "sh", "codex",
+	}
+	if !reflect.DeepEqual(launchArgs, expected) {
+		t.Fatalf("unexpected launch args: %#v", launchArgs)
+	}
+}
+
+func TestWindowCommandsMatchWindowID(t *testing.T) {
+	t.Parallel()
+
+	var calls [][]string
+	client := NewCLIClientWithExec("", func(_ context.Context, _ string, args ...string) ([]byte, error) {
+		calls = append(calls, args)
+		return nil, nil
+	})
+
+	if err := client.ActivatePane(context.Background(), 11); err != nil {
+		t.Fatalf("ActivatePane returned error: %v", err)
+	}
+	if _, err := client.GetText(context.Background(), 11); err != nil {
+		t.Fatalf("GetText returned error: %v", err)
+	}
+	if err := client.SendText(context.Background(), 11, `fix C:\tmp`); err != nil {
+		t.Fatalf("SendText returned error: %v", err)
+	}
+	if err := client.KillPane(context.Background(), 11); err != nil {
+		t.Fatalf("KillPane returned error: %v", err)
+	}
+	expected := [][]string{
+		{"@", "focus-window", "--match", "id:11"},
+		{"@", "get-text", "--match", "id:11"},
+		{"@", "send-text", "--match", "id:11", "--bracketed-paste", "enable", "--", `fix C:\\tmp`},
+		{"@", "send-text", "--match", "id:11", "--", `\r`},
+		{"@", "close-window", "--match", "id:11"},
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("unexpected calls: %#v", calls)
+	}
+}
+
+func TestSpawnReturnsErrorOnExecFailure(t *testing.T) {
+	t.Parallel()
+
+	client := NewCLIClientWithExec("", func(_ context.Context, _ string, _ ...string) ([]byte, error) {
+		return nil, errors.New("boom")
+	})
+	if _, err := client.Spawn(context.Background(), terminal.SpawnOptions{Workspace: "task-x"}); err == nil {
+		t.Fatalf("expected spawn error")
+	}
+}
+
+func TestKillPaneClassifiesMissingWindow(t *testing.T) {
+	t.Parallel()
+
+	client := NewCLIClientWithExec("", func(_ context.Context, name string, args ...string) ([]byte, error) {
+		return nil, &terminal.ExecError{Name: name, Args: args, ExitCode: 1, Stderr: "Error: No matching windows for expression: id:11", Err: errors.New("exit status 1")}
+	})
+	if err := client.KillPane(context.Background(), 11); !errors.Is(err, terminal.ErrPaneNotFound) {
+		t.Fatalf("expected ErrPaneNotFound, got %v", err)
+	}
+}
+
+func TestSplitPaneLaunchesBesideWindow(t *testing.T) {
+	t.Parallel()
+
+	var calls [][]string
+	client := NewCLIClientWithExec("", func(_ context.Context, _ string, args ...string) ([]byte, error) {
+		calls = append(calls, args)
+		return []byte("23\n"), nil
+	})
+	paneID, err := client.SplitPane(context.Background(), 11, terminal.SplitOptions{
+		Cwd:      "/tmp/repo",
+		UserVars: map[string]string{terminal.TaskIDUserVar: "task_1"},
+		Argv:     []string{"vim", "/notes/task_1.md"},
+	})
+	if err != nil {
+		t.Fatalf("SplitPane returned error: %v", err)
+	}
+	if paneID != 23 {
+		t.Fatalf("expected window 23, got %d", paneID)
+	}
+	expected := [][]string{{
+		"@", "launch", "--type=window", "--location=vsplit", "--match", "id:11",
+		"--cwd", "/tmp/repo", "--var", "TTT_TASK_ID=task_1", "vim", "/notes/task_1.md",
+	}}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("unexpected calls: %#v", calls)
+	}
+}
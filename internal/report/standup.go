@@ -0,0 +1,259 @@
+// Package report assembles summaries of recent task activity.
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"term-workspaces/internal/github"
+	"term-workspaces/internal/tasks"
+	"time"
+)
+
+// StandupInput is everything a standup is built from. Notes are keyed by
+// task ID. PRStates holds what GitHub reported for the tasks' PRs; PRs
+// missing from it only show up when they were linked.
+type StandupInput struct {
+	Tasks    []tasks.Task
+	Aliases  []tasks.TaskAliasRow
+	Sessions []tasks.TaskSession
+	Events   []tasks.TaskEvent
+	Notes    map[string]tasks.ParsedNote
+	PRStates map[PRKey]github.PRState
+}
+
+// PRKey names a pull request.
+type PRKey struct {
+	Repo   string
+	Number int
+}
+
+// Standup is the activity between Since and Until grouped by repo.
+type Standup struct {
+	Since time.Time     `json:"since"`
+	Until time.Time     `json:"until"`
+	Repos []RepoStandup `json:"repos"`
+}
+
+type RepoStandup struct {
+	Repo  string        `json:"repo"`
+	Tasks []TaskStandup `json:"tasks"`
+}
+
+// TaskStandup is one task touched in the window.
+type TaskStandup struct {
+	TaskID       string          `json:"task_id"`
+	Branch       string          `json:"branch,omitempty"`
+	PRNumber     int             `json:"pr_number,omitempty"`
+	LastActivity time.Time       `json:"last_activity"`
+	PRsLinked    []int           `json:"prs_linked"`
+	PRChanges    []PRChange      `json:"pr_changes"`
+	Status       string          `json:"status"`
+	Blockers     []string        `json:"blockers"`
+	Sessions     []SessionChange `json:"sessions"`
+}
+
+// SessionChange is a session opening or closing.
+type SessionChange struct {
+	At     time.Time           `json:"at"`
+	Status tasks.SessionStatus `json:"status"`
+	Detail string              `json:"detail"`
+}
+
+// PRChange is a pull request being opened, merged or closed.
+type PRChange struct {
+	Number int       `json:"number"`
+	State  string    `json:"state"`
+	At     time.Time `json:"at"`
+}
+
+// noRepo groups tasks without any alias.
+const noRepo = "(no repo)"
+
+// BuildStandup collects the tasks touched between since and until: tasks
+// updated, sessions seen, PRs linked, opened, merged or closed, and sessions
+// opened or closed.
+func BuildStandup(input StandupInput, since, until time.Time) Standup {
+	aliasesByTask := make(map[string][]tasks.TaskAliasRow)
+	for _, alias := range input.Aliases {
+		aliasesByTask[alias.TaskID] = append(aliasesByTask[alias.TaskID], alias)
+	}
+	sessionsByTask := make(map[string]tasks.TaskSession, len(input.Sessions))
+	for _, session := range input.Sessions {
+		sessionsByTask[session.TaskID] = session
+	}
+	changesByTask := make(map[string][]SessionChange)
+	for _, event := range input.Events {
+		status := tasks.SessionStatus(event.To)
+		if event.Kind != tasks.EventKindSessionStatus || !inWindow(event.CreatedAt, since, until) {
+			continue
+		}
+		if status != tasks.SessionStatusOpen && status != tasks.SessionStatusClosed {
+			continue
+		}
+		changesByTask[event.TaskID] = append(changesByTask[event.TaskID], SessionChange{
+			At:     event.CreatedAt,
+			Status: status,
+			Detail: event.Detail,
+		})
+	}
+
+	byRepo := make(map[string][]TaskStandup)
+	for _, task := range input.Tasks {
+		entry := TaskStandup{
+			TaskID:    task.ID,
+			PRsLinked: []int{},
+			PRChanges: []PRChange{},
+			Blockers:  []string{},
+			Sessions:  changesByTask[task.ID],
+		}
+		if entry.Sessions == nil {
+			entry.Sessions = []SessionChange{}
+		}
+		touched := false
+		touch := func(at time.Time) {
+			if !inWindow(at, since, until) {
+				return
+			}
+			touched = true
+			if at.After(entry.LastActivity) {
+				entry.LastActivity = at
+			}
+		}
+		touch(task.UpdatedAt)
+		if session, ok := sessionsByTask[task.ID]; ok {
+			touch(session.LastSeenAt)
+		}
+		for _, change := range entry.Sessions {
+			touch(change.At)
+		}
+
+		repo := noRepo
+		for _, alias := range aliasesByTask[task.ID] {
+			repo = alias.Repo
+			if alias.Branch != "" {
+				entry.Branch = alias.Branch
+			}
+			if alias.AliasType != tasks.AliasTypePR {
+				continue
+			}
+			entry.PRNumber = alias.PRNumber
+			if inWindow(alias.CreatedAt, since, until) {
+				entry.PRsLinked = append(entry.PRsLinked, alias.PRNumber)
+				touch(alias.CreatedAt)
+			}
+			for _, change := range prChanges(alias.PRNumber, input.PRStates[PRKey{Repo: alias.Repo, Number: alias.PRNumber}]) {
+				if inWindow(change.At, since, until) {
+					entry.PRChanges = append(entry.PRChanges, change)
+					touch(change.At)
+				}
+			}
+		}
+		if !touched {
+			continue
+		}
+
+		if note, ok := input.Notes[task.ID]; ok {
+			entry.Status = note.Status
+			entry.Blockers = note.Summary().OpenBlockers
+		}
+		byRepo[repo] = append(byRepo[repo], entry)
+	}
+
+	standup := Standup{Since: since, Until: until, Repos: make([]RepoStandup, 0, len(byRepo))}
+	for repo, entries := range byRepo {
+		sort.Slice(entries, func(i, j int) bool {
+			if !entries[i].LastActivity.Equal(entries[j].LastActivity) {
+				return entries[i].LastActivity.After(entries[j].LastActivity)
+			}
+			return entries[i].TaskID < entries[j].TaskID
+		})
+		standup.Repos = append(standup.Repos, RepoStandup{Repo: repo, Tasks: entries})
+	}
+	sort.Slice(standup.Repos, func(i, j int) bool {
+		return standup.Repos[i].Repo < standup.Repos[j].Repo
+	})
+	return standup
+}
+
+// Markdown renders the standup as a Markdown document. Times are shown in
+// the location of Until.
+func (s Standup) Markdown() string {
+	loc := s.Until.Location()
+	var out strings.Builder
+	fmt.Fprintf(&out, "# Standup %s\n\n", s.Until.Format(time.DateOnly))
+	fmt.Fprintf(&out, "Activity since %s.\n", s.Since.In(loc).Format("2006-01-02 15:04 MST"))
+	if len(s.Repos) == 0 {
+		out.WriteString("\nNo task activity.\n")
+		return out.String()
+	}
+
+	for _, repo := range s.Repos {
+		fmt.Fprintf(&out, "\n## %s\n\n", repo.Repo)
+		for _, task := range repo.Tasks {
+			fmt.Fprintf(&out, "- **%s** (`%s`)\n", taskTitle(task), task.TaskID)
+			for _, number := range task.PRsLinked {
+				fmt.Fprintf(&out, "  - Linked PR #%d\n", number)
+			}
+			for _, change := range task.PRChanges {
+				fmt.Fprintf(&out, "  - PR #%d %s at %s\n", change.Number, change.State, change.At.In(loc).Format("2006-01-02 15:04"))
+			}
+			if status := oneLine(task.Status); status != "" {
+				fmt.Fprintf(&out, "  - Status: %s\n", status)
+			}
+			for _, blocker := range task.Blockers {
+				fmt.Fprintf(&out, "  - Blocked: %s\n", blocker)
+			}
+			for _, change := range task.Sessions {
+				detail := ""
+				if change.Detail != "" {
+					detail = " (" + change.Detail + ")"
+				}
+				fmt.Fprintf(&out, "  - Session %s%s at %s\n", sessionVerb(change.Status), detail, change.At.In(loc).Format("2006-01-02 15:04"))
+			}
+		}
+	}
+	return out.String()
+}
+
+// prChanges lists when the PR was opened and then merged or closed, oldest
+// first. A merged PR is also closed, so only the merge is reported, and a
+// reopened PR keeps its old close time, which is skipped.
+func prChanges(number int, state github.PRState) []PRChange {
+	changes := []PRChange{{Number: number, State: "opened", At: state.CreatedAt}}
+	switch state.State {
+	case "MERGED":
+		changes = append(changes, PRChange{Number: number, State: "merged", At: state.MergedAt})
+	case "CLOSED":
+		changes = append(changes, PRChange{Number: number, State: "closed", At: state.ClosedAt})
+	}
+	return changes
+}
+
+func taskTitle(task TaskStandup) string {
+	switch {
+	case task.PRNumber > 0 && task.Branch != "":
+		return fmt.Sprintf("#%d %s", task.PRNumber, task.Branch)
+	case task.PRNumber > 0:
+		return fmt.Sprintf("#%d", task.PRNumber)
+	case task.Branch != "":
+		return task.Branch
+	default:
+		return task.TaskID
+	}
+}
+
+func sessionVerb(status tasks.SessionStatus) string {
+	if status == tasks.SessionStatusOpen {
+		return "opened"
+	}
+	return "closed"
+}
+
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func inWindow(at, since, until time.Time) bool {
+	return !at.IsZero() && !at.Before(since) && !at.After(until)
+}
@@ -0,0 +1,84 @@
+package report
+
+import (
+	"term-workspaces/internal/github"
+	"term-workspaces/internal/tasks"
+	"testing"
+	"time"
+)
+
+func TestBuildStandupGroupsTouchedTasksByRepo(t *testing.T) {
+	t.Parallel()
+
+	until := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	since := until.Add(-24 * time.Hour)
+	stale := since.Add(-time.Hour)
+
+	input := StandupInput{
+		Tasks: []tasks.Task{
+			{ID: "task_pr", UpdatedAt: until.Add(-2 * time.Hour)},
+			{ID: "task_session", UpdatedAt: stale},
+			{ID: "task_stale", UpdatedAt: stale},
+			{ID: "task_other", UpdatedAt: until.Add(-time.Hour)},
+		},
+		Aliases: []tasks.TaskAliasRow{
+			{TaskID: "task_pr", AliasType: tasks.AliasTypePrePR, Repo: "owner/app", Branch: "feature/cache", CreatedAt: stale},
+			{TaskID: "task_pr", AliasType: tasks.AliasTypePR, Repo: "owner/app", PRNumber: 42, CreatedAt: until.Add(-2 * time.Hour)},
+			{TaskID: "task_session", AliasType: tasks.AliasTypePrePR, Repo: "owner/app", Branch: "feature/docs", CreatedAt: stale},
+			{TaskID: "task_stale", AliasType: tasks.AliasTypePrePR, Repo: "owner/app", Branch: "feature/old", CreatedAt: stale},
+			{TaskID: "task_other", AliasType: tasks.AliasTypePR, Repo: "owner/lib", PRNumber: 7, CreatedAt: stale},
+		},
+		Sessions: []tasks.TaskSession{
+			{TaskID: "task_session", LastSeenAt: until.Add(-30 * time.Minute)},
+			{TaskID: "task_stale", LastSeenAt: stale},
+		},
+		Events: []tasks.TaskEvent{
+			{TaskID: "task_session", Kind: tasks.EventKindSessionStatus, From: "", To: "open", Detail: "spawned", CreatedAt: until.Add(-5 * time.Hour)},
+			{TaskID: "task_session", Kind: tasks.EventKindSessionStatus, From: "open", To: "unknown", CreatedAt: until.Add(-4 * time.Hour)},
+			{TaskID: "task_session", Kind: tasks.EventKindSessionStatus, From: "unknown", To: "closed", Detail: "pane gone", CreatedAt: until.Add(-3 * time.Hour)},
+			{TaskID: "task_stale", Kind: tasks.EventKindSessionStatus, To: "closed", CreatedAt: stale},
+		},
+		Notes: map[string]tasks.ParsedNote{
+			"task_pr": tasks.ParseNote("## Status\nCache fix\nin review.\n\n## Blockers\n- waiting on CI\n"),
+		},
+		PRStates: map[PRKey]github.PRState{
+			{Repo: "owner/app", Number: 42}: {State: "OPEN", CreatedAt: until.Add(-3 * time.Hour), ClosedAt: stale},
+			{Repo: "owner/lib", Number: 7}:  {State: "MERGED", CreatedAt: stale, ClosedAt: until.Add(-90 * time.Minute), MergedAt: until.Add(-90 * time.Minute)},
+		},
+	}
+
+	standup := BuildStandup(input, since, until)
+	if len(standup.Repos) != 2 || standup.Repos[0].Repo != "owner/app" || standup.Repos[1].Repo != "owner/lib" {
+		t.Fatalf("unexpected repo groups: %#v", standup.Repos)
+	}
+	app := standup.Repos[0].Tasks
+	if len(app) != 2 || app[0].TaskID != "task_session" || app[1].TaskID != "task_pr" {
+		t.Fatalf("expected touched app tasks by latest activity, got %#v", app)
+	}
+	if len(app[0].Sessions) != 2 || app[1].PRNumber != 42 || len(app[1].PRsLinked) != 1 {
+		t.Fatalf("unexpected task entries: %#v", app)
+	}
+
+	expected := "# Standup 2026-03-02\n\n" +
+		"Activity since 2026-03-01 09:00 UTC.\n\n" +
+		"## owner/app\n\n" +
+		"- **feature/docs** (`task_session`)\n" +
+		"  - Session opened (spawned) at 2026-03-02 04:00\n" +
+		"  - Session closed (pane gone) at 2026-03-02 06:00\n" +
+		"- **#42 feature/cache** (`task_pr`)\n" +
+		"  - Linked PR #42\n" +
+		"  - PR #42 opened at 2026-03-02 06:00\n" +
+		"  - Status: Cache fix in review.\n" +
+		"  - Blocked: waiting on CI\n\n" +
+		"## owner/lib\n\n" +
+		"- **#7** (`task_other`)\n" +
+		"  - PR #7 merged at 2026-03-02 07:30\n"
+	if got := standup.Markdown(); got != expected {
+		t.Fatalf("unexpected markdown:\n%s", got)
+	}
+
+	empty := BuildStandup(StandupInput{}, since, until)
+	if got := empty.Markdown(); got != "# Standup 2026-03-02\n\nActivity since 2026-03-01 09:00 UTC.\n\nNo task activity.\n" {
+		t.Fatalf("unexpected empty markdown:\n%s", got)
+	}
+}
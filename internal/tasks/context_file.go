@@ -0,0 +1,117 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// The generated block in a context file sits between these markers, so the
+// rest of a hand-written file survives a refresh.
+const (
+	contextBeginMarker = "<!-- ttt:task-context:begin -->"
+	contextEndMarker   = "<!-- ttt:task-context:end -->"
+)
+
+// ErrForeignContextFile reports a context file that ttt did not write: it
+// has content but no generated block.
+var ErrForeignContextFile = errors.New("context file has no ttt task-context block")
+
+// contextSections are the note sections copied into a context file.
+var contextSections = []string{
+	NoteSectionObjective,
+	NoteSectionStatus,
+	NoteSectionNextActions,
+	NoteSectionBlockers,
+}
+
+// TaskContext is what a context file in a task's working directory tells
+// the agents running there.
+type TaskContext struct {
+	TaskID   string
+	Aliases  []TaskAliasRow
+	NotePath string
+	// Sections are the task note's sections keyed by heading.
+	Sections map[string]string
+}
+
+// PRURL is the GitHub web URL of a pull request.
+func PRURL(repo string, prNumber int) string {
+	return fmt.Sprintf("https://github.com/%s/pull/%d", NormalizeRepo(repo), prNumber)
+}
+
+// RenderTaskContext renders the marked context block for a task.
+func RenderTaskContext(task TaskContext) string {
+	var block strings.Builder
+	block.WriteString(contextBeginMarker + "\n")
+	block.WriteString("## Task Context\n\n")
+	block.WriteString("Generated by `ttt task context sync` from the task note; edits inside this block are overwritten.\n\n")
+	fmt.Fprintf(&block, "- Task: `%s`\n", task.TaskID)
+
+	aliases := make([]string, 0, len(task.Aliases))
+	branch := ""
+	for _, alias := range task.Aliases {
+		aliases = append(aliases, "`"+alias.AliasValue+"`")
+		if branch == "" {
+			branch = alias.Branch
+		}
+	}
+	if len(aliases) > 0 {
+		fmt.Fprintf(&block, "- Aliases: %s\n", strings.Join(aliases, ", "))
+	}
+	if branch != "" {
+		fmt.Fprintf(&block, "- Branch: `%s`\n", branch)
+	}
+	for _, alias := range task.Aliases {
+		if alias.AliasType == AliasTypePR && alias.PRNumber > 0 {
+			fmt.Fprintf(&block, "- PR: %s\n", PRURL(alias.Repo, alias.PRNumber))
+		}
+	}
+	if task.NotePath != "" {
+		fmt.Fprintf(&block, "- Note: %s (keep it up to date as you work)\n", task.NotePath)
+	}
+
+	for _, heading := range contextSections {
+		body := task.Sections[heading]
+		if body == "" {
+			continue
+		}
+		fmt.Fprintf(&block, "\n### %s\n\n%s\n", heading, body)
+	}
+	block.WriteString(contextEndMarker + "\n")
+	return block.String()
+}
+
+// WriteContextFile puts block into the file at path: it replaces a
+// previously generated block or becomes the whole of a missing or empty
+// file. Any other file is left alone and ErrForeignContextFile returned. It
+// reports whether the file changed.
+func WriteContextFile(path, block string) (bool, error) {
+	// #nosec G304 -- the context file path is the session cwd plus the configured name.
+	raw, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("read context file: %w", err)
+	}
+	existing := string(raw)
+
+	updated := block
+	begin := strings.Index(existing, contextBeginMarker)
+	end := strings.Index(existing, contextEndMarker)
+	switch {
+	case begin >= 0 && end > begin:
+		rest := strings.TrimPrefix(existing[end+len(contextEndMarker):], "\n")
+		updated = existing[:begin] + block + rest
+	case strings.TrimSpace(existing) != "":
+		return false, fmt.Errorf("%s: %w", path, ErrForeignContextFile)
+	}
+	if updated == existing {
+		return false, nil
+	}
+
+	// #nosec G306 -- context files sit in the work tree next to source files.
+	if err := os.WriteFile(path, []byte(updated), 0o644); err != nil {
+		return false, fmt.Errorf("write context file: %w", err)
+	}
+	return true, nil
+}
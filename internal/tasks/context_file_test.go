@@ -0,0 +1,101 @@
+package tasks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderTaskContextIncludesAliasesPRAndSections(t *testing.T) {
+	t.Parallel()
+
+	block := RenderTaskContext(TaskContext{
+		TaskID: "task_1",
+		Aliases: []TaskAliasRow{
+			{AliasType: AliasTypePR, AliasValue: "pr:owner/repo#12", Repo: "owner/repo", PRNumber: 12},
+			{AliasType: AliasTypePrePR, AliasValue: "prepr:owner/repo:feature/x", Repo: "owner/repo", Branch: "feature/x"},
+		},
+		NotePath: "/notes/task_1.md",
+		Sections: map[string]string{
+			NoteSectionObjective:      "Ship it.",
+			NoteSectionBlockers:       "",
+			NoteSectionSessionContext: "private log",
+		},
+	})
+	for _, want := range []string{
+		contextBeginMarker,
+		"- Task: `task_1`",
+		"- Aliases: `pr:owner/repo#12`, `prepr:owner/repo:feature/x`",
+		"- Branch: `feature/x`",
+		"- PR: https://github.com/owner/repo/pull/12",
+		"- Note: /notes/task_1.md",
+		"### Current Objective\n\nShip it.\n",
+		contextEndMarker,
+	} {
+		if !strings.Contains(block, want) {
+			t.Fatalf("expected block to contain %q:\n%s", want, block)
+		}
+	}
+	if strings.Contains(block, "Blockers") || strings.Contains(block, "private log") {
+		t.Fatalf("expected empty and session sections to be left out:\n%s", block)
+	}
+}
+
+func TestWriteContextFileReplacesOnlyGeneratedBlock(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "AGENTS.md")
+	first := RenderTaskContext(TaskContext{TaskID: "task_1"})
+	if err := os.WriteFile(path, []byte("# House rules\n\nRun the tests.\n\n"+first+"\nKeep this.\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if changed, err := WriteContextFile(path, first); err != nil || changed {
+		t.Fatalf("repeat write changed=%v err=%v", changed, err)
+	}
+
+	second := RenderTaskContext(TaskContext{TaskID: "task_2"})
+	if changed, err := WriteContextFile(path, second); err != nil || !changed {
+		t.Fatalf("refresh changed=%v err=%v", changed, err)
+	}
+	// #nosec G304 -- path is inside t.TempDir.
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	expected := "# House rules\n\nRun the tests.\n\n" + second + "\nKeep this.\n"
+	if string(content) != expected {
+		t.Fatalf("unexpected file:\n%s", content)
+	}
+}
+
+func TestWriteContextFileLeavesForeignFileAlone(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "AGENTS.md")
+	original := "# House rules\n\nRun the tests.\n"
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	changed, err := WriteContextFile(path, RenderTaskContext(TaskContext{TaskID: "task_1"}))
+	if !errors.Is(err, ErrForeignContextFile) || changed {
+		t.Fatalf("expected ErrForeignContextFile, got changed=%v err=%v", changed, err)
+	}
+	// #nosec G304 -- path is inside t.TempDir.
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(content) != original {
+		t.Fatalf("expected file to be untouched:\n%s", content)
+	}
+
+	if err := os.WriteFile(path, []byte("\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if changed, err := WriteContextFile(path, RenderTaskContext(TaskContext{TaskID: "task_1"})); err != nil || !changed {
+		t.Fatalf("empty file write changed=%v err=%v", changed, err)
+	}
+}
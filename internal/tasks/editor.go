@@ -1,14 +1,137 @@
 package tasks
 
-import "strings"
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
 
-func ResolveEditorCommand(editorEnv, notePath string) (string, []string) {
-	fields := strings.Fields(strings.TrimSpace(editorEnv))
-	if len(fields) == 0 {
-		return "open", []string{"-e", notePath}
+// EditorSources are the places an editor command can come from, in order
+// of precedence. GOOS picks the fallback opener when none is set.
+type EditorSources struct {
+	Visual     string
+	Editor     string
+	Configured string
+	GOOS       string
+}
+
+// Command returns the editor command ResolveEditorCommand picks, or "" when
+// it falls back to the platform opener.
+func (s EditorSources) Command() string {
+	for _, candidate := range []string{s.Visual, s.Editor, s.Configured} {
+		if words, err := SplitShellWords(candidate); err != nil || len(words) > 0 {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// ResolveEditorCommand returns the program and arguments that open
+// notePath, preferring $VISUAL, then $EDITOR, then the configured editor,
+// then the platform opener. A line above zero is jumped to when the editor
+// is known to support it.
+func ResolveEditorCommand(sources EditorSources, notePath string, line int) (string, []string, error) {
+	for _, candidate := range []string{sources.Visual, sources.Editor, sources.Configured} {
+		words, err := SplitShellWords(candidate)
+		if err != nil {
+			return "", nil, fmt.Errorf("parse editor command %q: %w", candidate, err)
+		}
+		if len(words) == 0 {
+			continue
+		}
+		args := append(words[1:], editorFileArgs(words[0], notePath, line)...)
+		return words[0], args, nil
+	}
+
+	// Platform openers hand the file to the desktop and cannot jump to a
+	// line.
+	if sources.GOOS == "darwin" {
+		return "open", []string{"-t", notePath}, nil
+	}
+	return "xdg-open", []string{notePath}, nil
+}
+
+// editorFileArgs returns the arguments that open path at line in the named
+// editor. Unknown editors just get the path.
+func editorFileArgs(editor, path string, line int) []string {
+	if line <= 0 {
+		return []string{path}
 	}
+	switch filepath.Base(editor) {
+	case "vi", "vim", "nvim", "gvim", "mvim", "nano", "emacs", "emacsclient", "micro", "kak":
+		return []string{"+" + strconv.Itoa(line), path}
+	case "code", "code-insiders", "codium", "cursor":
+		return []string{"--goto", path + ":" + strconv.Itoa(line)}
+	case "subl", "zed", "hx", "helix":
+		return []string{path + ":" + strconv.Itoa(line)}
+	default:
+		return []string{path}
+	}
+}
 
-	name := fields[0]
-	args := append(fields[1:], notePath)
-	return name, args
+// SplitShellWords splits s into words the way a POSIX shell does, honouring
+// single quotes, double quotes and backslash escapes. Expansions such as $VAR
+// are left as literal text.
+func SplitShellWords(s string) ([]string, error) {
+	words := make([]string, 0)
+	var (
+		word    strings.Builder
+		inWord  bool
+		escaped bool
+		quote   rune
+	)
+	for _, r := range s {
+		switch {
+		case escaped:
+			// Inside double quotes a backslash only escapes these characters.
+			if quote == '"' && !strings.ContainsRune("$`\"\\\n", r) {
+				word.WriteRune('\\')
+			}
+			if r != '\n' {
+				word.WriteRune(r)
+			}
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			switch r {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			default:
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if escaped && quote == 0 {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
 }
@@ -1,19 +1,26 @@
 package tasks
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestResolveEditorCommandFallback(t *testing.T) {
-	name, args := ResolveEditorCommand("", "/tmp/task.md")
-	if name != "open" {
-		t.Fatalf("expected fallback editor command 'open', got %q", name)
+	name, args, err := ResolveEditorCommand(EditorSources{GOOS: "darwin"}, "/tmp/task.md", 4)
+	if err != nil || name != "open" || !reflect.DeepEqual(args, []string{"-t", "/tmp/task.md"}) {
+		t.Fatalf("unexpected darwin fallback: %q %#v %v", name, args, err)
 	}
-	if len(args) != 2 || args[0] != "-e" || args[1] != "/tmp/task.md" {
-		t.Fatalf("unexpected fallback args: %#v", args)
+	name, args, err = ResolveEditorCommand(EditorSources{GOOS: "linux"}, "/tmp/task.md", 4)
+	if err != nil || name != "xdg-open" || !reflect.DeepEqual(args, []string{"/tmp/task.md"}) {
+		t.Fatalf("unexpected linux fallback: %q %#v %v", name, args, err)
 	}
 }
 
 func TestResolveEditorCommandFromEnv(t *testing.T) {
-	name, args := ResolveEditorCommand("nvim -u NONE", "/tmp/task.md")
+	name, args, err := ResolveEditorCommand(EditorSources{Editor: "nvim -u NONE"}, "/tmp/task.md", 0)
+	if err != nil {
+		t.Fatalf("ResolveEditorCommand: %v", err)
+	}
 	if name != "nvim" {
 		t.Fatalf("expected editor command 'nvim', got %q", name)
 	}
@@ -21,3 +28,65 @@ func TestResolveEditorCommandFromEnv(t *testing.T) {
 		t.Fatalf("unexpected editor args: %#v", args)
 	}
 }
+
+func TestResolveEditorCommandPrecedenceAndLineJumps(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		sources EditorSources
+		name    string
+		args    []string
+	}{
+		{EditorSources{Visual: "code --wait", Editor: "vim", Configured: "nano"}, "code", []string{"--wait", "--goto", "/n.md:7"}},
+		{EditorSources{Editor: "vim", Configured: "nano"}, "vim", []string{"+7", "/n.md"}},
+		{EditorSources{Configured: "nano"}, "nano", []string{"+7", "/n.md"}},
+		{EditorSources{Editor: `"/Applications/My Editor/bin/vim" -n`}, "/Applications/My Editor/bin/vim", []string{"-n", "+7", "/n.md"}},
+		{EditorSources{Editor: "subl -w"}, "subl", []string{"-w", "/n.md:7"}},
+		{EditorSources{Editor: "ed"}, "ed", []string{"/n.md"}},
+	}
+	for _, tc := range cases {
+		name, args, err := ResolveEditorCommand(tc.sources, "/n.md", 7)
+		if err != nil || name != tc.name || !reflect.DeepEqual(args, tc.args) {
+			t.Fatalf("ResolveEditorCommand(%#v) = %q %#v %v; want %q %#v", tc.sources, name, args, err, tc.name, tc.args)
+		}
+	}
+
+	if _, _, err := ResolveEditorCommand(EditorSources{Editor: `vim "unterminated`}, "/n.md", 0); err == nil {
+		t.Fatalf("expected unterminated quote error")
+	}
+}
+
+func TestSplitShellWords(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string][]string{
+		"":                                 {},
+		"  vim  ":                          {"vim"},
+		`code --wait`:                      {"code", "--wait"},
+		`'/opt/my editor/bin/ed' -s`:       {"/opt/my editor/bin/ed", "-s"},
+		`"/opt/my editor/ed" "a \"b\" \x"`: {"/opt/my editor/ed", `a "b" \x`},
+		`/opt/my\ editor/ed ''`:            {"/opt/my editor/ed", ""},
+		`a"b c"'d'`:                        {"ab cd"},
+		`$HOME/bin/ed`:                     {"$HOME/bin/ed"},
+	}
+	for input, expected := range cases {
+		got, err := SplitShellWords(input)
+		if err != nil || !reflect.DeepEqual(got, expected) {
+			t.Fatalf("SplitShellWords(%q) = %#v, %v; want %#v", input, got, err, expected)
+		}
+	}
+	for _, input := range []string{`'open`, `"open`, `trailing\`} {
+		if _, err := SplitShellWords(input); err == nil {
+			t.Fatalf("expected error for %q", input)
+		}
+	}
+}
+
+func TestEditorSourcesCommand(t *testing.T) {
+	if got := (EditorSources{Visual: " ", Editor: "nvim -u NONE", Configured: "code"}).Command(); got != "nvim -u NONE" {
+		t.Fatalf("Command = %q, want $EDITOR", got)
+	}
+	if got := (EditorSources{Visual: "\t", GOOS: "darwin"}).Command(); got != "" {
+		t.Fatalf("expected the platform opener to have no command, got %q", got)
+	}
+}
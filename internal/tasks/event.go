@@ -0,0 +1,22 @@
+package tasks
+
+import "time"
+
+type EventKind string
+
+const (
+	// EventKindSessionStatus records a session moving between open, closed
+	// and unknown; From and To hold the SessionStatus values.
+	EventKindSessionStatus EventKind = "session_status"
+)
+
+// TaskEvent is an append-only record of a change observed on a task.
+type TaskEvent struct {
+	ID        int64     `json:"id"`
+	TaskID    string    `json:"task_id"`
+	Kind      EventKind `json:"kind"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Detail    string    `json:"detail"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,381 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const frontMatterDelimiter = "---"
+
+// NoteMetadata is the task metadata kept in a note's YAML front matter.
+// Due is a YYYY-MM-DD date.
+type NoteMetadata struct {
+	Title    string   `json:"title"`
+	Tags     []string `json:"tags"`
+	Priority string   `json:"priority"`
+	Status   string   `json:"status"`
+	Due      string   `json:"due"`
+}
+
+// Equal reports whether both hold the same values.
+func (m NoteMetadata) Equal(other NoteMetadata) bool {
+	return m.Title == other.Title && m.Priority == other.Priority && m.Status == other.Status &&
+		m.Due == other.Due && slices.Equal(m.Tags, other.Tags)
+}
+
+// Validate checks the due date.
+func (m NoteMetadata) Validate() error {
+	if m.Due == "" {
+		return nil
+	}
+	if _, err := time.Parse(time.DateOnly, m.Due); err != nil {
+		return fmt.Errorf("due must be a YYYY-MM-DD date: %q", m.Due)
+	}
+	return nil
+}
+
+// frontMatter locates a front matter block: the lines between an opening
+// "---" on the first line and the next "---" or "..." line.
+type frontMatter struct {
+	lines []string
+	// body is everything after the closing delimiter line, untouched.
+	body string
+}
+
+func splitFrontMatter(content string) (frontMatter, bool) {
+	first, rest, ok := strings.Cut(content, "\n")
+	if !ok || strings.TrimRight(first, " \t\r") != frontMatterDelimiter {
+		return frontMatter{}, false
+	}
+	lines := make([]string, 0)
+	for rest != "" {
+		line, next, _ := strings.Cut(rest, "\n")
+		trimmed := strings.TrimRight(line, " \t\r")
+		if trimmed == frontMatterDelimiter || trimmed == "..." {
+			return frontMatter{lines: lines, body: next}, true
+		}
+		lines = append(lines, line)
+		rest = next
+	}
+	return frontMatter{}, false
+}
+
+// frontMatterKey returns the top-level key a line starts, if any.
+func frontMatterKey(line string) (string, string, bool) {
+	if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '#' || line[0] == '-' {
+		return "", "", false
+	}
+	key, value, ok := strings.Cut(line, ":")
+	if !ok || strings.ContainsAny(key, " \t") {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(value), true
+}
+
+// ParseNoteMetadata reads the note's front matter. ok is false when the
+// note has none. Keys other than title, tags, priority, status and due are
+// ignored.
+func ParseNoteMetadata(content string) (NoteMetadata, bool, error) {
+	block, ok := splitFrontMatter(content)
+	if !ok {
+		return NoteMetadata{}, false, nil
+	}
+
+	var meta NoteMetadata
+	for i := 0; i < len(block.lines); i++ {
+		key, raw, ok := frontMatterKey(block.lines[i])
+		if !ok {
+			continue
+		}
+		value, err := parseYAMLScalar(raw)
+		if err != nil {
+			return NoteMetadata{}, true, fmt.Errorf("%w: %s: %w", ErrInvalidFrontMatter, key, err)
+		}
+		switch key {
+		case "title":
+			meta.Title = value
+		case "priority":
+			meta.Priority = value
+		case "status":
+			meta.Status = value
+		case "due":
+			meta.Due = value
+		case "tags":
+			if strings.HasPrefix(raw, "[") {
+				if meta.Tags, err = parseYAMLFlowList(raw); err != nil {
+					return NoteMetadata{}, true, fmt.Errorf("%w: tags: %w", ErrInvalidFrontMatter, err)
+				}
+				continue
+			}
+			meta.Tags = nil
+			if value != "" {
+				meta.Tags = []string{value}
+			}
+			// A block list follows on indented "- item" lines.
+			for i+1 < len(block.lines) {
+				item, ok := strings.CutPrefix(strings.TrimSpace(block.lines[i+1]), "- ")
+				if !ok {
+					break
+				}
+				tag, err := parseYAMLScalar(strings.TrimSpace(item))
+				if err != nil {
+					return NoteMetadata{}, true, fmt.Errorf("%w: tags: %w", ErrInvalidFrontMatter, err)
+				}
+				meta.Tags = append(meta.Tags, tag)
+				i++
+			}
+		}
+	}
+	if err := meta.Validate(); err != nil {
+		return NoteMetadata{}, true, fmt.Errorf("%w: %w", ErrInvalidFrontMatter, err)
+	}
+	return meta, true, nil
+}
+
+// SetNoteMetadata writes meta into the note's front matter and returns the
+// new content. Only the lines of known keys change: other keys, comments and
+// the Markdown body are kept byte for byte. Empty values remove their key; a
+// block left empty is removed.
+func SetNoteMetadata(content string, meta NoteMetadata) string {
+	block, ok := splitFrontMatter(content)
+	if !ok {
+		block = frontMatter{body: content}
+	}
+
+	values := map[string]string{
+		"title":    yamlScalar(meta.Title),
+		"tags":     yamlFlowList(meta.Tags),
+		"priority": yamlScalar(meta.Priority),
+		"status":   yamlScalar(meta.Status),
+		"due":      yamlScalar(meta.Due),
+	}
+	order := []string{"title", "tags", "priority", "status", "due"}
+	written := map[string]bool{}
+
+	lines := make([]string, 0, len(block.lines)+len(order))
+	for i := 0; i < len(block.lines); i++ {
+		key, _, ok := frontMatterKey(block.lines[i])
+		value, known := values[key]
+		if !ok || !known {
+			lines = append(lines, block.lines[i])
+			continue
+		}
+		// Skip the old value's continuation lines (a block list).
+		for i+1 < len(block.lines) && strings.HasPrefix(strings.TrimSpace(block.lines[i+1]), "- ") {
+			i++
+		}
+		if value != "" && !written[key] {
+			lines = append(lines, key+": "+value)
+		}
+		written[key] = true
+	}
+	for _, key := range order {
+		if !written[key] && values[key] != "" {
+			lines = append(lines, key+": "+values[key])
+		}
+	}
+
+	if len(lines) == 0 {
+		return block.body
+	}
+	return frontMatterDelimiter + "\n" + strings.Join(lines, "\n") + "\n" + frontMatterDelimiter + "\n" + block.body
+}
+
+// parseYAMLScalar reads a plain, single- or double-quoted scalar.
+func parseYAMLScalar(raw string) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		end := closingDoubleQuote(raw)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated double-quoted value %s", raw)
+		}
+		value, err := unescapeYAMLDoubleQuoted(raw[1:end])
+		if err != nil {
+			return "", fmt.Errorf("invalid double-quoted value %s: %w", raw, err)
+		}
+		return value, nil
+	case strings.HasPrefix(raw, "'"):
+		var value strings.Builder
+		for i := 1; i < len(raw); i++ {
+			if raw[i] != '\'' {
+				value.WriteByte(raw[i])
+				continue
+			}
+			if i+1 < len(raw) && raw[i+1] == '\'' {
+				value.WriteByte('\'')
+				i++
+				continue
+			}
+			return value.String(), nil
+		}
+		return "", fmt.Errorf("unterminated single-quoted value %s", raw)
+	default:
+		if comment := strings.Index(raw, " #"); comment >= 0 {
+			raw = raw[:comment]
+		}
+		if strings.HasPrefix(raw, "#") {
+			return "", nil
+		}
+		return strings.TrimSpace(raw), nil
+	}
+}
+
+// yamlEscapes maps YAML's single-character double-quoted escapes to the
+// text they stand for.
+var yamlEscapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n",
+	'v': "\v", 'f': "\f", 'r': "\r", 'e': "\x1b", ' ': " ", '"': `"`,
+	'/': "/", '\\': `\`, 'N': "\u0085", '_': "\u00a0", 'L': "\u2028", 'P': "\u2029",
+}
+
+// yamlHexEscapes gives the digit count of YAML's \x, \u and \U escapes.
+var yamlHexEscapes = map[byte]int{'x': 2, 'u': 4, 'U': 8}
+
+// unescapeYAMLDoubleQuoted decodes the body of a single-line double-quoted
+// YAML scalar. YAML's escape set differs from Go's: it adds \e, \/, \N and
+// others, and has no octal escapes.
+func unescapeYAMLDoubleQuoted(body string) (string, error) {
+	var value strings.Builder
+	for i := 0; i < len(body); i++ {
+		if body[i] != '\\' {
+			value.WriteByte(body[i])
+			continue
+		}
+		if i+1 >= len(body) {
+			return "", fmt.Errorf("trailing backslash")
+		}
+		i++
+		if text, ok := yamlEscapes[body[i]]; ok {
+			value.WriteString(text)
+			continue
+		}
+		digits, ok := yamlHexEscapes[body[i]]
+		if !ok {
+			return "", fmt.Errorf("unknown escape \\%c", body[i])
+		}
+		if i+digits >= len(body) {
+			return "", fmt.Errorf("short \\%c escape", body[i])
+		}
+		code, err := strconv.ParseUint(body[i+1:i+1+digits], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return "", fmt.Errorf("invalid \\%c escape", body[i])
+		}
+		value.WriteRune(rune(code))
+		i += digits
+	}
+	return value.String(), nil
+}
+
+func closingDoubleQuote(raw string) int {
+	for i := 1; i < len(raw); i++ {
+		switch raw[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// parseYAMLFlowList reads a single-line "[a, 'b', "c"]" list.
+func parseYAMLFlowList(raw string) ([]string, error) {
+	end := strings.LastIndex(raw, "]")
+	if end < 0 {
+		return nil, fmt.Errorf("unterminated list %s", raw)
+	}
+
+	// Split on commas outside quotes, then read each item as a scalar.
+	pieces := make([]string, 0)
+	var (
+		piece strings.Builder
+		quote byte
+	)
+	inner := raw[1:end]
+	for i := 0; i < len(inner); i++ {
+		c := inner[i]
+		switch {
+		case quote == '"' && c == '\\' && i+1 < len(inner):
+			piece.WriteByte(c)
+			i++
+			c = inner[i]
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == ',':
+			pieces = append(pieces, piece.String())
+			piece.Reset()
+			continue
+		}
+		piece.WriteByte(c)
+	}
+	pieces = append(pieces, piece.String())
+
+	items := make([]string, 0, len(pieces))
+	for _, piece := range pieces {
+		item, err := parseYAMLScalar(strings.TrimSpace(piece))
+		if err != nil {
+			return nil, err
+		}
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// yamlScalar quotes value when a plain scalar would read back differently.
+func yamlScalar(value string) string {
+	if value == "" {
+		return ""
+	}
+	if strings.TrimSpace(value) != value || strings.ContainsAny(value, "#:\"'[]{},&*!|>%@`\n") ||
+		strings.HasPrefix(value, "- ") || strings.HasPrefix(value, "?") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+func yamlFlowList(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	items := make([]string, 0, len(values))
+	for _, value := range values {
+		items = append(items, yamlScalar(value))
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// SyncNoteMetadata copies the front matter of the task's note content into
+// the task's metadata columns. A note without front matter leaves them as
+// they are. It reports whether the task changed.
+func (s *SQLiteStore) SyncNoteMetadata(ctx context.Context, taskID, content string, at time.Time) (bool, error) {
+	meta, ok, err := ParseNoteMetadata(content)
+	if err != nil {
+		return false, fmt.Errorf("note %s: %w", taskID, err)
+	}
+	if !ok {
+		return false, nil
+	}
+	task, found, err := s.GetTask(ctx, taskID)
+	if err != nil {
+		return false, err
+	}
+	if !found {
+		return false, ErrTaskNotFound
+	}
+	if task.Metadata.Equal(meta) {
+		return false, nil
+	}
+	if err := s.UpdateTaskMetadata(ctx, taskID, meta, at); err != nil {
+		return false, err
+	}
+	return true, nil
+}
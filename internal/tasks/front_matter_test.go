@@ -0,0 +1,139 @@
+package tasks
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const frontMatterNote = `---
+title: "Cache: flaky test"
+tags:
+  - ci
+  - 'cache'
+priority: high # triage said so
+owner: me
+due: 2026-03-10
+---
+# Task State
+
+## Status
+--- not front matter ---
+`
+
+func TestParseNoteMetadata(t *testing.T) {
+	t.Parallel()
+
+	meta, ok, err := ParseNoteMetadata(frontMatterNote)
+	if err != nil || !ok {
+		t.Fatalf("ParseNoteMetadata ok=%v err=%v", ok, err)
+	}
+	expected := NoteMetadata{Title: "Cache: flaky test", Tags: []string{"ci", "cache"}, Priority: "high", Due: "2026-03-10"}
+	if !reflect.DeepEqual(meta, expected) {
+		t.Fatalf("unexpected metadata: %#v", meta)
+	}
+
+	meta, _, err = ParseNoteMetadata("---\ntags: [a, \"b, c\", 'd']\nstatus: blocked\n---\n")
+	if err != nil || !reflect.DeepEqual(meta.Tags, []string{"a", "b, c", "d"}) || meta.Status != "blocked" {
+		t.Fatalf("unexpected flow list metadata: %#v err=%v", meta, err)
+	}
+
+	// Double-quoted values use YAML's escapes, not Go's.
+	meta, _, err = ParseNoteMetadata("---\ntitle: \"a\\/b\\_c\\ d\\e\\N\\x41\\u00e9\\t\\\"\"\n---\n")
+	if err != nil || meta.Title != "a/b\u00a0c d\x1b\u0085A\u00e9\t\"" {
+		t.Fatalf("unexpected escaped title %q err=%v", meta.Title, err)
+	}
+
+	if _, ok, err := ParseNoteMetadata("# Task State\n---\ntitle: x\n---\n"); ok || err != nil {
+		t.Fatalf("expected no front matter, ok=%v err=%v", ok, err)
+	}
+	if _, ok, err := ParseNoteMetadata("---\ntitle: never closed\n"); ok || err != nil {
+		t.Fatalf("expected unterminated block to be ignored, ok=%v err=%v", ok, err)
+	}
+	for _, content := range []string{"---\ndue: next week\n---\n", "---\ntitle: \"open\n---\n", "---\ntags: [a, b\n---\n", "---\ntitle: \"\\q\"\n---\n", "---\ntitle: \"\\x4\"\n---\n"} {
+		if _, _, err := ParseNoteMetadata(content); !errors.Is(err, ErrInvalidFrontMatter) {
+			t.Fatalf("expected ErrInvalidFrontMatter for %q, got %v", content, err)
+		}
+	}
+}
+
+func TestSetNoteMetadataKeepsBodyAndUnknownKeys(t *testing.T) {
+	t.Parallel()
+
+	meta := NoteMetadata{Title: "Cache fix", Tags: []string{"ci"}, Status: "in review"}
+	updated := SetNoteMetadata(frontMatterNote, meta)
+	expected := `---
+title: Cache fix
+tags: [ci]
+owner: me
+status: in review
+---
+# Task State
+
+## Status
+--- not front matter ---
+`
+	if updated != expected {
+		t.Fatalf("unexpected note:\n%s", updated)
+	}
+	if got, _, err := ParseNoteMetadata(updated); err != nil || !got.Equal(meta) {
+		t.Fatalf("round trip = %#v err=%v", got, err)
+	}
+
+	body := "# Task State\n\n## Status\n"
+	added := SetNoteMetadata(body, NoteMetadata{Title: "#1: x", Priority: "low"})
+	if added != "---\ntitle: \"#1: x\"\npriority: low\n---\n"+body {
+		t.Fatalf("unexpected new front matter:\n%s", added)
+	}
+	if removed := SetNoteMetadata(added, NoteMetadata{}); removed != body {
+		t.Fatalf("expected empty metadata to remove the block:\n%s", removed)
+	}
+}
+
+func TestSQLiteStoreSyncsNoteMetadata(t *testing.T) {
+	t.Parallel()
+
+	h := newSQLiteTestHarness(t)
+	task, _, err := h.Service.GetOrCreatePrePRTask(h.Ctx, "owner/repo", "feature/meta")
+	if err != nil {
+		t.Fatalf("GetOrCreatePrePRTask: %v", err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(NotePath(dir, task.ID), []byte(frontMatterNote), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := os.WriteFile(NotePath(dir, "task_stray"), []byte("---\ntitle: stray\n---\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if _, err := h.Store.RefreshNoteIndex(h.Ctx, dir); err != nil {
+		t.Fatalf("RefreshNoteIndex: %v", err)
+	}
+	got, found, err := h.Store.GetTask(h.Ctx, task.ID)
+	if err != nil || !found {
+		t.Fatalf("GetTask found=%v err=%v", found, err)
+	}
+	if got.Metadata.Title != "Cache: flaky test" || !reflect.DeepEqual(got.Metadata.Tags, []string{"ci", "cache"}) || got.Metadata.Due != "2026-03-10" {
+		t.Fatalf("unexpected synced metadata: %#v", got.Metadata)
+	}
+
+	at := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	changed, err := h.Store.SyncNoteMetadata(h.Ctx, task.ID, frontMatterNote, at)
+	if err != nil || changed {
+		t.Fatalf("expected unchanged sync, changed=%v err=%v", changed, err)
+	}
+	changed, err = h.Store.SyncNoteMetadata(h.Ctx, task.ID, "# no front matter\n", at)
+	if err != nil || changed {
+		t.Fatalf("expected a note without front matter to keep metadata, changed=%v err=%v", changed, err)
+	}
+	changed, err = h.Store.SyncNoteMetadata(h.Ctx, task.ID, "---\nstatus: done\n---\n", at)
+	if err != nil || !changed {
+		t.Fatalf("expected changed sync, changed=%v err=%v", changed, err)
+	}
+	got, _, _ = h.Store.GetTask(h.Ctx, task.ID)
+	if !got.Metadata.Equal(NoteMetadata{Status: "done", Tags: []string{}}) || !got.UpdatedAt.Equal(at) {
+		t.Fatalf("unexpected metadata after sync: %#v updated_at=%s", got.Metadata, got.UpdatedAt)
+	}
+}
@@ -17,6 +17,8 @@ type Task struct {
 	ID        string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// Metadata mirrors the front matter of the task note.
+	Metadata NoteMetadata
 }
 
 type TaskAlias struct {
@@ -0,0 +1,137 @@
+package tasks
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContextLines is how many unchanged lines surround each hunk.
+const diffContextLines = 3
+
+// noNewlineMarker follows a last line that has no newline, as in diff -u.
+const noNewlineMarker = "\\ No newline at end of file\n"
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	text string
+}
+
+// UnifiedDiff returns a unified diff turning from into to, or "" when they
+// are equal. Notes are small, so a plain LCS table is fast enough.
+func UnifiedDiff(fromName, toName, from, to string) string {
+	ops := diffLines(splitLines(from), splitLines(to))
+
+	changes := make([]int, 0)
+	for i, op := range ops {
+		if op.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	// fromBefore[i] and toBefore[i] count the lines of each side in ops[:i].
+	fromBefore := make([]int, len(ops)+1)
+	toBefore := make([]int, len(ops)+1)
+	for i, op := range ops {
+		fromBefore[i+1] = fromBefore[i]
+		toBefore[i+1] = toBefore[i]
+		if op.kind != '+' {
+			fromBefore[i+1]++
+		}
+		if op.kind != '-' {
+			toBefore[i+1]++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for i := 0; i < len(changes); {
+		start := max(0, changes[i]-diffContextLines)
+		end := min(len(ops), changes[i]+diffContextLines+1)
+		i++
+		for i < len(changes) && changes[i]-diffContextLines <= end {
+			end = min(len(ops), changes[i]+diffContextLines+1)
+			i++
+		}
+
+		fromLen := fromBefore[end] - fromBefore[start]
+		toLen := toBefore[end] - toBefore[start]
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(fromBefore[start], fromLen), hunkRange(toBefore[start], toLen))
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.text)
+			if !strings.HasSuffix(op.text, "\n") {
+				out.WriteString("\n" + noNewlineMarker)
+			}
+		}
+	}
+	return out.String()
+}
+
+func diffLines(from, to []string) []diffOp {
+	// lcs[i][j] is the longest common subsequence of from[i:] and to[j:].
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(from)+len(to))
+	i, j := 0, 0
+	for i < len(from) && j < len(to) {
+		switch {
+		case from[i] == to[j]:
+			ops = append(ops, diffOp{kind: ' ', text: from[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{kind: '-', text: from[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', text: to[j]})
+			j++
+		}
+	}
+	for ; i < len(from); i++ {
+		ops = append(ops, diffOp{kind: '-', text: from[i]})
+	}
+	for ; j < len(to); j++ {
+		ops = append(ops, diffOp{kind: '+', text: to[j]})
+	}
+	return ops
+}
+
+// hunkRange formats a hunk side as "start,len"; an empty side names the
+// line before it, as diff -u does.
+func hunkRange(before, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, length)
+}
+
+// splitLines keeps each line's newline, so a last line without one differs
+// from the same line with it.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,71 @@
+package tasks
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	t.Parallel()
+
+	if got := UnifiedDiff("a", "b", "same\n", "same\n"); got != "" {
+		t.Fatalf("expected no diff for equal text, got %q", got)
+	}
+
+	from := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	to := "1\n2\n3\nfour\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+	expected := `--- v1
++++ current
+@@ -1,7 +1,7 @@
+ 1
+ 2
+ 3
+-4
++four
+ 5
+ 6
+ 7
+@@ -10,3 +10,4 @@
+ 10
+ 11
+ 12
++13
+`
+	if got := UnifiedDiff("v1", "current", from, to); got != expected {
+		t.Fatalf("unexpected diff:\n%s", got)
+	}
+
+	expected = `--- /dev/null
++++ note
+@@ -0,0 +1,2 @@
++## Status
++new
+`
+	if got := UnifiedDiff("/dev/null", "note", "", "## Status\nnew\n"); got != expected {
+		t.Fatalf("unexpected diff from empty:\n%s", got)
+	}
+}
+
+func TestUnifiedDiffMarksMissingFinalNewline(t *testing.T) {
+	t.Parallel()
+
+	expected := `--- v1
++++ current
+@@ -1,2 +1,2 @@
+ ## Status
+-done
+\ No newline at end of file
++done
+`
+	if got := UnifiedDiff("v1", "current", "## Status\ndone", "## Status\ndone\n"); got != expected {
+		t.Fatalf("unexpected diff for an added final newline:\n%s", got)
+	}
+
+	expected = `--- v1
++++ current
+@@ -1 +1,2 @@
+ a
++b
+\ No newline at end of file
+`
+	if got := UnifiedDiff("v1", "current", "a\n", "a\nb"); got != expected {
+		t.Fatalf("unexpected diff for an unterminated added line:\n%s", got)
+	}
+}
@@ -0,0 +1,188 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NoteSearchHit is a task whose note matched a search query. Lower ranks
+// are better matches.
+type NoteSearchHit struct {
+	TaskID  string  `json:"task_id"`
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// NoteIndexResult reports what RefreshNoteIndex changed.
+type NoteIndexResult struct {
+	Indexed   int `json:"indexed"`
+	Unchanged int `json:"unchanged"`
+	Removed   int `json:"removed"`
+}
+
+// RefreshNoteIndex brings the full-text index in line with the notes in
+// notesDir and syncs each re-read note's front matter and links into its
+// task. Notes are re-read only when their modification time changed
+// since they were last indexed; notes that disappeared are dropped.
+func (s *SQLiteStore) RefreshNoteIndex(ctx context.Context, notesDir string) (NoteIndexResult, error) {
+	var result NoteIndexResult
+
+	indexed, err := s.noteIndexMTimes(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	entries, err := os.ReadDir(notesDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return result, fmt.Errorf("read notes dir: %w", err)
+	}
+	seen := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		taskID, ok := strings.CutSuffix(entry.Name(), ".md")
+		if !ok || taskID == "" || entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return result, fmt.Errorf("stat task note: %w", err)
+		}
+		seen[taskID] = struct{}{}
+		mtime := formatTime(info.ModTime())
+		if indexed[taskID] == mtime {
+			result.Unchanged++
+			continue
+		}
+		// #nosec G304 -- the note path is derived from the notes dir listing.
+		content, err := os.ReadFile(filepath.Join(notesDir, entry.Name()))
+		if err != nil {
+			return result, fmt.Errorf("read task note: %w", err)
+		}
+		if err := s.indexNote(ctx, taskID, string(content), mtime); err != nil {
+			return result, err
+		}
+		// Stray notes and malformed front matter keep the stored metadata and
+		// links; the note is still searchable.
+		if _, err := s.SyncNoteMetadata(ctx, taskID, string(content), info.ModTime().UTC()); err != nil &&
+			!errors.Is(err, ErrTaskNotFound) && !errors.Is(err, ErrInvalidFrontMatter) {
+			return result, err
+		}
+		if err := s.SyncNoteLinks(ctx, taskID, string(content), info.ModTime().UTC()); err != nil && !errors.Is(err, ErrTaskNotFound) {
+			return result, err
+		}
+		result.Indexed++
+	}
+
+	for taskID := range indexed {
+		if _, ok := seen[taskID]; ok {
+			continue
+		}
+		if err := s.removeNoteIndex(ctx, taskID); err != nil {
+			return result, err
+		}
+		result.Removed++
+	}
+	return result, s.resolveTaskLinks(ctx)
+}
+
+// SearchNotes returns the best matching notes for query, best first. Each
+// whitespace-separated term must appear in the note; a trailing "*" makes a
+// term a prefix match.
+func (s *SQLiteStore) SearchNotes(ctx context.Context, query string, limit int) ([]NoteSearchHit, error) {
+	match := noteMatchExpression(query)
+	if match == "" {
+		return nil, fmt.Errorf("search query is required")
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	hits := make([]NoteSearchHit, 0)
+	if err := s.db.WithContext(ctx).Raw(`SELECT task_id,
+			bm25(note_index) AS rank,
+			snippet(note_index, 1, '[', ']', '…', 12) AS snippet
+		FROM note_index
+		WHERE note_index MATCH ?
+		ORDER BY rank ASC, task_id ASC
+		LIMIT ?;`, match, limit).Scan(&hits).Error; err != nil {
+		return nil, fmt.Errorf("search notes: %w", err)
+	}
+	return hits, nil
+}
+
+func (s *SQLiteStore) noteIndexMTimes(ctx context.Context) (map[string]string, error) {
+	type stateRow struct {
+		TaskID string `gorm:"column:task_id"`
+		MTime  string `gorm:"column:mtime"`
+	}
+
+	rows := make([]stateRow, 0)
+	if err := s.db.WithContext(ctx).Raw("SELECT task_id, mtime FROM note_index_state;").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("query note index state: %w", err)
+	}
+	result := make(map[string]string, len(rows))
+	for _, row := range rows {
+		result[row.TaskID] = row.MTime
+	}
+	return result, nil
+}
+
+func (s *SQLiteStore) indexNote(ctx context.Context, taskID, content, mtime string) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM note_index WHERE task_id = ?;", taskID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("INSERT INTO note_index (task_id, content) VALUES (?, ?);", taskID, content).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`INSERT INTO note_index_state (task_id, mtime, indexed_at) VALUES (?, ?, ?)
+			ON CONFLICT(task_id) DO UPDATE SET mtime = excluded.mtime, indexed_at = excluded.indexed_at;`,
+			taskID, mtime, formatTime(time.Now().UTC())).Error
+	})
+	if err != nil {
+		return fmt.Errorf("index note %s: %w", taskID, err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) removeNoteIndex(ctx context.Context, taskID string) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM note_index WHERE task_id = ?;", taskID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM task_links WHERE from_task_id = ?;", taskID).Error; err != nil {
+			return err
+		}
+		return tx.Exec("DELETE FROM note_index_state WHERE task_id = ?;", taskID).Error
+	})
+	if err != nil {
+		return fmt.Errorf("remove note %s from index: %w", taskID, err)
+	}
+	return nil
+}
+
+// noteMatchExpression quotes each query term so punctuation such as "-" or
+// ":" is searched for rather than parsed as FTS5 query syntax.
+func noteMatchExpression(query string) string {
+	terms := strings.Fields(query)
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		prefix := strings.HasSuffix(term, "*")
+		term = strings.TrimRight(term, "*")
+		if term == "" {
+			continue
+		}
+		expr := `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+		if prefix {
+			expr += "*"
+		}
+		quoted = append(quoted, expr)
+	}
+	return strings.Join(quoted, " ")
+}
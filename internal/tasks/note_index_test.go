@@ -0,0 +1,132 @@
+package tasks
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStoreRefreshNoteIndexAndSearch(t *testing.T) {
+	t.Parallel()
+
+	h := newSQLiteTestHarness(t)
+	dir := t.TempDir()
+	writeNote := func(taskID, content string, mtime time.Time) {
+		t.Helper()
+		path := NotePath(dir, taskID)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("Chtimes: %v", err)
+		}
+	}
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	writeNote("task_cache", "## Status\nDebugging the flaky cache test in CI.\n", base)
+	writeNote("task_login", "## Status\nLogin page redesign; caching headers later.\n", base)
+	if err := os.WriteFile(dir+"/README.txt", []byte("cache"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	result, err := h.Store.RefreshNoteIndex(h.Ctx, dir)
+	if err != nil {
+		t.Fatalf("RefreshNoteIndex: %v", err)
+	}
+	if result != (NoteIndexResult{Indexed: 2}) {
+		t.Fatalf("unexpected first refresh: %#v", result)
+	}
+
+	hits, err := h.Store.SearchNotes(h.Ctx, "flaky cache", 10)
+	if err != nil {
+		t.Fatalf("SearchNotes: %v", err)
+	}
+	if len(hits) != 1 || hits[0].TaskID != "task_cache" || !strings.Contains(hits[0].Snippet, "[flaky]") {
+		t.Fatalf("unexpected hits: %#v", hits)
+	}
+	hits, err = h.Store.SearchNotes(h.Ctx, "cach*", 10)
+	if err != nil {
+		t.Fatalf("SearchNotes prefix: %v", err)
+	}
+	if len(hits) != 2 {
+		t.Fatalf("expected prefix query to match both notes, got %#v", hits)
+	}
+	if _, err := h.Store.SearchNotes(h.Ctx, "CI-only: (flaky", 10); err != nil {
+		t.Fatalf("expected punctuation to be searched literally: %v", err)
+	}
+
+	// Unchanged mtimes skip re-reading; changed ones re-index, deleted notes
+	// drop out.
+	writeNote("task_login", "## Status\nShipped the login page.\n", base.Add(time.Minute))
+	if err := os.Remove(NotePath(dir, "task_cache")); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	writeNote("task_docs", "## Status\nWriting docs.\n", base)
+	result, err = h.Store.RefreshNoteIndex(h.Ctx, dir)
+	if err != nil {
+		t.Fatalf("RefreshNoteIndex: %v", err)
+	}
+	if result != (NoteIndexResult{Indexed: 2, Removed: 1}) {
+		t.Fatalf("unexpected second refresh: %#v", result)
+	}
+	result, err = h.Store.RefreshNoteIndex(h.Ctx, dir)
+	if err != nil || result != (NoteIndexResult{Unchanged: 2}) {
+		t.Fatalf("unexpected third refresh: %#v err=%v", result, err)
+	}
+	hits, err = h.Store.SearchNotes(h.Ctx, "cach*", 10)
+	if err != nil || len(hits) != 0 {
+		t.Fatalf("expected stale notes gone from index, got %#v err=%v", hits, err)
+	}
+
+	if _, err := h.Store.SearchNotes(h.Ctx, "  ", 10); err == nil {
+		t.Fatalf("expected empty query error")
+	}
+}
+
+func TestSQLiteStoreMigrationResyncsIndexedNotes(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir() + "/tasks.db"
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	task, _, err := NewService(store).GetOrCreatePrePRTask(context.Background(), "owner/repo", "feature/old-note")
+	if err != nil {
+		t.Fatalf("GetOrCreatePrePRTask: %v", err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(NotePath(dir, task.ID), []byte(frontMatterNote), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := store.RefreshNoteIndex(context.Background(), dir); err != nil {
+		t.Fatalf("RefreshNoteIndex: %v", err)
+	}
+
+	// Roll the database back to a build that indexed notes but synced
+	// neither metadata nor links.
+	if err := store.UpdateTaskMetadata(context.Background(), task.ID, NoteMetadata{}, time.Now()); err != nil {
+		t.Fatalf("UpdateTaskMetadata: %v", err)
+	}
+	if err := store.db.Exec("DROP TABLE task_links;").Error; err != nil {
+		t.Fatalf("drop task_links: %v", err)
+	}
+	_ = store.Close()
+
+	store, err = NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore after upgrade: %v", err)
+	}
+	defer func() {
+		_ = store.Close()
+	}()
+	result, err := store.RefreshNoteIndex(context.Background(), dir)
+	if err != nil || result.Indexed != 1 {
+		t.Fatalf("expected the unchanged note to be re-read, got %#v err=%v", result, err)
+	}
+	got, _, err := store.GetTask(context.Background(), task.ID)
+	if err != nil || got.Metadata.Title != "Cache: flaky test" {
+		t.Fatalf("expected metadata synced after upgrade, got %#v err=%v", got.Metadata, err)
+	}
+}
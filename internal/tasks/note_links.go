@@ -0,0 +1,188 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TaskLink is a wiki link from one task's note to another task. ToTaskID
+// is empty while no task has the linked alias.
+type TaskLink struct {
+	FromTaskID string    `json:"from_task_id"`
+	AliasValue string    `json:"alias_value"`
+	ToTaskID   string    `json:"to_task_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// RelatedTask is a task linked to or from another task's note. Direction
+// is "link" when the note links to TaskID and "backlink" when TaskID's note
+// links back.
+type RelatedTask struct {
+	TaskID     string `json:"task_id"`
+	AliasValue string `json:"alias_value"`
+	Direction  string `json:"direction"`
+}
+
+type sqliteTaskLinkModel struct {
+	FromTaskID string `gorm:"column:from_task_id;primaryKey"`
+	AliasValue string `gorm:"column:alias_value;primaryKey"`
+	ToTaskID   string `gorm:"column:to_task_id;not null"`
+	CreatedAt  string `gorm:"column:created_at;not null"`
+}
+
+func (sqliteTaskLinkModel) TableName() string {
+	return "task_links"
+}
+
+var wikiLinkPattern = regexp.MustCompile(`\[\[([^\[\]\n]+)\]\]`)
+
+// ParseNoteLinks returns the alias values of the task links in a note, in
+// order and without duplicates. "[[owner/repo#123]]" and
+// "[[pr:owner/repo#123]]" link to a PR, "[[prepr:owner/repo:branch]]" to a
+// pre-PR branch; text after a "|" is a label. Other wiki links are ignored.
+func ParseNoteLinks(content string) []string {
+	aliases := make([]string, 0)
+	seen := map[string]bool{}
+	for _, match := range wikiLinkPattern.FindAllStringSubmatch(content, -1) {
+		target, _, _ := strings.Cut(match[1], "|")
+		alias, ok := noteLinkAlias(strings.TrimSpace(target))
+		if !ok || seen[alias] {
+			continue
+		}
+		seen[alias] = true
+		aliases = append(aliases, alias)
+	}
+	return aliases
+}
+
+func noteLinkAlias(target string) (string, bool) {
+	if rest, ok := strings.CutPrefix(target, "prepr:"); ok {
+		repo, branch, ok := strings.Cut(rest, ":")
+		if !ok || !strings.Contains(repo, "/") || strings.TrimSpace(branch) == "" {
+			return "", false
+		}
+		return PrePRAliasValue(repo, branch), true
+	}
+	target = strings.TrimPrefix(target, "pr:")
+	repo, number, ok := strings.Cut(target, "#")
+	if !ok || !strings.Contains(repo, "/") || strings.ContainsAny(repo, " \t") {
+		return "", false
+	}
+	prNumber, err := strconv.Atoi(number)
+	if err != nil || prNumber <= 0 {
+		return "", false
+	}
+	return PRAliasValue(repo, prNumber), true
+}
+
+// SyncNoteLinks replaces the links stored for taskID's note with those in
+// content, resolving each through the alias table. Links to the task itself
+// are dropped.
+func (s *SQLiteStore) SyncNoteLinks(ctx context.Context, taskID, content string, at time.Time) error {
+	if _, found, err := s.GetTask(ctx, taskID); err != nil {
+		return err
+	} else if !found {
+		return ErrTaskNotFound
+	}
+
+	aliases := ParseNoteLinks(content)
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		previous := make([]sqliteTaskLinkModel, 0)
+		if err := tx.Where("from_task_id = ?", taskID).Find(&previous).Error; err != nil {
+			return err
+		}
+		createdAt := make(map[string]string, len(previous))
+		for _, model := range previous {
+			createdAt[model.AliasValue] = model.CreatedAt
+		}
+		if err := tx.Where("from_task_id = ?", taskID).Delete(&sqliteTaskLinkModel{}).Error; err != nil {
+			return err
+		}
+
+		for _, alias := range aliases {
+			var target sqliteTaskAliasModel
+			err := tx.Where("alias_value = ?", alias).First(&target).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if target.TaskID == taskID {
+				continue
+			}
+			model := sqliteTaskLinkModel{
+				FromTaskID: taskID,
+				AliasValue: alias,
+				ToTaskID:   target.TaskID,
+				CreatedAt:  createdAt[alias],
+			}
+			if model.CreatedAt == "" {
+				model.CreatedAt = formatTime(at)
+			}
+			if err := tx.Create(&model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("sync note links %s: %w", taskID, err)
+	}
+	return nil
+}
+
+// resolveTaskLinks points unresolved links at tasks whose alias appeared
+// since the note was read.
+func (s *SQLiteStore) resolveTaskLinks(ctx context.Context) error {
+	if err := s.db.WithContext(ctx).Exec(`UPDATE task_links
+		SET to_task_id = COALESCE((
+			SELECT a.task_id FROM task_aliases a
+			WHERE a.alias_value = task_links.alias_value AND a.task_id != task_links.from_task_id
+		), '')
+		WHERE to_task_id = '';`).Error; err != nil {
+		return fmt.Errorf("resolve task links: %w", err)
+	}
+	return nil
+}
+
+// ListTaskLinks returns every stored note link, ordered by linking task.
+func (s *SQLiteStore) ListTaskLinks(ctx context.Context) ([]TaskLink, error) {
+	models := make([]sqliteTaskLinkModel, 0)
+	if err := s.db.WithContext(ctx).Order("from_task_id ASC, alias_value ASC").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("query task links: %w", err)
+	}
+
+	result := make([]TaskLink, 0, len(models))
+	for _, model := range models {
+		createdAt, _ := parseTime(model.CreatedAt)
+		result = append(result, TaskLink{
+			FromTaskID: model.FromTaskID,
+			AliasValue: model.AliasValue,
+			ToTaskID:   model.ToTaskID,
+			CreatedAt:  createdAt,
+		})
+	}
+	return result, nil
+}
+
+// RelatedTasks returns the resolved tasks taskID's note links to, then the
+// tasks whose notes link back to it.
+func RelatedTasks(links []TaskLink, taskID string) []RelatedTask {
+	related := make([]RelatedTask, 0)
+	for _, link := range links {
+		if link.FromTaskID == taskID && link.ToTaskID != "" {
+			related = append(related, RelatedTask{TaskID: link.ToTaskID, AliasValue: link.AliasValue, Direction: "link"})
+		}
+	}
+	for _, link := range links {
+		if link.ToTaskID == taskID {
+			related = append(related, RelatedTask{TaskID: link.FromTaskID, AliasValue: link.AliasValue, Direction: "backlink"})
+		}
+	}
+	return related
+}
@@ -0,0 +1,103 @@
+package tasks
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseNoteLinks(t *testing.T) {
+	t.Parallel()
+
+	content := `Follow-up to [[Owner/Repo#12]] and [[pr:owner/repo#12|the same PR]].
+Depends on [[prepr:owner/repo:feature/api]]; see [[Some Page]], [[owner/repo#0]] and [[owner/repo#x]].
+[[ prepr:owner/other:fix/a:b ]]
+`
+	expected := []string{"pr:owner/repo#12", "prepr:owner/repo:feature/api", "prepr:owner/other:fix/a:b"}
+	if got := ParseNoteLinks(content); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("ParseNoteLinks = %#v", got)
+	}
+}
+
+func TestSQLiteStoreSyncsAndResolvesNoteLinks(t *testing.T) {
+	t.Parallel()
+
+	h := newSQLiteTestHarness(t)
+	api, _, err := h.Service.GetOrCreatePrePRTask(h.Ctx, "owner/repo", "feature/api")
+	if err != nil {
+		t.Fatalf("GetOrCreatePrePRTask: %v", err)
+	}
+	ui, _, err := h.Service.GetOrCreatePrePRTask(h.Ctx, "owner/repo", "feature/ui")
+	if err != nil {
+		t.Fatalf("GetOrCreatePrePRTask: %v", err)
+	}
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	content := "Needs [[prepr:owner/repo:feature/api]] first, then [[owner/repo#7]]. Self: [[prepr:owner/repo:feature/ui]]\n"
+	if err := h.Store.SyncNoteLinks(h.Ctx, ui.ID, content, at); err != nil {
+		t.Fatalf("SyncNoteLinks: %v", err)
+	}
+	links, err := h.Store.ListTaskLinks(h.Ctx)
+	if err != nil {
+		t.Fatalf("ListTaskLinks: %v", err)
+	}
+	if len(links) != 2 || links[0].AliasValue != "pr:owner/repo#7" || links[0].ToTaskID != "" ||
+		links[1].AliasValue != "prepr:owner/repo:feature/api" || links[1].ToTaskID != api.ID {
+		t.Fatalf("unexpected links: %#v", links)
+	}
+
+	// The PR appears later; a refresh resolves the dangling link.
+	other, _, err := h.Service.GetOrCreatePrePRTask(h.Ctx, "owner/repo", "feature/other")
+	if err != nil {
+		t.Fatalf("GetOrCreatePrePRTask: %v", err)
+	}
+	if _, _, err := h.Service.LinkPRToPrePR(h.Ctx, "owner/repo", "feature/other", 7); err != nil {
+		t.Fatalf("LinkPRToPrePR: %v", err)
+	}
+	if _, err := h.Store.RefreshNoteIndex(h.Ctx, t.TempDir()); err != nil {
+		t.Fatalf("RefreshNoteIndex: %v", err)
+	}
+	links, _ = h.Store.ListTaskLinks(h.Ctx)
+	if links[0].ToTaskID != other.ID || !links[0].CreatedAt.Equal(at) {
+		t.Fatalf("expected resolved PR link, got %#v", links[0])
+	}
+
+	related := RelatedTasks(links, api.ID)
+	if !reflect.DeepEqual(related, []RelatedTask{{TaskID: ui.ID, AliasValue: "prepr:owner/repo:feature/api", Direction: "backlink"}}) {
+		t.Fatalf("unexpected related tasks: %#v", related)
+	}
+
+	// Editing the note replaces its links.
+	if err := h.Store.SyncNoteLinks(h.Ctx, ui.ID, "no links now\n", at.Add(time.Hour)); err != nil {
+		t.Fatalf("SyncNoteLinks: %v", err)
+	}
+	if links, _ = h.Store.ListTaskLinks(h.Ctx); len(links) != 0 {
+		t.Fatalf("expected links to be cleared: %#v", links)
+	}
+	if err := h.Store.SyncNoteLinks(h.Ctx, "task_missing", content, at); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("expected ErrTaskNotFound, got %v", err)
+	}
+
+	// A note removed from the notes dir drops its links.
+	dir := t.TempDir()
+	if err := os.WriteFile(NotePath(dir, ui.ID), []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := h.Store.RefreshNoteIndex(h.Ctx, dir); err != nil {
+		t.Fatalf("RefreshNoteIndex: %v", err)
+	}
+	if links, _ = h.Store.ListTaskLinks(h.Ctx); len(links) != 2 {
+		t.Fatalf("expected links from the indexed note: %#v", links)
+	}
+	if err := os.Remove(NotePath(dir, ui.ID)); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := h.Store.RefreshNoteIndex(h.Ctx, dir); err != nil {
+		t.Fatalf("RefreshNoteIndex: %v", err)
+	}
+	if links, _ = h.Store.ListTaskLinks(h.Ctx); len(links) != 0 {
+		t.Fatalf("expected links of a removed note to be dropped: %#v", links)
+	}
+}
@@ -0,0 +1,174 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ChecklistItem is a list item in a note section. Plain bullets are open
+// items; "- [x]" items are done.
+type ChecklistItem struct {
+	Text string `json:"text"`
+	Done bool   `json:"done"`
+}
+
+// ParsedNote is the structured state held in a task note.
+type ParsedNote struct {
+	Objective   string
+	Status      string
+	NextActions []ChecklistItem
+	Blockers    []ChecklistItem
+	// Sections holds every "## " section, including ones the parser does not
+	// interpret.
+	Sections map[string]string
+}
+
+// NoteSummary is the part of a note the dashboard shows.
+type NoteSummary struct {
+	Objective       string   `json:"objective"`
+	Status          string   `json:"status"`
+	OpenNextActions []string `json:"open_next_actions"`
+	DoneNextActions int      `json:"done_next_actions"`
+	OpenBlockers    []string `json:"open_blockers"`
+	BlockerCount    int      `json:"blocker_count"`
+}
+
+// noneMarkers are section bodies that mean "nothing here".
+var noneMarkers = map[string]struct{}{
+	"none": {}, "n/a": {}, "na": {}, "-": {}, "nothing": {},
+}
+
+// ParseNote reads the template sections of a note. A Blockers section with
+// text but no list items counts as a single blocker unless it just says
+// "none"; empty "- [ ]" placeholders are list items, not text.
+func ParseNote(content string) ParsedNote {
+	sections := NoteSections(content)
+	note := ParsedNote{
+		Objective:   sections[NoteSectionObjective],
+		Status:      sections[NoteSectionStatus],
+		NextActions: checklistItems(sections[NoteSectionNextActions]),
+		Blockers:    checklistItems(sections[NoteSectionBlockers]),
+		Sections:    sections,
+	}
+	if blockers := sections[NoteSectionBlockers]; len(note.Blockers) == 0 && !isNoneMarker(blockers) && !hasListItem(blockers) {
+		note.Blockers = []ChecklistItem{{Text: firstLine(blockers)}}
+	}
+	return note
+}
+
+// ReadNoteSummary parses the task's note in notesDir. A missing note yields
+// nil rather than an error.
+func ReadNoteSummary(notesDir, taskID string) (*NoteSummary, error) {
+	// #nosec G304 -- the note path is derived from the notes dir and task ID.
+	content, err := os.ReadFile(NotePath(notesDir, taskID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read task note: %w", err)
+	}
+	summary := ParseNote(string(content)).Summary()
+	return &summary, nil
+}
+
+// Summary condenses the note to what is still open.
+func (n ParsedNote) Summary() NoteSummary {
+	summary := NoteSummary{
+		Objective:       n.Objective,
+		Status:          n.Status,
+		OpenNextActions: []string{},
+		OpenBlockers:    []string{},
+	}
+	for _, item := range n.NextActions {
+		if item.Done {
+			summary.DoneNextActions++
+			continue
+		}
+		summary.OpenNextActions = append(summary.OpenNextActions, item.Text)
+	}
+	for _, item := range n.Blockers {
+		if !item.Done {
+			summary.OpenBlockers = append(summary.OpenBlockers, item.Text)
+		}
+	}
+	summary.BlockerCount = len(summary.OpenBlockers)
+	return summary
+}
+
+func checklistItems(body string) []ChecklistItem {
+	items := make([]ChecklistItem, 0)
+	for _, line := range strings.Split(body, "\n") {
+		text, ok := listItemText(strings.TrimSpace(line))
+		if !ok {
+			continue
+		}
+		item := ChecklistItem{Text: text}
+		if rest, done, ok := cutCheckbox(text); ok {
+			item.Text, item.Done = rest, done
+		}
+		// Empty checkboxes ("- [ ]" placeholders) are not items.
+		if item.Text != "" && !isNoneMarker(item.Text) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func hasListItem(body string) bool {
+	for _, line := range strings.Split(body, "\n") {
+		if _, ok := listItemText(strings.TrimSpace(line)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// cutCheckbox strips a leading "[ ]", "[x]" or "[X]" box, which may stand
+// alone, and reports whether it was ticked.
+func cutCheckbox(text string) (string, bool, bool) {
+	for box, done := range map[string]bool{"[ ]": false, "[x]": true, "[X]": true} {
+		rest, ok := strings.CutPrefix(text, box)
+		if ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			return strings.TrimSpace(rest), done, true
+		}
+	}
+	return text, false, false
+}
+
+// listItemText strips a "-", "*", "+" or "1." list marker.
+func listItemText(line string) (string, bool) {
+	for _, marker := range []string{"- ", "* ", "+ "} {
+		if text, ok := strings.CutPrefix(line, marker); ok {
+			return strings.TrimSpace(text), true
+		}
+	}
+	digits := 0
+	for digits < len(line) && line[digits] >= '0' && line[digits] <= '9' {
+		digits++
+	}
+	if digits > 0 {
+		if text, ok := strings.CutPrefix(line[digits:], ". "); ok {
+			return strings.TrimSpace(text), true
+		}
+	}
+	return "", false
+}
+
+func isNoneMarker(text string) bool {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if item, ok := listItemText(text); ok {
+		text = item
+	}
+	if text == "" {
+		return true
+	}
+	_, ok := noneMarkers[strings.TrimSuffix(text, ".")]
+	return ok
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return strings.TrimSpace(line)
+}
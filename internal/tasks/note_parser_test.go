@@ -0,0 +1,110 @@
+package tasks
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+const parsedNoteFixture = `# Task State
+
+## Current Objective
+Ship the dashboard note summary.
+
+## Status
+Parser done, wiring the dashboard.
+
+## Next Actions
+- [x] write the parser
+- [ ] add dashboard fields
+* update the README
+1. cut a release
+
+## Blockers
+- [ ] waiting on review from @infra
+- [x] CI flake (fixed)
+
+## Session Context
+- [ ] not an action
+`
+
+func TestParseNoteReadsSectionsAndChecklists(t *testing.T) {
+	t.Parallel()
+
+	note := ParseNote(parsedNoteFixture)
+	if note.Objective != "Ship the dashboard note summary." || note.Status != "Parser done, wiring the dashboard." {
+		t.Fatalf("unexpected objective/status: %#v", note)
+	}
+	expectedActions := []ChecklistItem{
+		{Text: "write the parser", Done: true},
+		{Text: "add dashboard fields"},
+		{Text: "update the README"},
+		{Text: "cut a release"},
+	}
+	if !reflect.DeepEqual(note.NextActions, expectedActions) {
+		t.Fatalf("unexpected next actions: %#v", note.NextActions)
+	}
+
+	summary := note.Summary()
+	expected := NoteSummary{
+		Objective:       "Ship the dashboard note summary.",
+		Status:          "Parser done, wiring the dashboard.",
+		OpenNextActions: []string{"add dashboard fields", "update the README", "cut a release"},
+		DoneNextActions: 1,
+		OpenBlockers:    []string{"waiting on review from @infra"},
+		BlockerCount:    1,
+	}
+	if !reflect.DeepEqual(summary, expected) {
+		t.Fatalf("unexpected summary: %#v", summary)
+	}
+}
+
+func TestParseNoteFreeTextBlockers(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]int{
+		"## Blockers\n":                                  0,
+		"## Blockers\nNone.\n":                           0,
+		"## Blockers\n- n/a\n":                           0,
+		"## Blockers\nNeed prod credentials\nfrom ops\n": 1,
+	}
+	for content, expected := range cases {
+		if got := ParseNote(content).Summary().BlockerCount; got != expected {
+			t.Fatalf("BlockerCount for %q = %d, want %d", content, got, expected)
+		}
+	}
+	if got := ParseNote("## Blockers\nNeed prod credentials\nfrom ops\n").Blockers[0].Text; got != "Need prod credentials" {
+		t.Fatalf("expected first line as blocker text, got %q", got)
+	}
+}
+
+func TestReadNoteSummaryMissingNote(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	summary, err := ReadNoteSummary(dir, "task_missing")
+	if err != nil || summary != nil {
+		t.Fatalf("expected nil summary for missing note, got %#v err=%v", summary, err)
+	}
+	if err := os.WriteFile(NotePath(dir, "task_1"), []byte(parsedNoteFixture), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	summary, err = ReadNoteSummary(dir, "task_1")
+	if err != nil || summary == nil || summary.BlockerCount != 1 {
+		t.Fatalf("unexpected summary %#v err=%v", summary, err)
+	}
+}
+
+func TestParseNoteSkipsEmptyCheckboxes(t *testing.T) {
+	t.Parallel()
+
+	content := "## Next Actions\n- [ ]\n- [x]\n- [ ]\tship it\n- [X] tests\n- [link](url)\n\n## Blockers\n- [ ]\n- [ ]\n"
+	note := ParseNote(content)
+	expected := []ChecklistItem{{Text: "ship it"}, {Text: "tests", Done: true}, {Text: "[link](url)"}}
+	if !reflect.DeepEqual(note.NextActions, expected) {
+		t.Fatalf("unexpected next actions: %#v", note.NextActions)
+	}
+	if len(note.Blockers) != 0 {
+		t.Fatalf("expected placeholder blockers to be ignored, got %#v", note.Blockers)
+	}
+}
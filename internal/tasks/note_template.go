@@ -0,0 +1,88 @@
+package tasks
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// defaultNoteTemplateName is the template file used when no file matches the
+// alias type.
+const defaultNoteTemplateName = "default"
+
+// NoteData is what a note template can reference.
+type NoteData struct {
+	TaskID    string
+	Repo      string
+	Branch    string
+	PRNumber  int
+	PRTitle   string
+	AliasType AliasType
+	CreatedAt time.Time
+}
+
+// CreatedDate is CreatedAt as YYYY-MM-DD, for template headers.
+func (d NoteData) CreatedDate() string {
+	return d.CreatedAt.Format(time.DateOnly)
+}
+
+// NoteTemplatePaths lists the template files tried for a note, most specific
+// first: the repo's template for the alias type, the repo's default, then the
+// global ones. Repo templates live in <templatesDir>/<owner>/<repo>/.
+func NoteTemplatePaths(templatesDir, repo string, aliasType AliasType) []string {
+	if strings.TrimSpace(templatesDir) == "" {
+		return nil
+	}
+	names := []string{defaultNoteTemplateName + ".md"}
+	if aliasType != "" {
+		names = []string{string(aliasType) + ".md", defaultNoteTemplateName + ".md"}
+	}
+
+	dirs := []string{templatesDir}
+	if owner, name, ok := strings.Cut(NormalizeRepo(repo), "/"); ok && owner != "" && name != "" {
+		dirs = []string{filepath.Join(templatesDir, owner, name), templatesDir}
+	}
+
+	paths := make([]string, 0, len(dirs)*len(names))
+	for _, dir := range dirs {
+		for _, name := range names {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+	}
+	return paths
+}
+
+// SelectNoteTemplate returns the first existing template from
+// NoteTemplatePaths and its path, or the built-in template and an empty path
+// when none exists.
+func SelectNoteTemplate(templatesDir, repo string, aliasType AliasType) (string, string, error) {
+	for _, path := range NoteTemplatePaths(templatesDir, repo, aliasType) {
+		// #nosec G304 -- template paths are built from the user's templates dir.
+		content, err := os.ReadFile(path)
+		if err == nil {
+			return string(content), path, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", "", fmt.Errorf("read note template: %w", err)
+		}
+	}
+	return noteTemplate, "", nil
+}
+
+// RenderNote executes a note template with data.
+func RenderNote(text string, data NoteData) (string, error) {
+	tmpl, err := template.New("note").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parse note template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render note template: %w", err)
+	}
+	return buf.String(), nil
+}
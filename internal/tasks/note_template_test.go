@@ -0,0 +1,111 @@
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTemplate(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+}
+
+func TestSelectNoteTemplatePrefersRepoAndAliasType(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeTemplate(t, filepath.Join(dir, "default.md"), "global default")
+	writeTemplate(t, filepath.Join(dir, "pr.md"), "global pr")
+	writeTemplate(t, filepath.Join(dir, "owner", "repo", "default.md"), "repo default")
+	writeTemplate(t, filepath.Join(dir, "owner", "repo", "prepr.md"), "repo prepr")
+
+	cases := []struct {
+		repo      string
+		aliasType AliasType
+		expected  string
+	}{
+		{repo: "Owner/Repo", aliasType: AliasTypePrePR, expected: "repo prepr"},
+		{repo: "owner/repo", aliasType: AliasTypePR, expected: "repo default"},
+		{repo: "other/repo", aliasType: AliasTypePR, expected: "global pr"},
+		{repo: "other/repo", aliasType: AliasTypePrePR, expected: "global default"},
+		{repo: "", aliasType: "", expected: "global default"},
+	}
+	for _, tc := range cases {
+		text, path, err := SelectNoteTemplate(dir, tc.repo, tc.aliasType)
+		if err != nil {
+			t.Fatalf("SelectNoteTemplate(%q, %q): %v", tc.repo, tc.aliasType, err)
+		}
+		if text != tc.expected || path == "" {
+			t.Fatalf("SelectNoteTemplate(%q, %q) = %q from %q, want %q", tc.repo, tc.aliasType, text, path, tc.expected)
+		}
+	}
+}
+
+func TestSelectNoteTemplateFallsBackToBuiltin(t *testing.T) {
+	t.Parallel()
+
+	for _, dir := range []string{"", t.TempDir(), filepath.Join(t.TempDir(), "missing")} {
+		text, path, err := SelectNoteTemplate(dir, "owner/repo", AliasTypePR)
+		if err != nil {
+			t.Fatalf("SelectNoteTemplate(%q): %v", dir, err)
+		}
+		if text != noteTemplate || path != "" {
+			t.Fatalf("expected built-in template for %q, got %q from %q", dir, text, path)
+		}
+	}
+}
+
+func TestEnsureTaskNoteRendersTemplateVariables(t *testing.T) {
+	t.Parallel()
+
+	templatesDir := t.TempDir()
+	writeTemplate(t, filepath.Join(templatesDir, "owner", "repo", "pr.md"),
+		"# {{.Repo}}#{{.PRNumber}}: {{.PRTitle}}\n\nTask {{.TaskID}} on {{.Branch}}, created {{.CreatedDate}}.\n")
+
+	path, created, err := EnsureTaskNote(t.TempDir(), templatesDir, NoteData{
+		TaskID:    "task_1",
+		Repo:      "owner/repo",
+		Branch:    "feature/x",
+		PRNumber:  12,
+		PRTitle:   "Add templates",
+		AliasType: AliasTypePR,
+		CreatedAt: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+	})
+	if err != nil || !created {
+		t.Fatalf("EnsureTaskNote created=%v err=%v", created, err)
+	}
+	// #nosec G304 -- path is returned by EnsureTaskNote using t.TempDir.
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	expected := "# owner/repo#12: Add templates\n\nTask task_1 on feature/x, created 2026-03-01.\n"
+	if string(content) != expected {
+		t.Fatalf("unexpected note:\n%s", content)
+	}
+}
+
+func TestEnsureTaskNoteReportsBadTemplate(t *testing.T) {
+	t.Parallel()
+
+	templatesDir := t.TempDir()
+	writeTemplate(t, filepath.Join(templatesDir, "default.md"), "{{.Missing}}")
+
+	notesDir := t.TempDir()
+	_, _, err := EnsureTaskNote(notesDir, templatesDir, NoteData{TaskID: "task_1"})
+	if err == nil || !strings.Contains(err.Error(), "default.md") {
+		t.Fatalf("expected template error naming the file, got %v", err)
+	}
+	if _, statErr := os.Stat(NotePath(notesDir, "task_1")); !os.IsNotExist(statErr) {
+		t.Fatalf("expected no note written for a bad template, got %v", statErr)
+	}
+}
@@ -0,0 +1,120 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NoteVersion is a snapshot of a task note. Versions count up from 1 per
+// task.
+type NoteVersion struct {
+	TaskID    string    `json:"task_id"`
+	Version   int       `json:"version"`
+	Content   string    `json:"content"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type sqliteNoteVersionModel struct {
+	TaskID    string `gorm:"column:task_id;primaryKey"`
+	Version   int    `gorm:"column:version;primaryKey"`
+	Content   string `gorm:"column:content;not null"`
+	Source    string `gorm:"column:source"`
+	CreatedAt string `gorm:"column:created_at;not null"`
+}
+
+func (sqliteNoteVersionModel) TableName() string { return "note_versions" }
+
+// SnapshotNote stores content as the task's next note version unless it
+// matches the latest version. It reports whether a version was added.
+func (s *SQLiteStore) SnapshotNote(ctx context.Context, taskID, content, source string, at time.Time) (NoteVersion, bool, error) {
+	var (
+		version NoteVersion
+		added   bool
+	)
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var latest sqliteNoteVersionModel
+		err := tx.Where("task_id = ?", taskID).Order("version DESC").Take(&latest).Error
+		switch {
+		case err == nil && latest.Content == content:
+			version = fromNoteVersionModel(latest)
+			return nil
+		case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		model := sqliteNoteVersionModel{
+			TaskID:    taskID,
+			Version:   latest.Version + 1,
+			Content:   content,
+			Source:    source,
+			CreatedAt: formatTime(at),
+		}
+		if err := tx.Create(&model).Error; err != nil {
+			return err
+		}
+		version = fromNoteVersionModel(model)
+		added = true
+		return nil
+	})
+	if err != nil {
+		return NoteVersion{}, false, fmt.Errorf("snapshot note %s: %w", taskID, err)
+	}
+	return version, added, nil
+}
+
+// SnapshotNoteFile snapshots the note at path, then syncs its front matter
+// into the task's metadata; a sync error wraps ErrNoteMetadataNotSynced and
+// still returns the snapshot. A missing note is not an error and adds
+// nothing.
+func (s *SQLiteStore) SnapshotNoteFile(ctx context.Context, taskID, path, source string, at time.Time) (NoteVersion, bool, error) {
+	// #nosec G304 -- the note path is derived from the notes dir and task ID.
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return NoteVersion{}, false, nil
+	}
+	if err != nil {
+		return NoteVersion{}, false, fmt.Errorf("read task note: %w", err)
+	}
+	version, added, err := s.SnapshotNote(ctx, taskID, string(content), source, at)
+	if err != nil {
+		return NoteVersion{}, false, err
+	}
+	if _, err := s.SyncNoteMetadata(ctx, taskID, string(content), at); err != nil {
+		return version, added, fmt.Errorf("%w: %w", ErrNoteMetadataNotSynced, err)
+	}
+	return version, added, nil
+}
+
+// ListNoteVersions returns the task's note versions, oldest first.
+func (s *SQLiteStore) ListNoteVersions(ctx context.Context, taskID string) ([]NoteVersion, error) {
+	models := make([]sqliteNoteVersionModel, 0)
+	if err := s.db.WithContext(ctx).
+		Where("task_id = ?", taskID).
+		Order("version ASC").
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("query note versions: %w", err)
+	}
+
+	result := make([]NoteVersion, 0, len(models))
+	for _, model := range models {
+		result = append(result, fromNoteVersionModel(model))
+	}
+	return result, nil
+}
+
+func fromNoteVersionModel(model sqliteNoteVersionModel) NoteVersion {
+	createdAt, _ := parseTime(model.CreatedAt)
+	return NoteVersion{
+		TaskID:    model.TaskID,
+		Version:   model.Version,
+		Content:   model.Content,
+		Source:    model.Source,
+		CreatedAt: createdAt,
+	}
+}
@@ -0,0 +1,60 @@
+package tasks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStoreSnapshotNoteSkipsUnchangedContent(t *testing.T) {
+	t.Parallel()
+
+	h := newSQLiteTestHarness(t)
+	task, _, err := h.Service.GetOrCreatePrePRTask(h.Ctx, "owner/repo", "feature/versions")
+	if err != nil {
+		t.Fatalf("GetOrCreatePrePRTask: %v", err)
+	}
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	first, added, err := h.Store.SnapshotNote(h.Ctx, task.ID, "v1\n", "open-note", now)
+	if err != nil || !added || first.Version != 1 {
+		t.Fatalf("first snapshot = %#v added=%v err=%v", first, added, err)
+	}
+	same, added, err := h.Store.SnapshotNote(h.Ctx, task.ID, "v1\n", "open-session", now.Add(time.Minute))
+	if err != nil || added || same.Version != 1 || same.Source != "open-note" {
+		t.Fatalf("unchanged snapshot = %#v added=%v err=%v", same, added, err)
+	}
+	second, added, err := h.Store.SnapshotNote(h.Ctx, task.ID, "v2\n", "open-session", now.Add(time.Hour))
+	if err != nil || !added || second.Version != 2 {
+		t.Fatalf("second snapshot = %#v added=%v err=%v", second, added, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "missing.md")
+	if _, added, err := h.Store.SnapshotNoteFile(h.Ctx, task.ID, path, "open-note", now); err != nil || added {
+		t.Fatalf("expected missing note to be skipped, added=%v err=%v", added, err)
+	}
+	if err := os.WriteFile(path, []byte("v3\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, added, err := h.Store.SnapshotNoteFile(h.Ctx, task.ID, path, "open-note", now.Add(2*time.Hour)); err != nil || !added {
+		t.Fatalf("expected file snapshot, added=%v err=%v", added, err)
+	}
+	// Bad front matter is reported apart from the snapshot, which is kept.
+	if err := os.WriteFile(path, []byte("---\ndue: next week\n---\nv4\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	version, added, err := h.Store.SnapshotNoteFile(h.Ctx, task.ID, path, "open-note", now.Add(3*time.Hour))
+	if !errors.Is(err, ErrNoteMetadataNotSynced) || !errors.Is(err, ErrInvalidFrontMatter) || !added || version.Version != 4 {
+		t.Fatalf("expected snapshot with a metadata error, got %#v added=%v err=%v", version, added, err)
+	}
+
+	versions, err := h.Store.ListNoteVersions(h.Ctx, task.ID)
+	if err != nil {
+		t.Fatalf("ListNoteVersions: %v", err)
+	}
+	if len(versions) != 4 || versions[0].Content != "v1\n" || versions[2].Version != 3 || !versions[1].CreatedAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("unexpected versions: %#v", versions)
+	}
+}
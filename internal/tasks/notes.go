@@ -4,8 +4,19 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
+// Section headings of the note template.
+const (
+	NoteSectionObjective      = "Current Objective"
+	NoteSectionStatus         = "Status"
+	NoteSectionNextActions    = "Next Actions"
+	NoteSectionBlockers       = "Blockers"
+	NoteSectionSessionContext = "Session Context"
+)
+
+// noteTemplate is the built-in template, used when no template file matches.
 const noteTemplate = `# Task State
 
 ## Current Objective
@@ -23,8 +34,10 @@ func NotePath(notesDir, taskID string) string {
 	return filepath.Join(notesDir, taskID+".md")
 }
 
-func EnsureTaskNote(notesDir, taskID string) (string, bool, error) {
-	if taskID == "" {
+// EnsureTaskNote creates the task's note from the template selected for its
+// repo and alias type (see SelectNoteTemplate), or returns the existing one.
+func EnsureTaskNote(notesDir, templatesDir string, data NoteData) (string, bool, error) {
+	if data.TaskID == "" {
 		return "", false, fmt.Errorf("taskID is required")
 	}
 
@@ -32,15 +45,73 @@ func EnsureTaskNote(notesDir, taskID string) (string, bool, error) {
 		return "", false, fmt.Errorf("create notes dir: %w", err)
 	}
 
-	path := NotePath(notesDir, taskID)
+	path := NotePath(notesDir, data.TaskID)
 	if _, err := os.Stat(path); err == nil {
 		return path, false, nil
 	} else if !os.IsNotExist(err) {
 		return "", false, fmt.Errorf("stat note file: %w", err)
 	}
 
-	if err := os.WriteFile(path, []byte(noteTemplate), 0o600); err != nil {
+	text, templatePath, err := SelectNoteTemplate(templatesDir, data.Repo, data.AliasType)
+	if err != nil {
+		return "", false, err
+	}
+	content, err := RenderNote(text, data)
+	if err != nil {
+		if templatePath != "" {
+			return "", false, fmt.Errorf("%s: %w", templatePath, err)
+		}
+		return "", false, err
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
 		return "", false, fmt.Errorf("write note template: %w", err)
 	}
 	return path, true, nil
 }
+
+// NoteSections splits a note into its "## " sections keyed by heading, with
+// trimmed bodies. Text before the first section is ignored.
+func NoteSections(content string) map[string]string {
+	sections := map[string]string{}
+	heading := ""
+	var body strings.Builder
+	flush := func() {
+		if heading != "" {
+			sections[heading] = strings.TrimSpace(body.String())
+		}
+		body.Reset()
+	}
+	inFence := false
+	for _, line := range strings.Split(content, "\n") {
+		if isCodeFence(line) {
+			inFence = !inFence
+		}
+		if title, ok := strings.CutPrefix(line, "## "); ok && !inFence {
+			flush()
+			heading = strings.TrimSpace(title)
+			continue
+		}
+		body.WriteString(line)
+		body.WriteString("\n")
+	}
+	flush()
+	return sections
+}
+
+// NoteSectionLine returns the 1-based line just below the "## " heading
+// named section, matched case-insensitively, so an editor opens in the
+// section body.
+func NoteSectionLine(content, section string) (int, bool) {
+	want := strings.TrimSpace(section)
+	inFence := false
+	for i, line := range strings.Split(content, "\n") {
+		if isCodeFence(line) {
+			inFence = !inFence
+			continue
+		}
+		if title, ok := strings.CutPrefix(line, "## "); ok && !inFence && strings.EqualFold(strings.TrimSpace(title), want) {
+			return i + 2, true
+		}
+	}
+	return 0, false
+}
@@ -2,6 +2,7 @@ package tasks
 
 import (
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -10,7 +11,7 @@ func TestEnsureTaskNoteCreatesThenReuses(t *testing.T) {
 	notesDir := t.TempDir()
 	taskID := "task_abc"
 
-	path, created, err := EnsureTaskNote(notesDir, taskID)
+	path, created, err := EnsureTaskNote(notesDir, "", NoteData{TaskID: taskID})
 	if err != nil {
 		t.Fatalf("EnsureTaskNote first call error: %v", err)
 	}
@@ -27,7 +28,7 @@ func TestEnsureTaskNoteCreatesThenReuses(t *testing.T) {
 		t.Fatalf("expected template header in note file")
 	}
 
-	pathAgain, createdAgain, err := EnsureTaskNote(notesDir, taskID)
+	pathAgain, createdAgain, err := EnsureTaskNote(notesDir, "", NoteData{TaskID: taskID})
 	if err != nil {
 		t.Fatalf("EnsureTaskNote second call error: %v", err)
 	}
@@ -40,8 +41,72 @@ func TestEnsureTaskNoteCreatesThenReuses(t *testing.T) {
 }
 
 func TestEnsureTaskNoteRequiresTaskID(t *testing.T) {
-	_, _, err := EnsureTaskNote(t.TempDir(), "")
+	_, _, err := EnsureTaskNote(t.TempDir(), "", NoteData{})
 	if err == nil {
 		t.Fatalf("expected error for empty task id")
 	}
 }
+
+func TestNoteSectionsSplitsOnSecondLevelHeadings(t *testing.T) {
+	t.Parallel()
+
+	content := `# Task State
+
+## Current Objective
+Ship the attention view.
+
+## Next Actions
+- wire the UI
+- add tests
+
+## Blockers
+
+## Session Context
+### 2026-03-01
+notes
+`
+	sections := NoteSections(content)
+	expected := map[string]string{
+		NoteSectionObjective:      "Ship the attention view.",
+		NoteSectionNextActions:    "- wire the UI\n- add tests",
+		NoteSectionBlockers:       "",
+		NoteSectionSessionContext: "### 2026-03-01\nnotes",
+	}
+	if len(sections) != len(expected) {
+		t.Fatalf("unexpected sections: %#v", sections)
+	}
+	for heading, body := range expected {
+		if sections[heading] != body {
+			t.Fatalf("section %q = %q, want %q", heading, sections[heading], body)
+		}
+	}
+}
+
+func TestNoteSectionsSkipsHeadingsInCodeFences(t *testing.T) {
+	t.Parallel()
+
+	content := "## Status\n```md\n## Not a heading\n```\nok\n\n## Next Actions\n~~~\n## also code\n~~~\n- [ ] act\n"
+	sections := NoteSections(content)
+	expected := map[string]string{
+		NoteSectionStatus:      "```md\n## Not a heading\n```\nok",
+		NoteSectionNextActions: "~~~\n## also code\n~~~\n- [ ] act",
+	}
+	if !reflect.DeepEqual(sections, expected) {
+		t.Fatalf("unexpected sections: %#v", sections)
+	}
+	if line, ok := NoteSectionLine(content, "not a heading"); ok {
+		t.Fatalf("expected fenced heading to be skipped, got line %d", line)
+	}
+}
+
+func TestNoteSectionLine(t *testing.T) {
+	t.Parallel()
+
+	content := "# Task State\n\n## Current Objective\nShip it.\n\n## Next Actions\n- [ ] review\n"
+	if line, ok := NoteSectionLine(content, "next actions"); !ok || line != 7 {
+		t.Fatalf("NoteSectionLine = %d, %v; want 7", line, ok)
+	}
+	if _, ok := NoteSectionLine(content, "Blockers"); ok {
+		t.Fatalf("expected missing section")
+	}
+}
@@ -10,15 +10,49 @@ const (
 	SessionStatusUnknown SessionStatus = "unknown"
 )
 
+// AgentState describes what is running in an open session's pane: the agent
+// (or another program), or a bare shell left behind after the agent exited.
+type AgentState string
+
+const (
+	AgentStateRunning       AgentState = "running"
+	AgentStateExitedToShell AgentState = "exited_to_shell"
+	// AgentStateWaitingForInput means the pane's screen ends at an approval
+	// prompt or question for the user.
+	AgentStateWaitingForInput AgentState = "waiting_for_input"
+	AgentStateUnknown         AgentState = "unknown"
+)
+
 type TaskSession struct {
-	TaskID         string        `json:"task_id"`
-	Workspace      string        `json:"workspace"`
-	PaneID         int64         `json:"pane_id"`
+	TaskID    string `json:"task_id"`
+	Workspace string `json:"workspace"`
+	Domain    string `json:"domain"`
+	PaneID    int64  `json:"pane_id"`
+	// PaneTagged is set when the pane was spawned carrying the task ID user
+	// var. Sessions opened by older builds have untagged panes.
+	PaneTagged bool `json:"pane_tagged"`
+	// AuxPaneIDs are extra panes opened for the session, such as a note
+	// editor split; they are killed with the session.
+	AuxPaneIDs     []int64       `json:"aux_pane_ids"`
 	Cwd            string        `json:"cwd"`
 	Command        string        `json:"command"`
+	Profile        string        `json:"profile"`
 	Status         SessionStatus `json:"status"`
-	CodexSessionID string        `json:"codex_session_id"`
-	LastSeenAt     time.Time     `json:"last_seen_at"`
-	CreatedAt      time.Time     `json:"created_at"`
-	UpdatedAt      time.Time     `json:"updated_at"`
+	AgentState     AgentState    `json:"agent_state"`
+	AgentKind      string        `json:"agent_kind"`
+	AgentSessionID string        `json:"agent_session_id"`
+	SpawnedAt      time.Time     `json:"spawned_at"`
+	// AttentionAt is when the session started needing attention (waiting
+	// for input or back at a shell); zero while the agent is working.
+	AttentionAt time.Time `json:"attention_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// NeedsAttention reports whether an open session is blocked on the user: its
+// agent is waiting for input or has exited to a shell.
+func (s TaskSession) NeedsAttention() bool {
+	return s.Status == SessionStatusOpen &&
+		(s.AgentState == AgentStateWaitingForInput || s.AgentState == AgentStateExitedToShell)
 }
@@ -0,0 +1,125 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Session log events written under the note's Session Context section.
+const (
+	SessionLogSpawned   = "spawned"
+	SessionLogActivated = "activated"
+	SessionLogClosed    = "closed"
+	SessionLogDead      = "dead"
+)
+
+// SessionLogEntry is one session lifecycle event recorded in a task note.
+type SessionLogEntry struct {
+	At        time.Time
+	Event     string
+	PaneID    int64
+	Workspace string
+	Cwd       string
+	Profile   string
+}
+
+// Line renders the entry as a Markdown list item.
+func (e SessionLogEntry) Line() string {
+	return fmt.Sprintf("- %s %s pane=%d workspace=%s cwd=%s profile=%s",
+		e.At.UTC().Format(time.RFC3339), e.Event, e.PaneID, e.Workspace, e.Cwd, e.Profile)
+}
+
+// AppendSessionLog adds entry's line to the end of the note's
+// "## Session Context" section and reports whether content changed. The
+// section is appended when the note has none. Appending a line the section
+// already holds is a no-op, and every other byte of the note is kept.
+func AppendSessionLog(content string, entry SessionLogEntry) (string, bool) {
+	line := entry.Line()
+	lines := strings.SplitAfter(content, "\n")
+
+	heading, end := -1, len(lines)
+	inFence := false
+	for i, raw := range lines {
+		text := strings.TrimRight(raw, "\r\n")
+		if isCodeFence(text) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if heading < 0 {
+			if title, ok := strings.CutPrefix(text, "## "); ok && strings.EqualFold(strings.TrimSpace(title), NoteSectionSessionContext) {
+				heading = i
+			}
+			continue
+		}
+		if strings.HasPrefix(text, "# ") || strings.HasPrefix(text, "## ") {
+			end = i
+			break
+		}
+	}
+
+	if heading < 0 {
+		var b strings.Builder
+		b.WriteString(content)
+		if content != "" {
+			if !strings.HasSuffix(content, "\n") {
+				b.WriteString("\n")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("## " + NoteSectionSessionContext + "\n" + line + "\n")
+		return b.String(), true
+	}
+
+	insert := heading + 1
+	for i := end - 1; i > heading; i-- {
+		text := strings.TrimRight(lines[i], "\r\n")
+		if text == line {
+			return content, false
+		}
+		if insert == heading+1 && strings.TrimSpace(text) != "" {
+			insert = i + 1
+		}
+	}
+
+	newline := "\n"
+	if strings.HasSuffix(lines[heading], "\r\n") {
+		newline = "\r\n"
+	}
+	before := strings.Join(lines[:insert], "")
+	if !strings.HasSuffix(before, "\n") {
+		before += newline
+	}
+	return before + line + newline + strings.Join(lines[insert:], ""), true
+}
+
+func isCodeFence(line string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
+
+// AppendSessionLogFile appends entry to the note at path. A missing note is
+// not an error and is left missing.
+func AppendSessionLogFile(path string, entry SessionLogEntry) (bool, error) {
+	// #nosec G304 -- the note path is derived from the notes dir and task ID.
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("read task note: %w", err)
+	}
+	updated, changed := AppendSessionLog(string(content), entry)
+	if !changed {
+		return false, nil
+	}
+	if err := os.WriteFile(path, []byte(updated), 0o600); err != nil {
+		return false, fmt.Errorf("write task note: %w", err)
+	}
+	return true, nil
+}
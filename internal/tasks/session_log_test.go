@@ -0,0 +1,77 @@
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendSessionLog(t *testing.T) {
+	t.Parallel()
+
+	entry := SessionLogEntry{
+		At:        time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+		Event:     SessionLogSpawned,
+		PaneID:    12,
+		Workspace: "ttt-abc",
+		Cwd:       "/src/repo",
+		Profile:   "codex",
+	}
+	line := "- 2026-03-01T09:00:00Z spawned pane=12 workspace=ttt-abc cwd=/src/repo profile=codex"
+
+	cases := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"template", noteTemplate, noteTemplate + line + "\n"},
+		{"empty section before another", "## Session Context\n\n## Links\nx\n", "## Session Context\n" + line + "\n\n## Links\nx\n"},
+		{"after existing entries", "## Session Context\n- old\n\n\n# Appendix\n", "## Session Context\n- old\n" + line + "\n\n\n# Appendix\n"},
+		{"heading without trailing newline", "## Session Context", "## Session Context\n" + line + "\n"},
+		{"case-insensitive heading", "## session context  \n- old", "## session context  \n- old\n" + line + "\n"},
+		{"crlf", "## Session Context\r\n- old\r\n## Next\r\n", "## Session Context\r\n- old\r\n" + line + "\r\n## Next\r\n"},
+		{"missing heading", "## Status\nok\n", "## Status\nok\n\n## Session Context\n" + line + "\n"},
+		{"missing heading without trailing newline", "## Status\nok", "## Status\nok\n\n## Session Context\n" + line + "\n"},
+		{"empty note", "", "## Session Context\n" + line + "\n"},
+		{"heading inside code fence", "```\n## Session Context\n```\n", "```\n## Session Context\n```\n\n## Session Context\n" + line + "\n"},
+		{"heading-like line in fenced section body", "## Session Context\n```\n## not a heading\n```\n## Next\n", "## Session Context\n```\n## not a heading\n```\n" + line + "\n## Next\n"},
+	}
+	for _, tc := range cases {
+		got, changed := AppendSessionLog(tc.content, entry)
+		if !changed || got != tc.expected {
+			t.Fatalf("%s: AppendSessionLog changed=%v\n%q\nwant\n%q", tc.name, changed, got, tc.expected)
+		}
+		again, changed := AppendSessionLog(got, entry)
+		if changed || again != got {
+			t.Fatalf("%s: expected second append to be a no-op, got %q", tc.name, again)
+		}
+	}
+}
+
+func TestAppendSessionLogFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "task.md")
+	entry := SessionLogEntry{At: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC), Event: SessionLogClosed, PaneID: 3}
+	if changed, err := AppendSessionLogFile(path, entry); err != nil || changed {
+		t.Fatalf("expected missing note to be skipped, changed=%v err=%v", changed, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected note to stay missing, got %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("## Session Context\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if changed, err := AppendSessionLogFile(path, entry); err != nil || !changed {
+		t.Fatalf("AppendSessionLogFile changed=%v err=%v", changed, err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(content) != "## Session Context\n- 2026-03-01T09:00:00Z closed pane=3 workspace= cwd= profile=\n" {
+		t.Fatalf("unexpected note: %q", content)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	sqlitegorm "github.com/glebarez/sqlite"
@@ -18,6 +20,11 @@ type SQLiteStore struct {
 
 type sqliteTaskModel struct {
 	TaskID    string `gorm:"column:task_id;primaryKey"`
+	Title     string `gorm:"column:title"`
+	Tags      string `gorm:"column:tags"`
+	Priority  string `gorm:"column:priority"`
+	Status    string `gorm:"column:status"`
+	Due       string `gorm:"column:due"`
 	CreatedAt string `gorm:"column:created_at;not null"`
 	UpdatedAt string `gorm:"column:updated_at;not null"`
 }
@@ -40,11 +47,19 @@ func (sqliteTaskAliasModel) TableName() string { return "task_aliases" }
 type sqliteSessionModel struct {
 	TaskID         string `gorm:"column:task_id;primaryKey"`
 	Workspace      string `gorm:"column:workspace;not null"`
+	Domain         string `gorm:"column:domain"`
 	PaneID         int64  `gorm:"column:pane_id"`
+	PaneTagged     bool   `gorm:"column:pane_tagged"`
+	AuxPaneIDs     string `gorm:"column:aux_pane_ids"`
 	Cwd            string `gorm:"column:cwd;not null"`
 	Command        string `gorm:"column:command"`
+	Profile        string `gorm:"column:profile"`
 	Status         string `gorm:"column:status;not null"`
-	CodexSessionID string `gorm:"column:codex_session_id"`
+	AgentState     string `gorm:"column:agent_state"`
+	AgentKind      string `gorm:"column:agent_kind"`
+	AgentSessionID string `gorm:"column:agent_session_id"`
+	SpawnedAt      string `gorm:"column:spawned_at"`
+	AttentionAt    string `gorm:"column:attention_at"`
 	LastSeenAt     string `gorm:"column:last_seen_at"`
 	CreatedAt      string `gorm:"column:created_at;not null"`
 	UpdatedAt      string `gorm:"column:updated_at;not null"`
@@ -52,6 +67,21 @@ type sqliteSessionModel struct {
 
 func (sqliteSessionModel) TableName() string { return "sessions" }
 
+// sqliteEventModel keeps created_unix_nano beside created_at so time ranges
+// can be queried through an index.
+type sqliteEventModel struct {
+	ID              int64  `gorm:"column:id;primaryKey;autoIncrement"`
+	TaskID          string `gorm:"column:task_id;not null;index"`
+	Kind            string `gorm:"column:kind;not null"`
+	FromValue       string `gorm:"column:from_value"`
+	ToValue         string `gorm:"column:to_value"`
+	Detail          string `gorm:"column:detail"`
+	CreatedAt       string `gorm:"column:created_at;not null"`
+	CreatedUnixNano int64  `gorm:"column:created_unix_nano;not null"`
+}
+
+func (sqliteEventModel) TableName() string { return "events" }
+
 func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
 	if err := os.MkdirAll(filepath.Dir(dbPath), 0o750); err != nil {
 		return nil, fmt.Errorf("create sqlite parent dir: %w", err)
@@ -78,10 +108,26 @@ func (s *SQLiteStore) Close() error {
 }
 
 func (s *SQLiteStore) migrate(ctx context.Context) error {
+	// Notes indexed before front matter and links were synced into tasks
+	// must be read again; RefreshNoteIndex skips notes whose mtime matches.
+	hasMetadata, err := s.hasColumn(ctx, "tasks", "title")
+	if err != nil {
+		return err
+	}
+	hasLinks, err := s.hasColumn(ctx, "task_links", "alias_value")
+	if err != nil {
+		return err
+	}
+
 	statements := []string{
 		"PRAGMA foreign_keys = ON;",
 		`CREATE TABLE IF NOT EXISTS tasks (
 			task_id TEXT PRIMARY KEY,
+			title TEXT,
+			tags TEXT,
+			priority TEXT,
+			status TEXT,
+			due TEXT,
 			created_at TEXT NOT NULL,
 			updated_at TEXT NOT NULL
 		);`,
@@ -99,16 +145,64 @@ func (s *SQLiteStore) migrate(ctx context.Context) error {
 		`CREATE TABLE IF NOT EXISTS sessions (
 			task_id TEXT PRIMARY KEY,
 			workspace TEXT NOT NULL,
+			domain TEXT,
 			pane_id INTEGER NOT NULL DEFAULT 0,
+			pane_tagged INTEGER NOT NULL DEFAULT 0,
+			aux_pane_ids TEXT,
 			cwd TEXT NOT NULL,
 			command TEXT,
+			profile TEXT,
 			status TEXT NOT NULL,
-			codex_session_id TEXT,
+			agent_state TEXT,
+			agent_kind TEXT,
+			agent_session_id TEXT,
+			spawned_at TEXT,
+			attention_at TEXT,
 			last_seen_at TEXT,
 			created_at TEXT NOT NULL,
 			updated_at TEXT NOT NULL,
 			FOREIGN KEY(task_id) REFERENCES tasks(task_id) ON DELETE CASCADE
 		);`,
+		`CREATE TABLE IF NOT EXISTS events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			task_id TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			from_value TEXT,
+			to_value TEXT,
+			detail TEXT,
+			created_at TEXT NOT NULL,
+			created_unix_nano INTEGER NOT NULL DEFAULT 0,
+			FOREIGN KEY(task_id) REFERENCES tasks(task_id) ON DELETE CASCADE
+		);`,
+		"CREATE INDEX IF NOT EXISTS idx_events_task_id ON events(task_id);",
+		`CREATE TABLE IF NOT EXISTS note_versions (
+			task_id TEXT NOT NULL,
+			version INTEGER NOT NULL,
+			content TEXT NOT NULL,
+			source TEXT,
+			created_at TEXT NOT NULL,
+			PRIMARY KEY(task_id, version),
+			FOREIGN KEY(task_id) REFERENCES tasks(task_id) ON DELETE CASCADE
+		);`,
+		`CREATE VIRTUAL TABLE IF NOT EXISTS note_index USING fts5(
+			task_id UNINDEXED,
+			content,
+			tokenize = 'porter unicode61'
+		);`,
+		`CREATE TABLE IF NOT EXISTS task_links (
+			from_task_id TEXT NOT NULL,
+			alias_value TEXT NOT NULL,
+			to_task_id TEXT NOT NULL DEFAULT '',
+			created_at TEXT NOT NULL,
+			PRIMARY KEY(from_task_id, alias_value),
+			FOREIGN KEY(from_task_id) REFERENCES tasks(task_id) ON DELETE CASCADE
+		);`,
+		"CREATE INDEX IF NOT EXISTS idx_task_links_to_task_id ON task_links(to_task_id);",
+		`CREATE TABLE IF NOT EXISTS note_index_state (
+			task_id TEXT PRIMARY KEY,
+			mtime TEXT NOT NULL,
+			indexed_at TEXT NOT NULL
+		);`,
 	}
 
 	for _, statement := range statements {
@@ -116,6 +210,112 @@ func (s *SQLiteStore) migrate(ctx context.Context) error {
 			return fmt.Errorf("run sqlite migration statement: %w", err)
 		}
 	}
+
+	// Columns added after the initial schema; CREATE TABLE IF NOT EXISTS does
+	// not touch databases created by older builds.
+	columns := []struct {
+		table      string
+		column     string
+		definition string
+	}{
+		{table: "sessions", column: "domain", definition: "TEXT"},
+		{table: "sessions", column: "agent_state", definition: "TEXT"},
+		{table: "sessions", column: "spawned_at", definition: "TEXT"},
+		{table: "sessions", column: "agent_kind", definition: "TEXT"},
+		{table: "sessions", column: "agent_session_id", definition: "TEXT"},
+		{table: "sessions", column: "profile", definition: "TEXT"},
+		{table: "sessions", column: "attention_at", definition: "TEXT"},
+		{table: "sessions", column: "aux_pane_ids", definition: "TEXT"},
+		{table: "sessions", column: "pane_tagged", definition: "INTEGER NOT NULL DEFAULT 0"},
+		{table: "tasks", column: "title", definition: "TEXT"},
+		{table: "tasks", column: "tags", definition: "TEXT"},
+		{table: "tasks", column: "priority", definition: "TEXT"},
+		{table: "tasks", column: "status", definition: "TEXT"},
+		{table: "tasks", column: "due", definition: "TEXT"},
+		{table: "events", column: "created_unix_nano", definition: "INTEGER NOT NULL DEFAULT 0"},
+	}
+	for _, entry := range columns {
+		if err := s.ensureColumn(ctx, entry.table, entry.column, entry.definition); err != nil {
+			return err
+		}
+	}
+	if err := s.backfillEventTimes(ctx); err != nil {
+		return err
+	}
+	if err := s.db.WithContext(ctx).Exec("CREATE INDEX IF NOT EXISTS idx_events_created_unix_nano ON events(created_unix_nano);").Error; err != nil {
+		return fmt.Errorf("run sqlite migration statement: %w", err)
+	}
+
+	if !hasMetadata || !hasLinks {
+		if err := s.db.WithContext(ctx).Exec("DELETE FROM note_index_state;").Error; err != nil {
+			return fmt.Errorf("reset note index state: %w", err)
+		}
+	}
+
+	// Databases from before agent-neutral tracking only have Codex IDs; the
+	// old column is left in place but no longer read or written.
+	hasCodexColumn, err := s.hasColumn(ctx, "sessions", "codex_session_id")
+	if err != nil {
+		return err
+	}
+	if hasCodexColumn {
+		if err := s.db.WithContext(ctx).Exec(`UPDATE sessions
+			SET agent_kind = 'codex', agent_session_id = codex_session_id
+			WHERE COALESCE(agent_session_id, '') = '' AND COALESCE(codex_session_id, '') != '';`).Error; err != nil {
+			return fmt.Errorf("migrate codex session ids: %w", err)
+		}
+	}
+	return nil
+}
+
+// backfillEventTimes fills created_unix_nano for events written before the
+// column existed.
+func (s *SQLiteStore) backfillEventTimes(ctx context.Context) error {
+	models := make([]sqliteEventModel, 0)
+	if err := s.db.WithContext(ctx).Where("created_unix_nano = 0").Find(&models).Error; err != nil {
+		return fmt.Errorf("query events to backfill: %w", err)
+	}
+	for _, model := range models {
+		createdAt, err := parseTime(model.CreatedAt)
+		if err != nil {
+			continue
+		}
+		if err := s.db.WithContext(ctx).Model(&sqliteEventModel{}).
+			Where("id = ?", model.ID).
+			Update("created_unix_nano", unixNano(createdAt)).Error; err != nil {
+			return fmt.Errorf("backfill event time: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) hasColumn(ctx context.Context, table, column string) (bool, error) {
+	type columnInfo struct {
+		Name string `gorm:"column:name"`
+	}
+
+	existing := make([]columnInfo, 0)
+	if err := s.db.WithContext(ctx).Raw(fmt.Sprintf("PRAGMA table_info(%s);", table)).Scan(&existing).Error; err != nil {
+		return false, fmt.Errorf("inspect %s columns: %w", table, err)
+	}
+	for _, info := range existing {
+		if info.Name == column {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *SQLiteStore) ensureColumn(ctx context.Context, table, column, definition string) error {
+	exists, err := s.hasColumn(ctx, table, column)
+	if err != nil || exists {
+		return err
+	}
+
+	statement := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", table, column, definition)
+	if err := s.db.WithContext(ctx).Exec(statement).Error; err != nil {
+		return fmt.Errorf("add %s.%s column: %w", table, column, err)
+	}
 	return nil
 }
 
@@ -131,6 +331,42 @@ func (s *SQLiteStore) CreateTask(ctx context.Context, task Task) error {
 	return nil
 }
 
+// UpdateTaskMetadata stores the task's note metadata and bumps its
+// updated_at. Tags are stored comma-separated, so commas inside a tag are
+// dropped.
+func (s *SQLiteStore) UpdateTaskMetadata(ctx context.Context, taskID string, meta NoteMetadata, at time.Time) error {
+	tags := make([]string, 0, len(meta.Tags))
+	for _, tag := range meta.Tags {
+		tags = append(tags, strings.ReplaceAll(tag, ",", ""))
+	}
+	result := s.db.WithContext(ctx).Model(&sqliteTaskModel{}).Where("task_id = ?", taskID).Updates(map[string]any{
+		"title":      meta.Title,
+		"tags":       strings.Join(tags, ","),
+		"priority":   meta.Priority,
+		"status":     meta.Status,
+		"due":        meta.Due,
+		"updated_at": formatTime(at),
+	})
+	if result.Error != nil {
+		return fmt.Errorf("update task metadata: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrTaskNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteStore) GetTask(ctx context.Context, taskID string) (Task, bool, error) {
+	var model sqliteTaskModel
+	if err := s.db.WithContext(ctx).Where("task_id = ?", taskID).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Task{}, false, nil
+		}
+		return Task{}, false, fmt.Errorf("query task: %w", err)
+	}
+	return fromTaskModel(model), true, nil
+}
+
 func (s *SQLiteStore) GetTaskByAlias(ctx context.Context, aliasValue string) (Task, bool, error) {
 	var alias sqliteTaskAliasModel
 	if err := s.db.WithContext(ctx).Where("alias_value = ?", aliasValue).First(&alias).Error; err != nil {
@@ -321,6 +557,33 @@ func (s *SQLiteStore) ListSessionStatusCounts(ctx context.Context) ([]GroupCount
 	return result, nil
 }
 
+func (s *SQLiteStore) AppendEvent(ctx context.Context, event TaskEvent) error {
+	model := toEventModel(event)
+	if err := s.db.WithContext(ctx).Create(&model).Error; err != nil {
+		return fmt.Errorf("insert event: %w", err)
+	}
+	return nil
+}
+
+// ListEvents returns events created at or after since, oldest first. A zero
+// since returns every event.
+func (s *SQLiteStore) ListEvents(ctx context.Context, since time.Time) ([]TaskEvent, error) {
+	query := s.db.WithContext(ctx)
+	if !since.IsZero() {
+		query = query.Where("created_unix_nano >= ?", unixNano(since))
+	}
+	models := make([]sqliteEventModel, 0)
+	if err := query.Order("id ASC").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("query events: %w", err)
+	}
+
+	result := make([]TaskEvent, 0, len(models))
+	for _, model := range models {
+		result = append(result, fromEventModel(model))
+	}
+	return result, nil
+}
+
 func taskAliasGroupByColumn(groupBy string) (string, error) {
 	switch groupBy {
 	case "repo":
@@ -339,7 +602,25 @@ func fromTaskModel(model sqliteTaskModel) Task {
 		ID:        model.TaskID,
 		CreatedAt: createdAt,
 		UpdatedAt: updatedAt,
+		Metadata: NoteMetadata{
+			Title:    model.Title,
+			Tags:     parseTags(model.Tags),
+			Priority: model.Priority,
+			Status:   model.Status,
+			Due:      model.Due,
+		},
+	}
+}
+
+// parseTags reads the comma-separated tags column.
+func parseTags(value string) []string {
+	tags := make([]string, 0)
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
 	}
+	return tags
 }
 
 func toAliasModel(alias TaskAlias) sqliteTaskAliasModel {
@@ -400,11 +681,19 @@ func toSessionModel(session TaskSession) sqliteSessionModel {
 	return sqliteSessionModel{
 		TaskID:         session.TaskID,
 		Workspace:      session.Workspace,
+		Domain:         session.Domain,
 		PaneID:         session.PaneID,
+		PaneTagged:     session.PaneTagged,
+		AuxPaneIDs:     formatPaneIDs(session.AuxPaneIDs),
 		Cwd:            session.Cwd,
 		Command:        session.Command,
+		Profile:        session.Profile,
 		Status:         string(session.Status),
-		CodexSessionID: session.CodexSessionID,
+		AgentState:     string(session.AgentState),
+		AgentKind:      session.AgentKind,
+		AgentSessionID: session.AgentSessionID,
+		SpawnedAt:      formatTime(session.SpawnedAt),
+		AttentionAt:    formatTime(session.AttentionAt),
 		LastSeenAt:     formatTime(session.LastSeenAt),
 		CreatedAt:      formatTime(session.CreatedAt),
 		UpdatedAt:      formatTime(session.UpdatedAt),
@@ -412,23 +701,78 @@ func toSessionModel(session TaskSession) sqliteSessionModel {
 }
 
 func fromSessionModel(model sqliteSessionModel) TaskSession {
+	spawnedAt, _ := parseTime(model.SpawnedAt)
+	attentionAt, _ := parseTime(model.AttentionAt)
 	lastSeenAt, _ := parseTime(model.LastSeenAt)
 	createdAt, _ := parseTime(model.CreatedAt)
 	updatedAt, _ := parseTime(model.UpdatedAt)
 	return TaskSession{
 		TaskID:         model.TaskID,
 		Workspace:      model.Workspace,
+		Domain:         model.Domain,
 		PaneID:         model.PaneID,
+		PaneTagged:     model.PaneTagged,
+		AuxPaneIDs:     parsePaneIDs(model.AuxPaneIDs),
 		Cwd:            model.Cwd,
 		Command:        model.Command,
+		Profile:        model.Profile,
 		Status:         SessionStatus(model.Status),
-		CodexSessionID: model.CodexSessionID,
+		AgentState:     AgentState(model.AgentState),
+		AgentKind:      model.AgentKind,
+		AgentSessionID: model.AgentSessionID,
+		SpawnedAt:      spawnedAt,
+		AttentionAt:    attentionAt,
 		LastSeenAt:     lastSeenAt,
 		CreatedAt:      createdAt,
 		UpdatedAt:      updatedAt,
 	}
 }
 
+func toEventModel(event TaskEvent) sqliteEventModel {
+	return sqliteEventModel{
+		ID:              event.ID,
+		TaskID:          event.TaskID,
+		Kind:            string(event.Kind),
+		FromValue:       event.From,
+		ToValue:         event.To,
+		Detail:          event.Detail,
+		CreatedAt:       formatTime(event.CreatedAt),
+		CreatedUnixNano: unixNano(event.CreatedAt),
+	}
+}
+
+func fromEventModel(model sqliteEventModel) TaskEvent {
+	createdAt, _ := parseTime(model.CreatedAt)
+	return TaskEvent{
+		ID:        model.ID,
+		TaskID:    model.TaskID,
+		Kind:      EventKind(model.Kind),
+		From:      model.FromValue,
+		To:        model.ToValue,
+		Detail:    model.Detail,
+		CreatedAt: createdAt,
+	}
+}
+
+// formatPaneIDs stores pane IDs as a comma-separated list.
+func formatPaneIDs(ids []int64) string {
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.FormatInt(id, 10))
+	}
+	return strings.Join(parts, ",")
+}
+
+func parsePaneIDs(value string) []int64 {
+	ids := make([]int64, 0)
+	for _, part := range strings.Split(value, ",") {
+		if id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
 func formatTime(value time.Time) string {
 	return value.UTC().Format(time.RFC3339Nano)
 }
@@ -436,3 +780,12 @@ func formatTime(value time.Time) string {
 func parseTime(value string) (time.Time, error) {
 	return time.Parse(time.RFC3339Nano, value)
 }
+
+// unixNano is value's Unix time in nanoseconds, with times before 1970
+// (which UnixNano leaves undefined when far off) stored as 0.
+func unixNano(value time.Time) int64 {
+	if value.Before(time.Unix(0, 0)) {
+		return 0
+	}
+	return value.UnixNano()
+}
@@ -1,9 +1,14 @@
 package tasks
 
 import (
+	"context"
 	"errors"
+	"path/filepath"
 	"testing"
 	"time"
+
+	sqlitegorm "github.com/glebarez/sqlite"
+	"gorm.io/gorm"
 )
 
 func TestSQLiteStoreServicePrePRToPRLink(t *testing.T) {
@@ -168,10 +173,15 @@ func TestSQLiteStoreUpsertAndListSessions(t *testing.T) {
 		TaskID:         task.ID,
 		Workspace:      "task-session",
 		PaneID:         42,
+		AuxPaneIDs:     []int64{43, 47},
 		Cwd:            "/tmp/repo",
 		Command:        "zsh",
+		Profile:        "shell",
 		Status:         SessionStatusOpen,
-		CodexSessionID: "codex-123",
+		AgentKind:      "codex",
+		AgentSessionID: "codex-123",
+		AgentState:     AgentStateWaitingForInput,
+		AttentionAt:    now,
 		LastSeenAt:     now,
 		CreatedAt:      now,
 		UpdatedAt:      now,
@@ -187,9 +197,12 @@ func TestSQLiteStoreUpsertAndListSessions(t *testing.T) {
 	if !found {
 		t.Fatalf("expected session to exist")
 	}
-	if got.Workspace != "task-session" || got.PaneID != 42 || got.Status != SessionStatusOpen {
+	if got.Workspace != "task-session" || got.PaneID != 42 || got.Status != SessionStatusOpen || got.AgentSessionID != "codex-123" || got.Profile != "shell" || !got.AttentionAt.Equal(now) || !got.NeedsAttention() {
 		t.Fatalf("unexpected session result: %#v", got)
 	}
+	if len(got.AuxPaneIDs) != 2 || got.AuxPaneIDs[0] != 43 || got.AuxPaneIDs[1] != 47 {
+		t.Fatalf("unexpected aux pane ids: %#v", got.AuxPaneIDs)
+	}
 
 	list, err := h.Store.ListSessions(h.Ctx)
 	if err != nil {
@@ -207,3 +220,159 @@ func TestSQLiteStoreUpsertAndListSessions(t *testing.T) {
 		t.Fatalf("unexpected group counts: %#v", groupCounts)
 	}
 }
+
+func TestSQLiteStoreMigratesLegacySessionsTable(t *testing.T) {
+	t.Parallel()
+
+	dbPath := filepath.Join(t.TempDir(), "legacy.db")
+	legacy, err := gorm.Open(sqlitegorm.Open(dbPath), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open legacy db: %v", err)
+	}
+	if err := legacy.Exec(`CREATE TABLE sessions (
+		task_id TEXT PRIMARY KEY,
+		workspace TEXT NOT NULL,
+		pane_id INTEGER NOT NULL DEFAULT 0,
+		cwd TEXT NOT NULL,
+		command TEXT,
+		status TEXT NOT NULL,
+		codex_session_id TEXT,
+		last_seen_at TEXT,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	);`).Error; err != nil {
+		t.Fatalf("create legacy sessions table: %v", err)
+	}
+	if err := legacy.Exec(`INSERT INTO sessions (task_id, workspace, pane_id, cwd, status, codex_session_id, created_at, updated_at)
+		VALUES ('task_legacy_codex', 'task-old', 0, '/srv/old', 'closed', 'codex-legacy', '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z');`).Error; err != nil {
+		t.Fatalf("insert legacy session: %v", err)
+	}
+	legacyDB, err := legacy.DB()
+	if err != nil {
+		t.Fatalf("legacy DB handle: %v", err)
+	}
+	_ = legacyDB.Close()
+
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore on legacy db: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = store.Close()
+	})
+
+	ctx := context.Background()
+	task, _, err := NewService(store).GetOrCreatePrePRTask(ctx, "owner/repo", "feature/legacy")
+	if err != nil {
+		t.Fatalf("GetOrCreatePrePRTask: %v", err)
+	}
+	now := time.Now().UTC()
+	if err := store.UpsertSession(ctx, TaskSession{
+		TaskID:     task.ID,
+		Workspace:  "task-legacy",
+		Domain:     "SSH:devbox",
+		Cwd:        "/srv/repo",
+		Status:     SessionStatusOpen,
+		AgentState: AgentStateExitedToShell,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+
+	got, found, err := store.GetSessionByTaskID(ctx, task.ID)
+	if err != nil || !found {
+		t.Fatalf("GetSessionByTaskID found=%v err=%v", found, err)
+	}
+	if got.Domain != "SSH:devbox" {
+		t.Fatalf("expected migrated domain column to round-trip, got %q", got.Domain)
+	}
+	if got.AgentState != AgentStateExitedToShell {
+		t.Fatalf("expected migrated agent_state column to round-trip, got %q", got.AgentState)
+	}
+
+	migrated, found, err := store.GetSessionByTaskID(ctx, "task_legacy_codex")
+	if err != nil || !found {
+		t.Fatalf("GetSessionByTaskID(legacy) found=%v err=%v", found, err)
+	}
+	if migrated.AgentKind != "codex" || migrated.AgentSessionID != "codex-legacy" {
+		t.Fatalf("expected codex_session_id to migrate to agent columns, got %#v", migrated)
+	}
+}
+
+func TestSQLiteStoreAppendAndListEvents(t *testing.T) {
+	t.Parallel()
+
+	h := newSQLiteTestHarness(t)
+	task, _, err := h.Service.GetOrCreatePrePRTask(h.Ctx, "owner/repo", "feature/events")
+	if err != nil {
+		t.Fatalf("GetOrCreatePrePRTask: %v", err)
+	}
+
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	events := []TaskEvent{
+		{TaskID: task.ID, Kind: EventKindSessionStatus, From: "", To: "open", CreatedAt: base},
+		{TaskID: task.ID, Kind: EventKindSessionStatus, From: "open", To: "closed", Detail: "pane gone", CreatedAt: base.Add(90 * time.Minute)},
+	}
+	for _, event := range events {
+		if err := h.Store.AppendEvent(h.Ctx, event); err != nil {
+			t.Fatalf("AppendEvent: %v", err)
+		}
+	}
+
+	all, err := h.Store.ListEvents(h.Ctx, time.Time{})
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	if len(all) != 2 || all[0].To != "open" || all[1].To != "closed" || all[1].Detail != "pane gone" {
+		t.Fatalf("unexpected events: %#v", all)
+	}
+	if all[0].ID == 0 || all[1].ID <= all[0].ID {
+		t.Fatalf("expected increasing event ids: %#v", all)
+	}
+
+	recent, err := h.Store.ListEvents(h.Ctx, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("ListEvents(since): %v", err)
+	}
+	if len(recent) != 1 || recent[0].From != "open" {
+		t.Fatalf("unexpected events since cutoff: %#v", recent)
+	}
+}
+
+func TestSQLiteStoreListEventsBackfillsAndCutsOffPrecisely(t *testing.T) {
+	t.Parallel()
+
+	h := newSQLiteTestHarness(t)
+	task, _, err := h.Service.GetOrCreatePrePRTask(h.Ctx, "owner/repo", "feature/event-times")
+	if err != nil {
+		t.Fatalf("GetOrCreatePrePRTask: %v", err)
+	}
+
+	// An event from before created_unix_nano existed, and fractional times
+	// that RFC 3339 text would not compare correctly.
+	if err := h.Store.db.Exec(`INSERT INTO events (task_id, kind, to_value, created_at)
+		VALUES (?, ?, 'old', '2026-03-01T09:00:00.5Z');`, task.ID, EventKindSessionStatus).Error; err != nil {
+		t.Fatalf("insert old event: %v", err)
+	}
+	if err := h.Store.migrate(h.Ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for _, event := range []TaskEvent{
+		{TaskID: task.ID, Kind: EventKindSessionStatus, To: "early", CreatedAt: base.Add(123456 * time.Microsecond)},
+		{TaskID: task.ID, Kind: EventKindSessionStatus, To: "late", CreatedAt: base.Add(time.Second)},
+	} {
+		if err := h.Store.AppendEvent(h.Ctx, event); err != nil {
+			t.Fatalf("AppendEvent: %v", err)
+		}
+	}
+
+	events, err := h.Store.ListEvents(h.Ctx, base.Add(200*time.Millisecond))
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	if len(events) != 2 || events[0].To != "old" || events[1].To != "late" {
+		t.Fatalf("unexpected events since cutoff: %#v", events)
+	}
+}
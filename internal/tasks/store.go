@@ -6,8 +6,10 @@ import (
 )
 
 var (
-	ErrAliasAlreadyBound = errors.New("alias already bound to a different task")
-	ErrTaskNotFound      = errors.New("task not found")
+	ErrAliasAlreadyBound     = errors.New("alias already bound to a different task")
+	ErrTaskNotFound          = errors.New("task not found")
+	ErrInvalidFrontMatter    = errors.New("invalid note front matter")
+	ErrNoteMetadataNotSynced = errors.New("note metadata not synced")
 )
 
 type Store interface {
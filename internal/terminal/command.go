@@ -0,0 +1,48 @@
+package terminal
+
+import (
+	"sort"
+	"strings"
+)
+
+// ShellCommand builds a pane program that runs setup (a shell snippet, may be
+// empty), then argv, and finally execs the user's login shell. Keeping the
+// shell around means an agent that exits leaves the pane open rather than
+// closing it, so its output stays readable and the exit is observable.
+//
+// argv runs as a job-controlled foreground job ("set -m"), so while it runs
+// the terminal reports argv, not the wrapping sh, as the pane's foreground
+// process.
+func ShellCommand(setup string, argv []string) []string {
+	script := setup
+	if len(argv) > 0 {
+		script += `set -m; "$@"; `
+	}
+	script += `exec "${SHELL:-/bin/sh}" -l`
+	return append([]string{"sh", "-c", script, "sh"}, argv...)
+}
+
+// ExecCommand builds a pane program that runs setup (a shell snippet, may be
+// empty) and then execs argv, so the pane closes when argv exits.
+func ExecCommand(setup string, argv []string) []string {
+	if setup == "" {
+		return argv
+	}
+	return append([]string{"sh", "-c", setup + `exec "$@"`, "sh"}, argv...)
+}
+
+// ShellQuote quotes value for safe use as a single POSIX shell word.
+func ShellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// SortedKeys returns the keys of values in sorted order, so maps turn into
+// deterministic command lines.
+func SortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,63 @@
+package terminal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+var (
+	ErrPaneNotFound      = errors.New("pane not found")
+	ErrMuxUnavailable    = errors.New("terminal multiplexer unavailable")
+	ErrCLIMissing        = errors.New("terminal CLI not installed")
+	ErrTimeout           = errors.New("terminal CLI timed out")
+	ErrDomainUnsupported = errors.New("domain cannot be queried")
+)
+
+// ErrorPatterns lists lowercase stderr fragments that identify a backend's
+// failure modes.
+type ErrorPatterns struct {
+	PaneNotFound   []string
+	MuxUnavailable []string
+}
+
+// ClassifyExecError wraps err with the matching sentinel error, keeping the
+// original error in the chain. Unrecognized errors are returned unchanged.
+func ClassifyExecError(err error, patterns ErrorPatterns) error {
+	if err == nil {
+		return nil
+	}
+
+	switch {
+	case errors.Is(err, exec.ErrNotFound):
+		return fmt.Errorf("%w: %w", ErrCLIMissing, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+
+	detail := strings.ToLower(err.Error())
+	var execErr *ExecError
+	if errors.As(err, &execErr) {
+		detail = strings.ToLower(execErr.Stderr)
+	}
+	// Connection failures can mention the target pane too, so they win.
+	switch {
+	case containsAny(detail, patterns.MuxUnavailable):
+		return fmt.Errorf("%w: %w", ErrMuxUnavailable, err)
+	case containsAny(detail, patterns.PaneNotFound):
+		return fmt.Errorf("%w: %w", ErrPaneNotFound, err)
+	default:
+		return err
+	}
+}
+
+func containsAny(value string, fragments []string) bool {
+	for _, fragment := range fragments {
+		if strings.Contains(value, fragment) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package terminal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+)
+
+func TestClassifyExecError(t *testing.T) {
+	t.Parallel()
+
+	patterns := ErrorPatterns{
+		PaneNotFound:   []string{"pane not found"},
+		MuxUnavailable: []string{"failed to connect"},
+	}
+
+	cases := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "missing cli", err: &ExecError{Name: "wezterm", Err: exec.ErrNotFound}, want: ErrCLIMissing},
+		{name: "timeout", err: &ExecError{Name: "wezterm", Err: fmt.Errorf("%w: signal: killed", context.DeadlineExceeded)}, want: ErrTimeout},
+		{name: "pane", err: &ExecError{Name: "wezterm", ExitCode: 1, Stderr: "Error: Pane Not Found", Err: errors.New("exit status 1")}, want: ErrPaneNotFound},
+		{name: "mux", err: &ExecError{Name: "wezterm", ExitCode: 1, Stderr: "failed to connect to socket", Err: errors.New("exit status 1")}, want: ErrMuxUnavailable},
+		{name: "plain error text", err: errors.New("failed to connect"), want: ErrMuxUnavailable},
+	}
+	for _, tc := range cases {
+		got := ClassifyExecError(tc.err, patterns)
+		if !errors.Is(got, tc.want) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+		if !errors.Is(got, tc.err) {
+			t.Fatalf("%s: expected original error to stay in chain, got %v", tc.name, got)
+		}
+	}
+
+	unknown := &ExecError{Name: "wezterm", ExitCode: 2, Stderr: "usage", Err: errors.New("exit status 2")}
+	if got := ClassifyExecError(unknown, patterns); got != unknown {
+		t.Fatalf("expected unrecognized error unchanged, got %v", got)
+	}
+}
+
+func TestDefaultExecReportsMissingCLI(t *testing.T) {
+	t.Parallel()
+
+	_, err := DefaultExec(context.Background(), "ttt-definitely-not-installed")
+	var execErr *ExecError
+	if !errors.As(err, &execErr) {
+		t.Fatalf("expected *ExecError, got %T (%v)", err, err)
+	}
+	if !errors.Is(err, exec.ErrNotFound) {
+		t.Fatalf("expected exec.ErrNotFound in chain, got %v", err)
+	}
+}
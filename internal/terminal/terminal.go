@@ -0,0 +1,74 @@
+package terminal
+
+import "context"
+
+// TaskIDUserVar tags panes spawned for a task. Pane IDs are reused after a
+// terminal restarts, so a pane only belongs to a task when this var matches.
+const TaskIDUserVar = "TTT_TASK_ID"
+
+// Pane is a live pane as reported by a terminal backend. Workspace is the
+// backend's grouping for task panes (a WezTerm workspace, a tmux session).
+// ForegroundProcess is the program currently in the foreground of the pane,
+// when the backend's listing reports it; empty means unknown.
+// UserVarsUnknown is set when the listing has no user vars at all, as with
+// stock WezTerm, so the pane can't be told apart by TaskIDUserVar.
+type Pane struct {
+	PaneID            int64             `json:"pane_id"`
+	Workspace         string            `json:"workspace"`
+	Domain            string            `json:"domain,omitempty"`
+	UserVars          map[string]string `json:"user_vars,omitempty"`
+	UserVarsUnknown   bool              `json:"user_vars_unknown,omitempty"`
+	ForegroundProcess string            `json:"foreground_process,omitempty"`
+}
+
+// SpawnOptions describes where a new pane should be created. Backends
+// without a notion of domains ignore Domain. UserVars are attached to the new
+// pane and reported back by ListPanes. Argv, when set, is run in the pane
+// before it drops to the user's shell (see ShellCommand); Env is added to the
+// pane's environment.
+type SpawnOptions struct {
+	Workspace string
+	Cwd       string
+	Domain    string
+	UserVars  map[string]string
+	Argv      []string
+	Env       map[string]string
+}
+
+// SplitOptions describes a pane split off an existing one. Argv runs in the
+// new pane, which closes when Argv exits; UserVars are attached as for Spawn.
+type SplitOptions struct {
+	Cwd      string
+	UserVars map[string]string
+	Argv     []string
+}
+
+// BelongsToTask reports whether the pane was spawned for taskID.
+func (p Pane) BelongsToTask(taskID string) bool {
+	return taskID != "" && p.UserVars[TaskIDUserVar] == taskID
+}
+
+// Client is the control surface ttt needs from a terminal multiplexer.
+type Client interface {
+	Spawn(ctx context.Context, opts SpawnOptions) (int64, error)
+	// SplitPane splits paneID and returns the new pane beside it.
+	SplitPane(ctx context.Context, paneID int64, opts SplitOptions) (int64, error)
+	ActivatePane(ctx context.Context, paneID int64) error
+	KillPane(ctx context.Context, paneID int64) error
+	ListPanes(ctx context.Context) ([]Pane, error)
+	// GetText returns the pane's visible screen contents.
+	GetText(ctx context.Context, paneID int64) (string, error)
+	// SendText pastes text into the pane as a bracketed paste and then
+	// presses Enter, submitting it to the program reading the pane's input.
+	SendText(ctx context.Context, paneID int64, text string) error
+}
+
+// DomainPaneLister is implemented by backends whose remote domains keep
+// their panes while detached. ListDomainPanes asks the domain's own
+// multiplexer, so it answers whether or not the domain is attached here, and
+// returns ErrDomainUnsupported for domains it cannot ask.
+type DomainPaneLister interface {
+	ListDomainPanes(ctx context.Context, domain string) ([]Pane, error)
+}
+
+type ExecFunc func(ctx context.Context, name string, args ...string) ([]byte, error)
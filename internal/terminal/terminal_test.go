@@ -0,0 +1,37 @@
+package terminal
+
+import "testing"
+
+func TestPaneBelongsToTask(t *testing.T) {
+	t.Parallel()
+
+	tagged := Pane{PaneID: 4, UserVars: map[string]string{TaskIDUserVar: "task_1"}}
+	if !tagged.BelongsToTask("task_1") {
+		t.Fatalf("expected tagged pane to belong to task_1")
+	}
+	if tagged.BelongsToTask("task_2") {
+		t.Fatalf("expected tagged pane not to belong to task_2")
+	}
+	if (Pane{PaneID: 4}).BelongsToTask("task_1") {
+		t.Fatalf("expected untagged pane not to belong to any task")
+	}
+	if (Pane{PaneID: 4}).BelongsToTask("") {
+		t.Fatalf("expected empty task id never to match")
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"":            "''",
+		"plain":       "'plain'",
+		"it's":        `'it'\''s'`,
+		"$HOME; rm *": "'$HOME; rm *'",
+	}
+	for input, expected := range cases {
+		if got := ShellQuote(input); got != expected {
+			t.Fatalf("ShellQuote(%q) = %q, want %q", input, got, expected)
+		}
+	}
+}
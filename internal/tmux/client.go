@@ -0,0 +1,218 @@
+package tmux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"term-workspaces/internal/terminal"
+)
+
+var errorPatterns = terminal.ErrorPatterns{
+	PaneNotFound:   []string{"can't find pane", "can't find window"},
+	MuxUnavailable: []string{"no server running", "error connecting to", "failed to connect"},
+}
+
+// listPanesFormat reads back the task identity option set by Spawn (tmux
+// expands unset user options to an empty string) and the foreground command.
+const listPanesFormat = "#{pane_id}\t#{session_name}\t#{@" + terminal.TaskIDUserVar + "}\t#{pane_current_command}"
+
+// CLIClient drives tmux through its command line. Task workspaces map to tmux
+// sessions; pane IDs are tmux's "%N" pane identifiers without the prefix.
+// tmux has no multiplexer domains, so SpawnOptions.Domain is ignored.
+type CLIClient struct {
+	exec terminal.ExecFunc
+}
+
+var _ terminal.Client = (*CLIClient)(nil)
+
+func NewCLIClient() *CLIClient {
+	return &CLIClient{exec: terminal.DefaultExec}
+}
+
+func NewCLIClientWithExec(execFn terminal.ExecFunc) *CLIClient {
+	return &CLIClient{exec: execFn}
+}
+
+func (c *CLIClient) Spawn(ctx context.Context, opts terminal.SpawnOptions) (int64, error) {
+	var args []string
+	if c.hasSession(ctx, opts.Workspace) {
+		args = []string{"new-window", "-t", exactSession(opts.Workspace) + ":", "-P", "-F", "#{pane_id}"}
+	} else {
+		args = []string{"new-session", "-d", "-s", opts.Workspace, "-P", "-F", "#{pane_id}"}
+	}
+	if strings.TrimSpace(opts.Cwd) != "" {
+		args = append(args, "-c", opts.Cwd)
+	}
+	for _, name := range terminal.SortedKeys(opts.Env) {
+		args = append(args, "-e", name+"="+opts.Env[name])
+	}
+	if len(opts.Argv) > 0 {
+		args = append(args, terminal.ShellCommand("", opts.Argv)...)
+	}
+
+	output, err := c.run(ctx, args...)
+	if err != nil {
+		return 0, fmt.Errorf("tmux %s: %w", args[0], err)
+	}
+	paneID, err := parsePaneID(strings.TrimSpace(string(output)))
+	if err != nil {
+		return 0, err
+	}
+
+	// User vars become pane-scoped user options ("@NAME"), which survive
+	// until the pane closes and can be read back through list-panes.
+	target := paneTarget(paneID)
+	for _, name := range terminal.SortedKeys(opts.UserVars) {
+		if _, err := c.run(ctx, "set-option", "-p", "-t", target, "@"+name, opts.UserVars[name]); err != nil {
+			return 0, fmt.Errorf("tmux set-option %s @%s: %w", target, name, err)
+		}
+	}
+	return paneID, nil
+}
+
+// SplitPane opens a pane to the right of paneID taking 40% of its width.
+func (c *CLIClient) SplitPane(ctx context.Context, paneID int64, opts terminal.SplitOptions) (int64, error) {
+	target := paneTarget(paneID)
+	args := []string{"split-window", "-h", "-l", "40%", "-t", target, "-P", "-F", "#{pane_id}"}
+	if strings.TrimSpace(opts.Cwd) != "" {
+		args = append(args, "-c", opts.Cwd)
+	}
+	args = append(args, terminal.ExecCommand("", opts.Argv)...)
+
+	output, err := c.run(ctx, args...)
+	if err != nil {
+		return 0, fmt.Errorf("tmux split-window %s: %w", target, err)
+	}
+	newPaneID, err := parsePaneID(strings.TrimSpace(string(output)))
+	if err != nil {
+		return 0, err
+	}
+	newTarget := paneTarget(newPaneID)
+	for _, name := range terminal.SortedKeys(opts.UserVars) {
+		if _, err := c.run(ctx, "set-option", "-p", "-t", newTarget, "@"+name, opts.UserVars[name]); err != nil {
+			return 0, fmt.Errorf("tmux set-option %s @%s: %w", newTarget, name, err)
+		}
+	}
+	return newPaneID, nil
+}
+
+func (c *CLIClient) ActivatePane(ctx context.Context, paneID int64) error {
+	target := paneTarget(paneID)
+	if _, err := c.run(ctx, "select-window", "-t", target); err != nil {
+		return fmt.Errorf("tmux select-window %s: %w", target, err)
+	}
+	if _, err := c.run(ctx, "select-pane", "-t", target); err != nil {
+		return fmt.Errorf("tmux select-pane %s: %w", target, err)
+	}
+	// switch-client needs an attached client; when ttt runs outside tmux the
+	// pane is still selected for the next attach, so the failure is ignored.
+	_, _ = c.run(ctx, "switch-client", "-t", target)
+	return nil
+}
+
+func (c *CLIClient) KillPane(ctx context.Context, paneID int64) error {
+	target := paneTarget(paneID)
+	if _, err := c.run(ctx, "kill-pane", "-t", target); err != nil {
+		return fmt.Errorf("tmux kill-pane %s: %w", target, err)
+	}
+	return nil
+}
+
+func (c *CLIClient) GetText(ctx context.Context, paneID int64) (string, error) {
+	target := paneTarget(paneID)
+	output, err := c.run(ctx, "capture-pane", "-p", "-t", target)
+	if err != nil {
+		return "", fmt.Errorf("tmux capture-pane %s: %w", target, err)
+	}
+	return string(output), nil
+}
+
+// sendTextBuffer is the tmux paste buffer SendText stages text in; it is
+// deleted again by paste-buffer -d.
+const sendTextBuffer = "ttt-send-text"
+
+func (c *CLIClient) SendText(ctx context.Context, paneID int64, text string) error {
+	target := paneTarget(paneID)
+	steps := [][]string{
+		{"set-buffer", "-b", sendTextBuffer, "--", text},
+		{"paste-buffer", "-p", "-d", "-b", sendTextBuffer, "-t", target},
+		{"send-keys", "-t", target, "Enter"},
+	}
+	for _, args := range steps {
+		if _, err := c.run(ctx, args...); err != nil {
+			return fmt.Errorf("tmux %s %s: %w", args[0], target, err)
+		}
+	}
+	return nil
+}
+
+func (c *CLIClient) ListPanes(ctx context.Context) ([]terminal.Pane, error) {
+	output, err := c.run(ctx, "list-panes", "-a", "-F", listPanesFormat)
+	if err != nil {
+		// No server means no panes, not a failure to talk to tmux.
+		if errors.Is(err, terminal.ErrMuxUnavailable) {
+			return []terminal.Pane{}, nil
+		}
+		return nil, fmt.Errorf("tmux list-panes: %w", err)
+	}
+	return parseListPanes(output)
+}
+
+func (c *CLIClient) run(ctx context.Context, args ...string) ([]byte, error) {
+	output, err := c.exec(ctx, "tmux", args...)
+	if err != nil {
+		return nil, terminal.ClassifyExecError(err, errorPatterns)
+	}
+	return output, nil
+}
+
+func (c *CLIClient) hasSession(ctx context.Context, name string) bool {
+	_, err := c.run(ctx, "has-session", "-t", exactSession(name))
+	return err == nil
+}
+
+func parseListPanes(raw []byte) ([]terminal.Pane, error) {
+	panes := make([]terminal.Pane, 0)
+	for _, line := range strings.Split(string(raw), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		paneID, err := parsePaneID(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		pane := terminal.Pane{PaneID: paneID}
+		if len(fields) > 1 {
+			pane.Workspace = fields[1]
+		}
+		if len(fields) > 2 && fields[2] != "" {
+			pane.UserVars = map[string]string{terminal.TaskIDUserVar: fields[2]}
+		}
+		if len(fields) > 3 {
+			pane.ForegroundProcess = fields[3]
+		}
+		panes = append(panes, pane)
+	}
+	return panes, nil
+}
+
+func parsePaneID(raw string) (int64, error) {
+	paneID, err := strconv.ParseInt(strings.TrimPrefix(raw, "%"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse tmux pane id %q: %w", raw, err)
+	}
+	return paneID, nil
+}
+
+func paneTarget(paneID int64) string {
+	return "%" + strconv.FormatInt(paneID, 10)
+}
+
+// exactSession prevents tmux from prefix-matching a different session name.
+func exactSession(name string) string {
+	return "=" + name
+}
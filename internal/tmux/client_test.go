@@ -0,0 +1,299 @@
+package tmux
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"term-workspaces/internal/terminal"
+	"testing"
+)
+
+type recordedCall struct {
+	name string
+	args []string
+}
+
+func TestSpawnCreatesSessionWhenMissing(t *testing.T) {
+	t.Parallel()
+
+	var calls []recordedCall
+	client := NewCLIClientWithExec(func(_ context.Context, name string, args ...string) ([]byte, error) {
+		calls = append(calls, recordedCall{name: name, args: args})
+		if args[0] == "has-session" {
+			return nil, errors.New("can't find session: task-1")
+		}
+		return []byte("%12\n"), nil
+	})
+
+	paneID, err := client.Spawn(context.Background(), terminal.SpawnOptions{Workspace: "task-1", Cwd: "/tmp/work"})
+	if err != nil {
+		t.Fatalf("Spawn returned error: %v", err)
+	}
+	if paneID != 12 {
+		t.Fatalf("expected paneID=12, got %d", paneID)
+	}
+	expected := []string{"new-session", "-d", "-s", "task-1", "-P", "-F", "#{pane_id}", "-c", "/tmp/work"}
+	if len(calls) != 2 || calls[1].name != "tmux" || !reflect.DeepEqual(calls[1].args, expected) {
+		t.Fatalf("unexpected calls: %#v", calls)
+	}
+}
+
+func TestSpawnAddsWindowToExistingSession(t *testing.T) {
+	t.Parallel()
+
+	var spawnArgs []string
+	client := NewCLIClientWithExec(func(_ context.Context, _ string, args ...string) ([]byte, error) {
+		if args[0] == "has-session" {
+			return nil, nil
+		}
+		spawnArgs = args
+		return []byte("%31\n"), nil
+	})
+
+	paneID, err := client.Spawn(context.Background(), terminal.SpawnOptions{Workspace: "task-1", Domain: "SSH:ignored"})
+	if err != nil {
+		t.Fatalf("Spawn returned error: %v", err)
+	}
+	if paneID != 31 {
+		t.Fatalf("expected paneID=31, got %d", paneID)
+	}
+	expected := []string{"new-window", "-t", "=task-1:", "-P", "-F", "#{pane_id}"}
+	if !reflect.DeepEqual(spawnArgs, expected) {
+		t.Fatalf("unexpected spawn args: %#v", spawnArgs)
+	}
+}
+
+func TestSpawnRunsArgvThenShell(t *testing.T) {
+	t.Parallel()
+
+	var spawnArgs []string
+	client := NewCLIClientWithExec(func(_ context.Context, _ string, args ...string) ([]byte, error) {
+		if args[0] == "has-session" {
+			return nil, errors.New("can't find session: task-1")
+		}
+		spawnArgs = args
+		return []byte("%2\n"), nil
+	})
+
+	opts := terminal.SpawnOptions{
+		Workspace: "task-1",
+		Argv:      []string{"codex", "resume", "abc"},
+		Env:       map[string]string{"CODEX_PROFILE": "work"},
+	}
+	if _, err := client.Spawn(context.Background(), opts); err != nil {
+		t.Fatalf("Spawn returned error: %v", err)
+	}
+	expected := []string{
+		"new-session", "-d", "-s", "task-1", "-P", "-F", "#{pane_id}", "-e", "CODEX_PROFILE=work",
+		"sh", "-c", `set -m; "$@"; exec "${SHELL:-/bin/sh}" -l`, "sh", "codex", "resume", "abc",
+	}
+	if !reflect.DeepEqual(spawnArgs, expected) {
+		t.Fatalf("unexpected spawn args: %#v", spawnArgs)
+	}
+}
+
+func TestSpawnSetsUserVarsAsPaneOptions(t *testing.T) {
+	t.Parallel()
+
+	var calls [][]string
+	client := NewCLIClientWithExec(func(_ context.Context, _ string, args ...string) ([]byte, error) {
+		calls = append(calls, args)
+		if args[0] == "has-session" {
+			return nil, nil
+		}
+		return []byte("%5\n"), nil
+	})
+
+	_, err := client.Spawn(context.Background(), terminal.SpawnOptions{
+		Workspace: "task-1",
+		UserVars:  map[string]string{terminal.TaskIDUserVar: "task-1"},
+	})
+	if err != nil {
+		t.Fatalf("Spawn returned error: %v", err)
+	}
+	expected := []string{"set-option", "-p", "-t", "%5", "@TTT_TASK_ID", "task-1"}
+	if len(calls) != 3 || !reflect.DeepEqual(calls[2], expected) {
+		t.Fatalf("unexpected calls: %#v", calls)
+	}
+}
+
+func TestActivatePaneSelectsAndSwitches(t *testing.T) {
+	t.Parallel()
+
+	var calls [][]string
+	client := NewCLIClientWithExec(func(_ context.Context, _ string, args ...string) ([]byte, error) {
+		calls = append(calls, args)
+		if args[0] == "switch-client" {
+			return nil, errors.New("no current client")
+		}
+		return nil, nil
+	})
+
+	if err := client.ActivatePane(context.Background(), 7); err != nil {
+		t.Fatalf("ActivatePane returned error: %v", err)
+	}
+	expected := [][]string{
+		{"select-window", "-t", "%7"},
+		{"select-pane", "-t", "%7"},
+		{"switch-client", "-t", "%7"},
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("unexpected calls: %#v", calls)
+	}
+}
+
+func TestActivatePaneReturnsErrorForMissingPane(t *testing.T) {
+	t.Parallel()
+
+	client := NewCLIClientWithExec(func(_ context.Context, name string, args ...string) ([]byte, error) {
+		return nil, &terminal.ExecError{Name: name, Args: args, ExitCode: 1, Stderr: "can't find pane: %7", Err: errors.New("exit status 1")}
+	})
+	if err := client.ActivatePane(context.Background(), 7); !errors.Is(err, terminal.ErrPaneNotFound) {
+		t.Fatalf("expected ErrPaneNotFound, got %v", err)
+	}
+}
+
+func TestKillPane(t *testing.T) {
+	t.Parallel()
+
+	called := false
+	client := NewCLIClientWithExec(func(_ context.Context, name string, args ...string) ([]byte, error) {
+		called = true
+		expected := []string{"kill-pane", "-t", "%91"}
+		if name != "tmux" || !reflect.DeepEqual(args, expected) {
+			t.Fatalf("unexpected call %q %#v", name, args)
+		}
+		return nil, nil
+	})
+
+	if err := client.KillPane(context.Background(), 91); err != nil {
+		t.Fatalf("KillPane returned error: %v", err)
+	}
+	if !called {
+		t.Fatalf("expected kill call")
+	}
+}
+
+func TestGetTextCapturesPane(t *testing.T) {
+	t.Parallel()
+
+	client := NewCLIClientWithExec(func(_ context.Context, _ string, args ...string) ([]byte, error) {
+		expected := []string{"capture-pane", "-p", "-t", "%4"}
+		if !reflect.DeepEqual(args, expected) {
+			t.Fatalf("unexpected args: %#v", args)
+		}
+		return []byte("~/repo $ \n"), nil
+	})
+
+	text, err := client.GetText(context.Background(), 4)
+	if err != nil {
+		t.Fatalf("GetText returned error: %v", err)
+	}
+	if text != "~/repo $ \n" {
+		t.Fatalf("unexpected text: %q", text)
+	}
+}
+
+func TestSendTextPastesThenPressesEnter(t *testing.T) {
+	t.Parallel()
+
+	var calls [][]string
+	client := NewCLIClientWithExec(func(_ context.Context, _ string, args ...string) ([]byte, error) {
+		calls = append(calls, args)
+		return nil, nil
+	})
+	if err := client.SendText(context.Background(), 4, "line one\nline two"); err != nil {
+		t.Fatalf("SendText returned error: %v", err)
+	}
+	expected := [][]string{
+		{"set-buffer", "-b", "ttt-send-text", "--", "line one\nline two"},
+		{"paste-buffer", "-p", "-d", "-b", "ttt-send-text", "-t", "%4"},
+		{"send-keys", "-t", "%4", "Enter"},
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("unexpected calls: %#v", calls)
+	}
+}
+
+func TestListPanesParsesSessions(t *testing.T) {
+	t.Parallel()
+
+	client := NewCLIClientWithExec(func(_ context.Context, _ string, args ...string) ([]byte, error) {
+		expected := []string{"list-panes", "-a", "-F", "#{pane_id}\t#{session_name}\t#{@TTT_TASK_ID}\t#{pane_current_command}"}
+		if !reflect.DeepEqual(args, expected) {
+			t.Fatalf("unexpected args: %#v", args)
+		}
+		return []byte("%1\talpha\ttask-a\tcodex\n%2\talpha\t\tzsh\n%3\tbeta\t\n"), nil
+	})
+
+	panes, err := client.ListPanes(context.Background())
+	if err != nil {
+		t.Fatalf("ListPanes returned error: %v", err)
+	}
+	expected := []terminal.Pane{
+		{PaneID: 1, Workspace: "alpha", UserVars: map[string]string{terminal.TaskIDUserVar: "task-a"}, ForegroundProcess: "codex"},
+		{PaneID: 2, Workspace: "alpha", ForegroundProcess: "zsh"},
+		{PaneID: 3, Workspace: "beta"},
+	}
+	if !reflect.DeepEqual(panes, expected) {
+		t.Fatalf("unexpected panes: %#v", panes)
+	}
+}
+
+func TestListPanesWithoutServerIsEmpty(t *testing.T) {
+	t.Parallel()
+
+	client := NewCLIClientWithExec(func(_ context.Context, name string, args ...string) ([]byte, error) {
+		return nil, &terminal.ExecError{Name: name, Args: args, ExitCode: 1, Stderr: "no server running on /tmp/tmux-501/default", Err: errors.New("exit status 1")}
+	})
+
+	panes, err := client.ListPanes(context.Background())
+	if err != nil {
+		t.Fatalf("ListPanes returned error: %v", err)
+	}
+	if len(panes) != 0 {
+		t.Fatalf("expected no panes, got %#v", panes)
+	}
+}
+
+func TestListPanesRejectsBadPaneID(t *testing.T) {
+	t.Parallel()
+
+	client := NewCLIClientWithExec(func(_ context.Context, _ string, _ ...string) ([]byte, error) {
+		return []byte("pane\talpha\n"), nil
+	})
+	if _, err := client.ListPanes(context.Background()); err == nil {
+		t.Fatalf("expected parse error")
+	}
+}
+
+func TestSplitPaneTagsNewPane(t *testing.T) {
+	t.Parallel()
+
+	var calls [][]string
+	client := NewCLIClientWithExec(func(_ context.Context, _ string, args ...string) ([]byte, error) {
+		calls = append(calls, args)
+		if args[0] == "split-window" {
+			return []byte("%9\n"), nil
+		}
+		return nil, nil
+	})
+	paneID, err := client.SplitPane(context.Background(), 4, terminal.SplitOptions{
+		Cwd:      "/tmp/repo",
+		UserVars: map[string]string{terminal.TaskIDUserVar: "task_1"},
+		Argv:     []string{"vim", "/notes/task_1.md"},
+	})
+	if err != nil {
+		t.Fatalf("SplitPane returned error: %v", err)
+	}
+	if paneID != 9 {
+		t.Fatalf("expected pane 9, got %d", paneID)
+	}
+	expected := [][]string{
+		{"split-window", "-h", "-l", "40%", "-t", "%4", "-P", "-F", "#{pane_id}", "-c", "/tmp/repo", "vim", "/notes/task_1.md"},
+		{"set-option", "-p", "-t", "%9", "@TTT_TASK_ID", "task_1"},
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("unexpected calls: %#v", calls)
+	}
+}
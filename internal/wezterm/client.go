@@ -2,44 +2,121 @@ package wezterm
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
+	"term-workspaces/internal/terminal"
+	"time"
 )
 
-type Pane struct {
-	PaneID    int64  `json:"pane_id"`
-	Workspace string `json:"workspace"`
+const (
+	defaultCallTimeout = 5 * time.Second
+	defaultRetries     = 2
+	defaultRetryDelay  = 250 * time.Millisecond
+)
+
+// errorPatterns match `wezterm cli` stderr. WezTerm reports unknown panes as
+// "pane id N not found in mux" or "invalid pane id", and a missing mux/GUI as
+// a failure to connect to its socket.
+var errorPatterns = terminal.ErrorPatterns{
+	PaneNotFound: []string{"not found in mux", "invalid pane", "no such pane"},
+	MuxUnavailable: []string{
+		"failed to connect",
+		"unable to connect",
+		"connection refused",
+		"no running wezterm",
+		"is wezterm running",
+	},
 }
 
-type Client interface {
-	Spawn(ctx context.Context, workspace, cwd string) (int64, error)
-	ActivatePane(ctx context.Context, paneID int64) error
-	KillPane(ctx context.Context, paneID int64) error
-	ListPanes(ctx context.Context) ([]Pane, error)
+// CLIClient drives WezTerm through `wezterm cli`. An empty SpawnOptions.Domain
+// spawns into WezTerm's default (local) domain. Each CLI call gets its own
+// timeout, and calls that fail because the mux is not reachable are retried a
+// limited number of times.
+type CLIClient struct {
+	exec       terminal.ExecFunc
+	timeout    time.Duration
+	retries    int
+	retryDelay time.Duration
+	// sshHosts maps an SSHMUX domain's name to the ssh destination of its
+	// host; a domain not listed is reached by its name.
+	sshHosts map[string]string
 }
 
-type ExecFunc func(ctx context.Context, name string, args ...string) ([]byte, error)
+var _ terminal.Client = (*CLIClient)(nil)
 
-type CLIClient struct {
-	exec ExecFunc
+// NewCLIClient returns a client that reaches SSHMUX domains through sshHosts,
+// which may be nil.
+func NewCLIClient(sshHosts map[string]string) *CLIClient {
+	client := NewCLIClientWithExec(terminal.DefaultExec)
+	client.sshHosts = sshHosts
+	return client
 }
 
-func NewCLIClient() *CLIClient {
-	return &CLIClient{exec: defaultExec}
+func NewCLIClientWithExec(execFn terminal.ExecFunc) *CLIClient {
+	return &CLIClient{
+		exec:       execFn,
+		timeout:    defaultCallTimeout,
+		retries:    defaultRetries,
+		retryDelay: defaultRetryDelay,
+	}
 }
 
-func NewCLIClientWithExec(execFn ExecFunc) *CLIClient {
-	return &CLIClient{exec: execFn}
+// callKind tells run which failures are safe to retry: a mux that could not
+// be reached never ran the command, but a timed-out spawn may have created a
+// pane already.
+type callKind int
+
+const (
+	callMutating callKind = iota
+	callIdempotent
+)
+
+func (c *CLIClient) run(ctx context.Context, kind callKind, args ...string) ([]byte, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, lastErr
+			case <-time.After(c.retryDelay * time.Duration(attempt)):
+			}
+		}
+
+		callCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		output, err := c.exec(callCtx, "wezterm", args...)
+		cancel()
+		if err == nil {
+			return output, nil
+		}
+
+		lastErr = terminal.ClassifyExecError(err, errorPatterns)
+		retryable := errors.Is(lastErr, terminal.ErrMuxUnavailable) ||
+			(kind == callIdempotent && errors.Is(lastErr, terminal.ErrTimeout))
+		if !retryable || ctx.Err() != nil {
+			return nil, lastErr
+		}
+	}
+	return nil, lastErr
 }
 
-func (c *CLIClient) Spawn(ctx context.Context, workspace, cwd string) (int64, error) {
-	args := []string{"cli", "spawn", "--new-window", "--workspace", workspace}
-	if strings.TrimSpace(cwd) != "" {
-		args = append(args, "--cwd", cwd)
+func (c *CLIClient) Spawn(ctx context.Context, opts terminal.SpawnOptions) (int64, error) {
+	args := []string{"cli", "spawn", "--new-window", "--workspace", opts.Workspace}
+	if strings.TrimSpace(opts.Domain) != "" {
+		args = append(args, "--domain-name", opts.Domain)
+	}
+	if strings.TrimSpace(opts.Cwd) != "" {
+		args = append(args, "--cwd", opts.Cwd)
+	}
+	if len(opts.UserVars) > 0 || len(opts.Argv) > 0 || len(opts.Env) > 0 {
+		args = append(args, "--")
+		args = append(args, terminal.ShellCommand(envSetup(opts.Env)+userVarSetup(opts.UserVars), opts.Argv)...)
 	}
-	output, err := c.exec(ctx, "wezterm", args...)
+	output, err := c.run(ctx, callMutating, args...)
 	if err != nil {
 		return 0, fmt.Errorf("wezterm spawn: %w", err)
 	}
@@ -52,8 +129,31 @@ func (c *CLIClient) Spawn(ctx context.Context, workspace, cwd string) (int64, er
 	return paneID, nil
 }
 
+// SplitPane opens a pane to the right of paneID taking 40% of its width.
+func (c *CLIClient) SplitPane(ctx context.Context, paneID int64, opts terminal.SplitOptions) (int64, error) {
+	args := []string{"cli", "split-pane", "--pane-id", strconv.FormatInt(paneID, 10), "--right", "--percent", "40"}
+	if strings.TrimSpace(opts.Cwd) != "" {
+		args = append(args, "--cwd", opts.Cwd)
+	}
+	if len(opts.Argv) > 0 {
+		args = append(args, "--")
+		args = append(args, terminal.ExecCommand(userVarSetup(opts.UserVars), opts.Argv)...)
+	}
+	output, err := c.run(ctx, callMutating, args...)
+	if err != nil {
+		return 0, fmt.Errorf("wezterm split-pane %d: %w", paneID, err)
+	}
+
+	paneIDRaw := strings.TrimSpace(string(output))
+	newPaneID, err := strconv.ParseInt(paneIDRaw, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse split-pane pane id %q: %w", paneIDRaw, err)
+	}
+	return newPaneID, nil
+}
+
 func (c *CLIClient) ActivatePane(ctx context.Context, paneID int64) error {
-	_, err := c.exec(ctx, "wezterm", "cli", "activate-pane", "--pane-id", strconv.FormatInt(paneID, 10))
+	_, err := c.run(ctx, callIdempotent, "cli", "activate-pane", "--pane-id", strconv.FormatInt(paneID, 10))
 	if err != nil {
 		return fmt.Errorf("wezterm activate-pane %d: %w", paneID, err)
 	}
@@ -61,48 +161,119 @@ func (c *CLIClient) ActivatePane(ctx context.Context, paneID int64) error {
 }
 
 func (c *CLIClient) KillPane(ctx context.Context, paneID int64) error {
-	_, err := c.exec(ctx, "wezterm", "cli", "kill-pane", "--pane-id", strconv.FormatInt(paneID, 10))
+	_, err := c.run(ctx, callMutating, "cli", "kill-pane", "--pane-id", strconv.FormatInt(paneID, 10))
 	if err != nil {
 		return fmt.Errorf("wezterm kill-pane %d: %w", paneID, err)
 	}
 	return nil
 }
 
-func (c *CLIClient) ListPanes(ctx context.Context) ([]Pane, error) {
-	output, err := c.exec(ctx, "wezterm", "cli", "list", "--format", "json")
+func (c *CLIClient) GetText(ctx context.Context, paneID int64) (string, error) {
+	output, err := c.run(ctx, callIdempotent, "cli", "get-text", "--pane-id", strconv.FormatInt(paneID, 10))
+	if err != nil {
+		return "", fmt.Errorf("wezterm get-text %d: %w", paneID, err)
+	}
+	return string(output), nil
+}
+
+func (c *CLIClient) SendText(ctx context.Context, paneID int64, text string) error {
+	pane := strconv.FormatInt(paneID, 10)
+	if _, err := c.run(ctx, callMutating, "cli", "send-text", "--pane-id", pane, "--", text); err != nil {
+		return fmt.Errorf("wezterm send-text %d: %w", paneID, err)
+	}
+	if _, err := c.run(ctx, callMutating, "cli", "send-text", "--pane-id", pane, "--no-paste", "\r"); err != nil {
+		return fmt.Errorf("wezterm send-text %d: %w", paneID, err)
+	}
+	return nil
+}
+
+func (c *CLIClient) ListPanes(ctx context.Context) ([]terminal.Pane, error) {
+	output, err := c.run(ctx, callIdempotent, "cli", "list", "--format", "json")
 	if err != nil {
 		return nil, fmt.Errorf("wezterm list: %w", err)
 	}
 	return parseListPanesJSON(output)
 }
 
-func parseListPanesJSON(raw []byte) ([]Pane, error) {
+var _ terminal.DomainPaneLister = (*CLIClient)(nil)
+
+// ListDomainPanes lists the panes held by domain itself. An SSHMUX domain is
+// asked over ssh, with `wezterm cli list` on its host: the domain's entry in
+// sshHosts, else its name, which then has to resolve as an ssh host or
+// ~/.ssh/config alias. A plain SSH domain has no remote multiplexer and never
+// detaches, so its live panes are all in ListPanes and none are returned
+// here. Other domains are not supported.
+func (c *CLIClient) ListDomainPanes(ctx context.Context, domain string) ([]terminal.Pane, error) {
+	if strings.HasPrefix(domain, "SSH:") {
+		return nil, nil
+	}
+	name, ok := strings.CutPrefix(domain, "SSHMUX:")
+	if !ok || strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("%w: %s", terminal.ErrDomainUnsupported, domain)
+	}
+	host := name
+	if configured := strings.TrimSpace(c.sshHosts[name]); configured != "" {
+		host = configured
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	output, err := c.exec(callCtx, "ssh", "-o", "BatchMode=yes", host, "wezterm", "cli", "list", "--format", "json")
+	if err != nil {
+		return nil, fmt.Errorf("wezterm list on %s: %w", host, terminal.ClassifyExecError(err, errorPatterns))
+	}
+	panes, err := parseListPanesJSON(output)
+	if err != nil {
+		return nil, err
+	}
+	for i := range panes {
+		panes[i].Domain = domain
+	}
+	return panes, nil
+}
+
+// processTitlePattern matches a pane title that is a bare program name.
+// WezTerm titles a pane after its foreground process unless the program sets
+// a title of its own, such as a shell prompt's "user@host: dir", which says
+// nothing about the process.
+var processTitlePattern = regexp.MustCompile(`^-?[A-Za-z0-9][A-Za-z0-9_.+-]*$`)
+
+func parseListPanesJSON(raw []byte) ([]terminal.Pane, error) {
 	var generic any
 	if err := json.Unmarshal(raw, &generic); err != nil {
 		return nil, fmt.Errorf("decode wezterm list json: %w", err)
 	}
 
-	entries := make([]Pane, 0)
-	walkPanes(generic, "", &entries)
+	entries := make([]terminal.Pane, 0)
+	walkPanes(generic, terminal.Pane{}, &entries)
 	return dedupePanes(entries), nil
 }
 
-func walkPanes(node any, inheritedWorkspace string, out *[]Pane) {
+func walkPanes(node any, inherited terminal.Pane, out *[]terminal.Pane) {
 	switch typed := node.(type) {
 	case map[string]any:
-		workspace := inheritedWorkspace
+		current := inherited
 		if value, ok := typed["workspace"].(string); ok && strings.TrimSpace(value) != "" {
-			workspace = value
+			current.Workspace = value
+		}
+		if value, ok := typed["domain_name"].(string); ok && strings.TrimSpace(value) != "" {
+			current.Domain = value
 		}
 		if paneID, ok := extractPaneID(typed); ok {
-			*out = append(*out, Pane{PaneID: paneID, Workspace: workspace})
+			pane := current
+			pane.PaneID = paneID
+			pane.UserVars, pane.UserVarsUnknown = extractUserVars(typed)
+			if title, ok := typed["title"].(string); ok && processTitlePattern.MatchString(title) {
+				pane.ForegroundProcess = title
+			}
+			*out = append(*out, pane)
 		}
 		for _, value := range typed {
-			walkPanes(value, workspace, out)
+			walkPanes(value, current, out)
 		}
 	case []any:
 		for _, value := range typed {
-			walkPanes(value, inheritedWorkspace, out)
+			walkPanes(value, inherited, out)
 		}
 	}
 }
@@ -127,19 +298,69 @@ func extractPaneID(node map[string]any) (int64, bool) {
 	}
 }
 
-func dedupePanes(entries []Pane) []Pane {
-	seen := map[int64]Pane{}
+// extractUserVars returns the pane's user vars and whether the listing left
+// them out; stock `wezterm cli list` output has no user_vars key.
+func extractUserVars(node map[string]any) (map[string]string, bool) {
+	value, present := node["user_vars"]
+	if !present {
+		return nil, true
+	}
+	raw, ok := value.(map[string]any)
+	if !ok || len(raw) == 0 {
+		return nil, false
+	}
+	vars := make(map[string]string, len(raw))
+	for name, value := range raw {
+		if text, ok := value.(string); ok {
+			vars[name] = text
+		}
+	}
+	return vars, false
+}
+
+// envSetup exports env in the pane's wrapper shell; `wezterm cli spawn` has
+// no flag for setting environment variables.
+func envSetup(env map[string]string) string {
+	var script strings.Builder
+	for _, name := range terminal.SortedKeys(env) {
+		script.WriteString("export " + name + "=" + terminal.ShellQuote(env[name]) + "; ")
+	}
+	return script.String()
+}
+
+// userVarSetup returns shell commands that set user vars on the pane they
+// run in. `wezterm cli` cannot set user vars on another pane, so the new pane
+// announces them itself with OSC 1337 SetUserVar.
+func userVarSetup(vars map[string]string) string {
+	var script strings.Builder
+	for _, name := range terminal.SortedKeys(vars) {
+		encoded := base64.StdEncoding.EncodeToString([]byte(vars[name]))
+		script.WriteString(`printf '\033]1337;SetUserVar=%s=%s\007' `)
+		script.WriteString(terminal.ShellQuote(name) + " " + terminal.ShellQuote(encoded) + "; ")
+	}
+	return script.String()
+}
+
+func dedupePanes(entries []terminal.Pane) []terminal.Pane {
+	seen := map[int64]terminal.Pane{}
 	for _, entry := range entries {
 		if existing, ok := seen[entry.PaneID]; ok {
 			if existing.Workspace == "" && entry.Workspace != "" {
-				seen[entry.PaneID] = entry
+				existing.Workspace = entry.Workspace
+			}
+			if existing.Domain == "" && entry.Domain != "" {
+				existing.Domain = entry.Domain
+			}
+			if existing.UserVars == nil && entry.UserVars != nil {
+				existing.UserVars = entry.UserVars
 			}
+			seen[entry.PaneID] = existing
 			continue
 		}
 		seen[entry.PaneID] = entry
 	}
 
-	result := make([]Pane, 0, len(seen))
+	result := make([]terminal.Pane, 0, len(seen))
 	for _, pane := range seen {
 		result = append(result, pane)
 	}
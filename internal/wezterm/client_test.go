@@ -3,10 +3,25 @@ package wezterm
 import (
 	"context"
 	"errors"
+	"os"
+	"os/exec"
 	"reflect"
+	"term-workspaces/internal/terminal"
 	"testing"
+	"time"
 )
 
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	// #nosec G304 -- fixture names are fixed test inputs under testdata.
+	raw, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("ReadFile(%s): %v", name, err)
+	}
+	return raw
+}
+
 func TestSpawnParsesPaneID(t *testing.T) {
 	t.Parallel()
 
@@ -21,7 +36,7 @@ func TestSpawnParsesPaneID(t *testing.T) {
 		return []byte("123\n"), nil
 	})
 
-	paneID, err := client.Spawn(context.Background(), "task-1", "/tmp/work")
+	paneID, err := client.Spawn(context.Background(), terminal.SpawnOptions{Workspace: "task-1", Cwd: "/tmp/work"})
 	if err != nil {
 		t.Fatalf("Spawn returned error: %v", err)
 	}
@@ -30,6 +45,77 @@ func TestSpawnParsesPaneID(t *testing.T) {
 	}
 }
 
+func TestSpawnPassesDomainName(t *testing.T) {
+	t.Parallel()
+
+	client := NewCLIClientWithExec(func(_ context.Context, _ string, args ...string) ([]byte, error) {
+		expected := []string{"cli", "spawn", "--new-window", "--workspace", "task-1", "--domain-name", "SSH:devbox", "--cwd", "/srv/work"}
+		if !reflect.DeepEqual(args, expected) {
+			t.Fatalf("unexpected args: %#v", args)
+		}
+		return []byte("7\n"), nil
+	})
+
+	paneID, err := client.Spawn(context.Background(), terminal.SpawnOptions{Workspace: "task-1", Cwd: "/srv/work", Domain: "SSH:devbox"})
+	if err != nil {
+		t.Fatalf("Spawn returned error: %v", err)
+	}
+	if paneID != 7 {
+		t.Fatalf("expected paneID=7, got %d", paneID)
+	}
+}
+
+func TestSpawnAnnouncesUserVars(t *testing.T) {
+	t.Parallel()
+
+	var spawnArgs []string
+	client := NewCLIClientWithExec(func(_ context.Context, _ string, args ...string) ([]byte, error) {
+		spawnArgs = args
+		return []byte("8\n"), nil
+	})
+
+	_, err := client.Spawn(context.Background(), terminal.SpawnOptions{
+		Workspace: "task-1",
+		UserVars:  map[string]string{terminal.TaskIDUserVar: "task-1"},
+	})
+	if err != nil {
+		t.Fatalf("Spawn returned error: %v", err)
+	}
+	expected := []string{
+		"cli", "spawn", "--new-window", "--workspace", "task-1", "--",
+		"sh", "-c", `printf '\033]1337;SetUserVar=%s=%s\007' 'TTT_TASK_ID' 'dGFzay0x'; exec "${SHELL:-/bin/sh}" -l`, "sh",
+	}
+	if !reflect.DeepEqual(spawnArgs, expected) {
+		t.Fatalf("unexpected args: %#v", spawnArgs)
+	}
+}
+
+func TestSpawnRunsArgvThenShell(t *testing.T) {
+	t.Parallel()
+
+	var spawnArgs []string
+	client := NewCLIClientWithExec(func(_ context.Context, _ string, args ...string) ([]byte, error) {
+		spawnArgs = args
+		return []byte("9\n"), nil
+	})
+
+	_, err := client.Spawn(context.Background(), terminal.SpawnOptions{
+		Workspace: "task-1",
+		Argv:      []string{"codex", "resume", "abc"},
+		Env:       map[string]string{"CODEX_PROFILE": "it's work"},
+	})
+	if err != nil {
+		t.Fatalf("Spawn returned error: %v", err)
+	}
+	expected := []string{
+		"cli", "spawn", "--new-window", "--workspace", "task-1", "--",
+		"sh", "-c", `export CODEX_PROFILE='it'\''s work'; set -m; "$@"; exec "${SHELL:-/bin/sh}" -l`, "sh", "codex", "resume", "abc",
+	}
+	if !reflect.DeepEqual(spawnArgs, expected) {
+		t.Fatalf("unexpected args: %#v", spawnArgs)
+	}
+}
+
 func TestActivatePane(t *testing.T) {
 	t.Parallel()
 
@@ -72,6 +158,46 @@ func TestKillPane(t *testing.T) {
 	}
 }
 
+func TestGetText(t *testing.T) {
+	t.Parallel()
+
+	client := NewCLIClientWithExec(func(_ context.Context, _ string, args ...string) ([]byte, error) {
+		expected := []string{"cli", "get-text", "--pane-id", "91"}
+		if !reflect.DeepEqual(args, expected) {
+			t.Fatalf("unexpected args: %#v", args)
+		}
+		return []byte("▌ Ask Codex\n"), nil
+	})
+
+	text, err := client.GetText(context.Background(), 91)
+	if err != nil {
+		t.Fatalf("GetText returned error: %v", err)
+	}
+	if text != "▌ Ask Codex\n" {
+		t.Fatalf("unexpected text: %q", text)
+	}
+}
+
+func TestSendTextPastesThenPressesEnter(t *testing.T) {
+	t.Parallel()
+
+	var calls [][]string
+	client := NewCLIClientWithExec(func(_ context.Context, _ string, args ...string) ([]byte, error) {
+		calls = append(calls, args)
+		return nil, nil
+	})
+	if err := client.SendText(context.Background(), 91, "continue the task"); err != nil {
+		t.Fatalf("SendText returned error: %v", err)
+	}
+	expected := [][]string{
+		{"cli", "send-text", "--pane-id", "91", "--", "continue the task"},
+		{"cli", "send-text", "--pane-id", "91", "--no-paste", "\r"},
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("unexpected calls: %#v", calls)
+	}
+}
+
 func TestListPanesParsesWorkspaceHierarchy(t *testing.T) {
 	t.Parallel()
 
@@ -98,6 +224,79 @@ func TestListPanesParsesWorkspaceHierarchy(t *testing.T) {
 	}
 }
 
+func TestListPanesParsesDomainName(t *testing.T) {
+	t.Parallel()
+
+	jsonOut := `[
+	  {"pane_id": 1, "workspace": "alpha", "domain_name": "local"},
+	  {"pane_id": 2, "workspace": "beta", "domain_name": "SSH:devbox"}
+	]`
+	client := NewCLIClientWithExec(func(_ context.Context, _ string, _ ...string) ([]byte, error) {
+		return []byte(jsonOut), nil
+	})
+
+	panes, err := client.ListPanes(context.Background())
+	if err != nil {
+		t.Fatalf("ListPanes returned error: %v", err)
+	}
+	domains := map[int64]string{}
+	for _, pane := range panes {
+		domains[pane.PaneID] = pane.Domain
+	}
+	if domains[1] != "local" || domains[2] != "SSH:devbox" {
+		t.Fatalf("unexpected pane domains: %#v", panes)
+	}
+}
+
+func TestListPanesParsesUserVars(t *testing.T) {
+	t.Parallel()
+
+	jsonOut := `[
+	  {"pane_id": 1, "workspace": "alpha", "user_vars": {"TTT_TASK_ID": "task-a", "WEZTERM_PROG": ""}},
+	  {"pane_id": 2, "workspace": "beta", "user_vars": {}}
+	]`
+	client := NewCLIClientWithExec(func(_ context.Context, _ string, _ ...string) ([]byte, error) {
+		return []byte(jsonOut), nil
+	})
+
+	panes, err := client.ListPanes(context.Background())
+	if err != nil {
+		t.Fatalf("ListPanes returned error: %v", err)
+	}
+	byID := map[int64]terminal.Pane{}
+	for _, pane := range panes {
+		byID[pane.PaneID] = pane
+	}
+	if !byID[1].BelongsToTask("task-a") || byID[1].BelongsToTask("task-b") {
+		t.Fatalf("unexpected pane 1 user vars: %#v", byID[1])
+	}
+	if byID[2].BelongsToTask("task-a") {
+		t.Fatalf("pane without user vars must not belong to a task: %#v", byID[2])
+	}
+}
+
+func TestListPanesParsesStockListOutput(t *testing.T) {
+	t.Parallel()
+
+	fixture := readFixture(t, "list.json")
+	client := NewCLIClientWithExec(func(_ context.Context, _ string, _ ...string) ([]byte, error) {
+		return fixture, nil
+	})
+
+	panes, err := client.ListPanes(context.Background())
+	if err != nil {
+		t.Fatalf("ListPanes returned error: %v", err)
+	}
+	expected := []terminal.Pane{
+		{PaneID: 0, Workspace: "default", UserVarsUnknown: true, ForegroundProcess: "zsh"},
+		{PaneID: 3, Workspace: "ttt-term-workspaces", UserVarsUnknown: true, ForegroundProcess: "codex"},
+		{PaneID: 4, Workspace: "ttt-term-workspaces", UserVarsUnknown: true},
+	}
+	if !reflect.DeepEqual(panes, expected) {
+		t.Fatalf("unexpected panes: %#v", panes)
+	}
+}
+
 func TestListPanesReturnsErrorOnBadJSON(t *testing.T) {
 	t.Parallel()
 
@@ -115,7 +314,217 @@ func TestSpawnReturnsErrorOnExecFailure(t *testing.T) {
 	client := NewCLIClientWithExec(func(_ context.Context, _ string, _ ...string) ([]byte, error) {
 		return nil, errors.New("boom")
 	})
-	if _, err := client.Spawn(context.Background(), "task-x", ""); err == nil {
+	if _, err := client.Spawn(context.Background(), terminal.SpawnOptions{Workspace: "task-x"}); err == nil {
 		t.Fatalf("expected spawn error")
 	}
 }
+
+func newTestClient(execFn terminal.ExecFunc) *CLIClient {
+	client := NewCLIClientWithExec(execFn)
+	client.retryDelay = time.Millisecond
+	return client
+}
+
+func TestActivatePaneClassifiesPaneNotFound(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	client := newTestClient(func(_ context.Context, name string, args ...string) ([]byte, error) {
+		calls++
+		return nil, &terminal.ExecError{Name: name, Args: args, ExitCode: 1, Stderr: "ERROR: pane id 91 not found in mux", Err: errors.New("exit status 1")}
+	})
+
+	err := client.ActivatePane(context.Background(), 91)
+	if !errors.Is(err, terminal.ErrPaneNotFound) {
+		t.Fatalf("expected ErrPaneNotFound, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected no retries for missing pane, got %d calls", calls)
+	}
+}
+
+func TestListPanesRetriesWhenMuxUnavailable(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	client := newTestClient(func(_ context.Context, name string, args ...string) ([]byte, error) {
+		calls++
+		if calls < 3 {
+			return nil, &terminal.ExecError{Name: name, Args: args, ExitCode: 1, Stderr: "Error: failed to connect to Socket", Err: errors.New("exit status 1")}
+		}
+		return []byte(`[{"pane_id": 4, "workspace": "alpha"}]`), nil
+	})
+
+	panes, err := client.ListPanes(context.Background())
+	if err != nil {
+		t.Fatalf("ListPanes returned error: %v", err)
+	}
+	if calls != 3 || len(panes) != 1 {
+		t.Fatalf("expected success on third attempt, calls=%d panes=%#v", calls, panes)
+	}
+}
+
+func TestListPanesGivesUpWhenMuxStaysUnavailable(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	client := newTestClient(func(_ context.Context, name string, args ...string) ([]byte, error) {
+		calls++
+		return nil, &terminal.ExecError{Name: name, Args: args, ExitCode: 1, Stderr: "Connection refused (os error 61)", Err: errors.New("exit status 1")}
+	})
+
+	_, err := client.ListPanes(context.Background())
+	if !errors.Is(err, terminal.ErrMuxUnavailable) {
+		t.Fatalf("expected ErrMuxUnavailable, got %v", err)
+	}
+	if calls != defaultRetries+1 {
+		t.Fatalf("expected %d attempts, got %d", defaultRetries+1, calls)
+	}
+}
+
+func TestSpawnDoesNotRetryTimeouts(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	client := newTestClient(func(ctx context.Context, _ string, _ ...string) ([]byte, error) {
+		calls++
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	client.timeout = 10 * time.Millisecond
+
+	_, err := client.Spawn(context.Background(), terminal.SpawnOptions{Workspace: "task-x"})
+	if !errors.Is(err, terminal.ErrTimeout) {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected a single spawn attempt after timeout, got %d", calls)
+	}
+}
+
+func TestListPanesRetriesTimeouts(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	client := newTestClient(func(ctx context.Context, _ string, _ ...string) ([]byte, error) {
+		calls++
+		if calls == 1 {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return []byte(`[]`), nil
+	})
+	client.timeout = 10 * time.Millisecond
+
+	if _, err := client.ListPanes(context.Background()); err != nil {
+		t.Fatalf("ListPanes returned error: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected retry after timeout, got %d calls", calls)
+	}
+}
+
+func TestSpawnReportsMissingCLI(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(func(_ context.Context, name string, args ...string) ([]byte, error) {
+		return nil, &terminal.ExecError{Name: name, Args: args, ExitCode: -1, Err: exec.ErrNotFound}
+	})
+	_, err := client.Spawn(context.Background(), terminal.SpawnOptions{Workspace: "task-x"})
+	if !errors.Is(err, terminal.ErrCLIMissing) {
+		t.Fatalf("expected ErrCLIMissing, got %v", err)
+	}
+}
+
+func TestSplitPaneRunsArgvWithUserVars(t *testing.T) {
+	t.Parallel()
+
+	var calls [][]string
+	client := NewCLIClientWithExec(func(_ context.Context, _ string, args ...string) ([]byte, error) {
+		calls = append(calls, args)
+		return []byte("57\n"), nil
+	})
+	paneID, err := client.SplitPane(context.Background(), 42, terminal.SplitOptions{
+		Cwd:      "/tmp/repo",
+		UserVars: map[string]string{terminal.TaskIDUserVar: "task_1"},
+		Argv:     []string{"vim", "/notes/task_1.md"},
+	})
+	if err != nil {
+		t.Fatalf("SplitPane returned error: %v", err)
+	}
+	if paneID != 57 {
+		t.Fatalf("expected pane 57, got %d", paneID)
+	}
+	expected := [][]string{{
+		"cli", "split-pane", "--pane-id", "42", "--right", "--percent", "40", "--cwd", "/tmp/repo", "--",
+		"sh", "-c", `printf '\033]1337;SetUserVar=%s=%s\007' 'TTT_TASK_ID' 'dGFza18x'; exec "$@"`, "sh",
+		"vim", "/notes/task_1.md",
+	}}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("unexpected calls: %#v", calls)
+	}
+}
+
+func TestListDomainPanesAsksSSHMuxHost(t *testing.T) {
+	t.Parallel()
+
+	client := NewCLIClientWithExec(func(_ context.Context, name string, args ...string) ([]byte, error) {
+		expected := []string{"-o", "BatchMode=yes", "devbox", "wezterm", "cli", "list", "--format", "json"}
+		if name != "ssh" || !reflect.DeepEqual(args, expected) {
+			t.Fatalf("unexpected command: %s %#v", name, args)
+		}
+		return []byte(`[{"pane_id": 4, "workspace": "ttt-x", "user_vars": {"TTT_TASK_ID": "task-1"}}]`), nil
+	})
+
+	panes, err := client.ListDomainPanes(context.Background(), "SSHMUX:devbox")
+	if err != nil {
+		t.Fatalf("ListDomainPanes: %v", err)
+	}
+	expected := []terminal.Pane{{
+		PaneID:    4,
+		Workspace: "ttt-x",
+		Domain:    "SSHMUX:devbox",
+		UserVars:  map[string]string{"TTT_TASK_ID": "task-1"},
+	}}
+	if !reflect.DeepEqual(panes, expected) {
+		t.Fatalf("unexpected panes: %#v", panes)
+	}
+}
+
+func TestListDomainPanesUsesConfiguredSSHHost(t *testing.T) {
+	t.Parallel()
+
+	var hosts []string
+	client := NewCLIClientWithExec(func(_ context.Context, name string, args ...string) ([]byte, error) {
+		hosts = append(hosts, args[2])
+		return []byte(`[]`), nil
+	})
+	// ssh_domains names are often not resolvable hosts; remote_address is.
+	client.sshHosts = map[string]string{"devbox": "me@devbox.example.com"}
+
+	for _, domain := range []string{"SSHMUX:devbox", "SSHMUX:buildbox"} {
+		if _, err := client.ListDomainPanes(context.Background(), domain); err != nil {
+			t.Fatalf("ListDomainPanes %s: %v", domain, err)
+		}
+	}
+	if !reflect.DeepEqual(hosts, []string{"me@devbox.example.com", "buildbox"}) {
+		t.Fatalf("unexpected ssh hosts: %#v", hosts)
+	}
+}
+
+func TestListDomainPanesWithoutRemoteMux(t *testing.T) {
+	t.Parallel()
+
+	client := NewCLIClientWithExec(func(_ context.Context, name string, args ...string) ([]byte, error) {
+		t.Fatalf("unexpected command: %s %#v", name, args)
+		return nil, nil
+	})
+
+	// Plain SSH domains never detach, so there is nothing beyond ListPanes.
+	if panes, err := client.ListDomainPanes(context.Background(), "SSH:devbox"); err != nil || len(panes) != 0 {
+		t.Fatalf("expected no panes for a plain SSH domain, got %#v, %v", panes, err)
+	}
+	if _, err := client.ListDomainPanes(context.Background(), "unix"); !errors.Is(err, terminal.ErrDomainUnsupported) {
+		t.Fatalf("expected ErrDomainUnsupported, got %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package wezterm
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// LuaPluginOptions are the defaults baked into the generated task switcher.
+// Every field can still be overridden from wezterm.lua via apply_to_config.
+type LuaPluginOptions struct {
+	TTTPath string
+	DBPath  string
+	Key     string
+	Mods    string
+	Title   string
+	// Label is the choice label format; {alias}, {task}, {repo} and {status}
+	// are substituted per task.
+	Label string
+}
+
+func DefaultLuaPluginOptions() LuaPluginOptions {
+	return LuaPluginOptions{
+		TTTPath: "ttt",
+		Key:     "t",
+		Mods:    "LEADER",
+		Title:   "ttt tasks",
+		Label:   "{alias}  [{status}]",
+	}
+}
+
+var luaPluginTemplate = template.Must(template.New("ttt.lua").Funcs(template.FuncMap{
+	"lua": luaString,
+}).Parse(`-- ttt task switcher for WezTerm.
+-- Generated by ` + "`ttt wezterm export-lua`" + `; regenerate instead of editing.
+--
+-- Usage in wezterm.lua:
+--   local ttt = require("ttt")
+--   ttt.apply_to_config(config)
+local wezterm = require("wezterm")
+local act = wezterm.action
+
+local M = {}
+
+M.options = {
+  ttt = {{ lua .TTTPath }},
+  db = {{ lua .DBPath }},
+  key = {{ lua .Key }},
+  mods = {{ lua .Mods }},
+  title = {{ lua .Title }},
+  label = {{ lua .Label }},
+}
+
+local function run_ttt(args)
+  local argv = { M.options.ttt }
+  for _, arg in ipairs(args) do
+    table.insert(argv, arg)
+  end
+  if M.options.db ~= "" then
+    table.insert(argv, "--db")
+    table.insert(argv, M.options.db)
+  end
+  return wezterm.run_child_process(argv)
+end
+
+-- Prefer PR aliases so choices show canonical review identity first.
+local function primary_alias(aliases)
+  if aliases == nil or #aliases == 0 then
+    return nil
+  end
+  for _, alias in ipairs(aliases) do
+    if alias.alias_type == "pr" then
+      return alias
+    end
+  end
+  return aliases[1]
+end
+
+local function open_session_args(alias, session)
+  local args = { "task", "open-session", "--repo", alias.repo }
+  if alias.alias_type == "pr" then
+    table.insert(args, "--pr")
+    table.insert(args, tostring(alias.pr_number))
+  else
+    table.insert(args, "--branch")
+    table.insert(args, alias.branch)
+  end
+  if session ~= nil and session.cwd ~= nil and session.cwd ~= "" then
+    table.insert(args, "--cwd")
+    table.insert(args, session.cwd)
+  end
+  return args
+end
+
+local function render_label(entry, alias)
+  local status = "none"
+  if entry.session ~= nil then
+    status = entry.session.status
+  end
+  local values = {
+    alias = alias.alias_value,
+    task = entry.task.ID,
+    repo = alias.repo,
+    status = status,
+  }
+  return (M.options.label:gsub("{(%w+)}", function(name)
+    return values[name] or ""
+  end))
+end
+
+function M.load_choices()
+  local ok, stdout, stderr = run_ttt({ "task", "dashboard", "--json" })
+  if not ok then
+    wezterm.log_error("ttt task dashboard failed: " .. stderr)
+    return {}, {}
+  end
+
+  local payload = wezterm.json_parse(stdout)
+  local choices = {}
+  local commands = {}
+  for _, entry in ipairs(payload.tasks or {}) do
+    local alias = primary_alias(entry.aliases)
+    if alias ~= nil then
+      table.insert(choices, { id = entry.task.ID, label = render_label(entry, alias) })
+      commands[entry.task.ID] = open_session_args(alias, entry.session)
+    end
+  end
+  return choices, commands
+end
+
+function M.switcher_action()
+  return wezterm.action_callback(function(window, pane)
+    local choices, commands = M.load_choices()
+    window:perform_action(
+      act.InputSelector({
+        title = M.options.title,
+        fuzzy = true,
+        choices = choices,
+        action = wezterm.action_callback(function(_, _, id, _)
+          if id == nil or commands[id] == nil then
+            return
+          end
+          local ok, _, stderr = run_ttt(commands[id])
+          if not ok then
+            wezterm.log_error("ttt task open-session failed: " .. stderr)
+          end
+        end),
+      }),
+      pane
+    )
+  end)
+end
+
+function M.apply_to_config(config, opts)
+  for name, value in pairs(opts or {}) do
+    M.options[name] = value
+  end
+  config.keys = config.keys or {}
+  table.insert(config.keys, {
+    key = M.options.key,
+    mods = M.options.mods,
+    action = M.switcher_action(),
+  })
+end
+
+return M
+`))
+
+// RenderLuaPlugin renders the task switcher Lua module.
+func RenderLuaPlugin(opts LuaPluginOptions) ([]byte, error) {
+	if strings.TrimSpace(opts.TTTPath) == "" {
+		return nil, fmt.Errorf("ttt path is required")
+	}
+	if strings.TrimSpace(opts.Key) == "" {
+		return nil, fmt.Errorf("key binding is required")
+	}
+
+	var buf bytes.Buffer
+	if err := luaPluginTemplate.Execute(&buf, opts); err != nil {
+		return nil, fmt.Errorf("render lua plugin: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// luaString quotes value as a Lua string literal. Control bytes use Lua's
+// decimal escapes; UTF-8 text is kept as-is since Lua strings are bytes.
+func luaString(value string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c < 0x20 || c == 0x7f:
+			b.WriteString(`\` + fmt.Sprintf("%03d", c))
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
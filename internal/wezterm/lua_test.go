@@ -0,0 +1,77 @@
+package wezterm
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files under testdata")
+
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := os.WriteFile(path, got, 0o600); err != nil {
+			t.Fatalf("write golden %s: %v", path, err)
+		}
+	}
+
+	// #nosec G304 -- golden file names are fixed test inputs under testdata.
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden %s: %v (run go test -update to create it)", path, err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("generated output does not match %s (run go test -update after reviewing):\n%s", path, got)
+	}
+}
+
+func TestRenderLuaPluginDefaults(t *testing.T) {
+	t.Parallel()
+
+	got, err := RenderLuaPlugin(DefaultLuaPluginOptions())
+	if err != nil {
+		t.Fatalf("RenderLuaPlugin returned error: %v", err)
+	}
+	assertGolden(t, "ttt_default.lua.golden", got)
+}
+
+func TestRenderLuaPluginCustomBindingAndLabels(t *testing.T) {
+	t.Parallel()
+
+	got, err := RenderLuaPlugin(LuaPluginOptions{
+		TTTPath: "/opt/homebrew/bin/ttt",
+		DBPath:  `/Users/me/Library/Application Support/ttt/state.db`,
+		Key:     "p",
+		Mods:    "CMD|SHIFT",
+		Title:   `Tasks "mine"`,
+		Label:   "{repo} {alias} ({task})",
+	})
+	if err != nil {
+		t.Fatalf("RenderLuaPlugin returned error: %v", err)
+	}
+	assertGolden(t, "ttt_custom.lua.golden", got)
+}
+
+func TestRenderLuaPluginRequiresKey(t *testing.T) {
+	t.Parallel()
+
+	opts := DefaultLuaPluginOptions()
+	opts.Key = ""
+	if _, err := RenderLuaPlugin(opts); err == nil {
+		t.Fatalf("expected error for empty key")
+	}
+}
+
+func TestLuaStringEscapes(t *testing.T) {
+	t.Parallel()
+
+	got := luaString("a\"b\\c\nd\x01é")
+	want := `"a\"b\\c\nd\001é"`
+	if got != want {
+		t.Fatalf("luaString = %s, want %s", got, want)
+	}
+}